@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 
@@ -11,7 +12,9 @@ import (
 	"github.com/manifoldco/promptui"
 	"github.com/transifex/cli/internal/txlib"
 	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/cassette"
 	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
 	"github.com/urfave/cli/v2"
 )
 
@@ -47,10 +50,203 @@ func Main() {
 			Usage:   "Path to CA certificate bundle file",
 			EnvVars: []string{"TX_CACERT"},
 		},
+		&cli.BoolFlag{
+			Name:    "debug-http",
+			Aliases: []string{"verbose"},
+			Usage: "Print every API request and response to stderr " +
+				"(credentials are redacted)",
+			EnvVars: []string{"TX_DEBUG"},
+		},
+		&cli.StringFlag{
+			Name: "debug-http-har",
+			Usage: "Save every API request and response to `FILE` in HAR " +
+				"format (credentials are redacted)",
+			EnvVars: []string{"TX_DEBUG_HAR"},
+		},
+		&cli.StringFlag{
+			Name: "record-cassette",
+			Usage: "Record every API request and response to `FILE` so that " +
+				"tests can replay them offline",
+			EnvVars: []string{"TX_RECORD_CASSETTE"},
+			Hidden:  true,
+		},
+	}
+
+	// Shared by all the API connections of the command that runs
+	var httpTracer *jsonapi.HttpTracer
+	var recordedCassette *cassette.Cassette
+	debugFilesSaved := false
+	saveDebugFiles := func(c *cli.Context) error {
+		if debugFilesSaved {
+			return nil
+		}
+		debugFilesSaved = true
+		err := txlib.SaveHttpTrace(httpTracer, c.String("debug-http-har"))
+		if err != nil {
+			return fmt.Errorf("could not save HTTP trace: %w", err)
+		}
+		if recordedCassette != nil {
+			err = recordedCassette.Save(c.String("record-cassette"))
+			if err != nil {
+				return fmt.Errorf("could not save cassette: %w", err)
+			}
+		}
+		return nil
+	}
+	getClient := func(c *cli.Context) (http.Client, error) {
+		client, err := txlib.GetClient(c.String("cacert"))
+		if err == nil && recordedCassette != nil {
+			client.Transport = cassette.NewRecorder(
+				client.Transport, recordedCassette,
+			)
+		}
+		return client, err
+	}
+	// Connection to the API with the settings that all commands share
+	newConnection := func(
+		c *cli.Context,
+		cfg *config.Config,
+		hostname, token string,
+		client http.Client,
+	) jsonapi.Connection {
+		return jsonapi.Connection{
+			Host:        hostname,
+			Token:       token,
+			Client:      client,
+			RateLimiter: txlib.GetRateLimiter(cfg, c.String("hostname")),
+			RetryPolicy: txlib.GetRetryPolicy(cfg, c.String("hostname")),
+			Tracer:      httpTracer,
+			Headers: map[string]string{
+				"Integration": "txclient",
+			},
+		}
+	}
+	// Connection to the API for commands that need one
+	getApi := func(c *cli.Context, cfg *config.Config) (jsonapi.Connection, error) {
+		hostname, token, err := txlib.GetHostAndToken(
+			cfg, c.String("hostname"), c.String("token"),
+		)
+		if err != nil {
+			return jsonapi.Connection{}, fmt.Errorf(
+				"error getting API token: %w", err,
+			)
+		}
+		client, err := getClient(c)
+		if err != nil {
+			return jsonapi.Connection{}, fmt.Errorf(
+				"error getting HTTP client configuration: %w", err,
+			)
+		}
+		return newConnection(c, cfg, hostname, token, client), nil
+	}
+	// Identify a source string either by its id or by resource id and key
+	getStringArguments := func(c *cli.Context) (string, string, string, error) {
+		switch c.Args().Len() {
+		case 1:
+			return c.Args().First(), "", "", nil
+		case 2:
+			return "", c.Args().Get(0), c.Args().Get(1), nil
+		default:
+			return "", "", "", errors.New(
+				"please provide either a string id or a resource id and a key",
+			)
+		}
+	}
+	// Flags for the settings of a project, shared by 'create' and 'update'
+	projectSettingsFlags := func() []cli.Flag {
+		flags := []cli.Flag{
+			&cli.BoolFlag{
+				Name: "private",
+				Usage: "Whether the project is private; new projects are, " +
+					"unless created with '--private=false' and --repository-url",
+			},
+			&cli.BoolFlag{
+				Name:  "archived",
+				Usage: "Whether the project is archived",
+			},
+			&cli.StringFlag{
+				Name:  "tags",
+				Usage: "Replace the tags with these (comma separated, '' for none)",
+			},
+			&cli.BoolFlag{
+				Name:  "tm-fillup",
+				Usage: "Whether to fill up translations from translation memory",
+			},
+		}
+		for _, flag := range [][2]string{
+			{"name", "Set the name"},
+			{"description", "Set the short description"},
+			{"long-description", "Set the long description"},
+			{"instructions-url", "Set the URL of the instructions for translators"},
+			{"homepage-url", "Set the URL of the project's homepage"},
+			{"repository-url", "Set the URL of the project's source code"},
+			{"license", "Set the license of an open source project"},
+		} {
+			flags = append(flags, &cli.StringFlag{Name: flag[0], Usage: flag[1]})
+		}
+		return append(flags, &cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the project as JSON",
+		})
+	}
+	getProjectSettings := func(c *cli.Context) txapi.ProjectUpdate {
+		var settings txapi.ProjectUpdate
+		for name, target := range map[string]**string{
+			"name":             &settings.Name,
+			"description":      &settings.Description,
+			"long-description": &settings.LongDescription,
+			"instructions-url": &settings.InstructionsURL,
+			"homepage-url":     &settings.HomepageURL,
+			"repository-url":   &settings.RepositoryURL,
+			"license":          &settings.License,
+		} {
+			if c.IsSet(name) {
+				value := c.String(name)
+				*target = &value
+			}
+		}
+		for name, target := range map[string]**bool{
+			"private":   &settings.Private,
+			"archived":  &settings.Archived,
+			"tm-fillup": &settings.TMFillup,
+		} {
+			if c.IsSet(name) {
+				value := c.Bool(name)
+				*target = &value
+			}
+		}
+		if c.IsSet("tags") {
+			tags := []string{}
+			for _, tag := range strings.Split(c.String("tags"), ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			settings.Tags = &tags
+		}
+		return settings
 	}
 	app := &cli.App{
 		Version:                txlib.Version,
 		UseShortOptionHandling: true,
+		Before: func(c *cli.Context) error {
+			httpTracer = txlib.GetHttpTracer(
+				c.Bool("debug-http"), c.String("debug-http-har"),
+			)
+			if c.String("record-cassette") != "" {
+				recordedCassette = cassette.New()
+			}
+			return nil
+		},
+		After: saveDebugFiles,
+		// Commands that fail exit before 'After' runs, but that's when the
+		// debug files are most useful
+		ExitErrHandler: func(c *cli.Context, err error) {
+			if saveErr := saveDebugFiles(c); saveErr != nil {
+				fmt.Fprintln(os.Stderr, errorColor(fmt.Sprint(saveErr)))
+			}
+			cli.HandleExitCoder(err)
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "migrate",
@@ -64,13 +260,14 @@ func Main() {
 						return cli.Exit(err, 1)
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, err := getClient(c)
 					if err != nil {
 						return cli.Exit(err, 1)
 					}
 
 					api := jsonapi.Connection{
 						Client: client,
+						Tracer: httpTracer,
 					}
 
 					backUpFilePath, err := txlib.MigrateLegacyConfigFile(&cfg,
@@ -89,7 +286,7 @@ func Main() {
 			},
 			{
 				Name:  "merge",
-				Usage: "tx merge [options] [resource_id]",
+				Usage: "tx merge [options] [resource_id...]",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name: "branch",
@@ -112,17 +309,32 @@ func Main() {
 						Name:  "skip",
 						Usage: "Whether to skip on errors",
 					},
+					&cli.StringFlag{
+						Name:    "resources",
+						Aliases: []string{"r"},
+						Usage:   "Specify which resources you want to merge",
+					},
+					&cli.BoolFlag{
+						Name: "preview",
+						Usage: "Only show which strings were added, changed or " +
+							"deleted on each side and which conflict",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the preview as JSON",
+					},
+					&cli.IntFlag{
+						Name:    "workers",
+						Usage:   "How many parallel workers to use (max 20)",
+						Aliases: []string{"w"},
+						Value:   5,
+					},
 					&cli.BoolFlag{
 						Name:  "silent",
 						Usage: "Whether to reduce verbosity of the output",
 					},
 				},
 				Action: func(c *cli.Context) error {
-					if c.Args().Len() != 1 {
-						return cli.Exit(errorColor("Please provide one resource"), 1)
-					}
-
-					resourceId := c.Args().First()
 					cfg, err := config.LoadFromPaths(
 						c.String("root-config"),
 						c.String("config"),
@@ -149,7 +361,7 @@ func Main() {
 						)
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, err := getClient(c)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -160,24 +372,33 @@ func Main() {
 						)
 					}
 
-					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
+					api := newConnection(c, &cfg, hostname, token, client)
+
+					resourceIds := c.Args().Slice()
+					if c.String("resources") != "" {
+						resourceIds = append(
+							resourceIds,
+							strings.Split(c.String("resources"), ",")...,
+						)
+					}
+
+					workers := c.Int("workers")
+					if workers > 20 {
+						workers = 20
 					}
 
 					args := txlib.MergeCommandArguments{
-						ResourceId:         resourceId,
+						ResourceIds:        resourceIds,
 						Branch:             c.String("branch"),
 						ConflictResolution: c.String("conflict-resolution"),
 						Force:              c.Bool("force"),
 						Skip:               c.Bool("skip"),
 						Silent:             c.Bool("silent"),
+						Workers:            workers,
+						Preview:            c.Bool("preview"),
+						Json:               c.Bool("json"),
 					}
-					err = txlib.MergeCommand(&cfg, api, args)
+					err = txlib.MergeCommand(&cfg, api, args, os.Stdout)
 					if err != nil {
 						return cli.Exit(err, 1)
 					}
@@ -187,6 +408,11 @@ func Main() {
 			{
 				Name:  "push",
 				Usage: "tx push [options] [resource_id...]",
+				Description: "Local files whose language code is not a " +
+					"Transifex language are skipped and listed on stderr " +
+					"after pushing. Codes are checked against all the " +
+					"languages Transifex supports, not only the target " +
+					"languages of the project.",
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
 						Name:    "source",
@@ -218,6 +444,12 @@ func Main() {
 							"this option, for example, when cloning a Git " +
 							"repository.",
 					},
+					&cli.StringFlag{
+						Name: "changed-since",
+						Usage: "Only push the resources whose files changed " +
+							"in git since this ref (commit, branch or tag), " +
+							"including uncommitted changes",
+					},
 					&cli.BoolFlag{
 						Name:    "all",
 						Aliases: []string{"a"},
@@ -297,7 +529,7 @@ func Main() {
 						)
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, err := getClient(c)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -308,14 +540,7 @@ func Main() {
 						)
 					}
 
-					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
-					}
+					api := newConnection(c, &cfg, hostname, token, client)
 
 					resourceIds := c.Args().Slice()
 					if c.String("resources") != "" {
@@ -352,6 +577,7 @@ func Main() {
 						Silent:               c.Bool("silent"),
 						ReplaceEditedStrings: c.Bool("replace-edited-strings"),
 						KeepTranslations:     c.Bool("keep-translations"),
+						ChangedSince:         c.String("changed-since"),
 					}
 
 					if args.All && len(args.Languages) > 0 {
@@ -504,6 +730,21 @@ func Main() {
 						Usage: "Generate mock string translations",
 						Value: false,
 					},
+					&cli.BoolFlag{
+						Name: "commit",
+						Usage: "Commit the pulled files to git, with a " +
+							"message that summarizes them",
+					},
+					&cli.StringFlag{
+						Name: "commit-message-template",
+						Usage: "Go template for the commit message; can use " +
+							"{{.Resources}}, {{.Languages}}, {{.Files}} " +
+							"and {{.Details}}",
+					},
+					&cli.StringFlag{
+						Name:  "branch-name",
+						Usage: "Create this git branch for the commit",
+					},
 				},
 				Action: func(c *cli.Context) error {
 					cfg, err := config.LoadFromPaths(c.String("root-config"),
@@ -519,18 +760,11 @@ func Main() {
 						return err
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, err := getClient(c)
 					if err != nil {
 						return err
 					}
-					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
-					}
+					api := newConnection(c, &cfg, hostname, token, client)
 
 					resourceIds := c.Args().Slice()
 					if c.String("resources") != "" {
@@ -563,6 +797,20 @@ func Main() {
 						Workers:           workers,
 						Silent:            c.Bool("silent"),
 						Pseudo:            c.Bool("pseudo"),
+						Commit:            c.Bool("commit"),
+						CommitMessageTemplate: c.String(
+							"commit-message-template",
+						),
+						BranchName: c.String("branch-name"),
+					}
+
+					if !arguments.Commit && (arguments.CommitMessageTemplate != "" ||
+						arguments.BranchName != "") {
+						return cli.Exit(errorColor(
+							"It doesn't make sense to use the "+
+								"'--commit-message-template' or '--branch-name' "+
+								"flag without the '--commit' flag",
+						), 1)
 					}
 
 					if c.Bool("xliff") && c.Bool("json") {
@@ -683,13 +931,7 @@ func Main() {
 						if err != nil {
 							return cli.Exit(err, 1)
 						}
-						api := jsonapi.Connection{
-							Host:  hostname,
-							Token: token,
-							Headers: map[string]string{
-								"Integration": "txclient",
-							},
-						}
+						api := newConnection(c, &cfg, hostname, token, http.Client{})
 						err = txlib.AddCommandInteractive(&cfg, api)
 						if err != nil {
 							if err == promptui.ErrInterrupt {
@@ -794,7 +1036,7 @@ func Main() {
 									1,
 								)
 							}
-							client, err := txlib.GetClient(c.String("cacert"))
+							client, err := getClient(c)
 							if err != nil {
 								return cli.Exit(
 									errorColor(
@@ -804,14 +1046,7 @@ func Main() {
 									1,
 								)
 							}
-							api := jsonapi.Connection{
-								Host:   hostname,
-								Token:  token,
-								Client: client,
-								Headers: map[string]string{
-									"Integration": "txclient",
-								},
-							}
+							api := newConnection(c, &cfg, hostname, token, client)
 
 							projectUrls := c.Args().Slice()
 							if len(projectUrls) == 0 {
@@ -908,19 +1143,12 @@ func Main() {
 						return err
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, err := getClient(c)
 					if err != nil {
 						return err
 					}
 
-					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
-					}
+					api := newConnection(c, &cfg, hostname, token, client)
 
 					// Get extra resource ids
 					resourceIds := c.Args().Slice()
@@ -1012,19 +1240,12 @@ func Main() {
 						return err
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, err := getClient(c)
 					if err != nil {
 						return err
 					}
 
-					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
-					}
+					api := newConnection(c, &cfg, hostname, token, client)
 
 					// Get extra resource ids
 					resourceIds := c.Args().Slice()
@@ -1048,6 +1269,986 @@ func Main() {
 					return nil
 				},
 			},
+			{
+				Name:  "strings",
+				Usage: "List, inspect and update the source strings of a resource",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "list",
+						Usage:     "List the source strings of a resource",
+						ArgsUsage: "<resource_id>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "key",
+								Usage: "Only show the string with this key",
+							},
+							&cli.StringSliceFlag{
+								Name:  "tag",
+								Usage: "Only show strings with this tag (can be repeated)",
+							},
+							&cli.StringFlag{
+								Name:  "modified-after",
+								Usage: "Only show strings modified after this ISO 8601 date",
+							},
+							&cli.StringFlag{
+								Name:  "modified-before",
+								Usage: "Only show strings modified before this ISO 8601 date",
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Show at most this many strings",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the strings as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.Exit(errorColor("Please provide one resource"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.StringsListCommand(&cfg, api, txlib.StringsListArguments{
+								ResourceId: c.Args().First(),
+								Filter: txapi.ResourceStringsFilter{
+									Key:            c.String("key"),
+									Tags:           c.StringSlice("tag"),
+									ModifiedAfter:  c.String("modified-after"),
+									ModifiedBefore: c.String("modified-before"),
+								},
+								Limit: c.Int("limit"),
+								Json:  c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "show",
+						Usage:     "Show everything about a source string",
+						ArgsUsage: "<string_id> | <resource_id> <key>",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the string as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							stringId, resourceId, key, err := getStringArguments(c)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.StringsShowCommand(&cfg, api, txlib.StringsShowArguments{
+								StringId:   stringId,
+								ResourceId: resourceId,
+								Key:        key,
+								Json:       c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name: "update",
+						Usage: "Update the tags, context, character limit or " +
+							"comments of a source string",
+						ArgsUsage: "<string_id> | <resource_id> <key>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "tags",
+								Usage: "Replace the tags with these (comma separated, '' for none)",
+							},
+							&cli.StringSliceFlag{
+								Name:  "add-tag",
+								Usage: "Add a tag (can be repeated)",
+							},
+							&cli.StringSliceFlag{
+								Name:  "remove-tag",
+								Usage: "Remove a tag (can be repeated)",
+							},
+							&cli.StringFlag{
+								Name:  "context",
+								Usage: "Set the context",
+							},
+							&cli.IntFlag{
+								Name:  "character-limit",
+								Usage: "Set the character limit (0 to remove it)",
+							},
+							&cli.StringFlag{
+								Name:  "developer-comment",
+								Usage: "Set the developer comment",
+							},
+							&cli.StringFlag{
+								Name:  "instructions",
+								Usage: "Set the instructions for translators",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the updated string as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							stringId, resourceId, key, err := getStringArguments(c)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							var update txapi.ResourceStringUpdate
+							if c.IsSet("tags") {
+								tags := []string{}
+								for _, tag := range strings.Split(c.String("tags"), ",") {
+									if tag = strings.TrimSpace(tag); tag != "" {
+										tags = append(tags, tag)
+									}
+								}
+								update.Tags = &tags
+							}
+							if c.IsSet("context") {
+								value := c.String("context")
+								update.Context = &value
+							}
+							if c.IsSet("character-limit") {
+								value := c.Int("character-limit")
+								update.CharacterLimit = &value
+							}
+							if c.IsSet("developer-comment") {
+								value := c.String("developer-comment")
+								update.DeveloperComment = &value
+							}
+							if c.IsSet("instructions") {
+								value := c.String("instructions")
+								update.Instructions = &value
+							}
+
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.StringsUpdateCommand(&cfg, api, txlib.StringsUpdateArguments{
+								StringId:   stringId,
+								ResourceId: resourceId,
+								Key:        key,
+								Update:     update,
+								AddTags:    c.StringSlice("add-tag"),
+								RemoveTags: c.StringSlice("remove-tag"),
+								Json:       c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:    "projects",
+				Aliases: []string{"project"},
+				Usage:   "List, create and configure projects",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "create",
+						Usage: "Create a project",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:     "organization",
+								Aliases:  []string{"o"},
+								Usage:    "Slug of the organization to create the project in",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "slug",
+								Usage:    "Slug of the project",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "source-language",
+								Usage:    "Code of the project's source language",
+								Required: true,
+							},
+						}, projectSettingsFlags()...),
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 0 {
+								return cli.Exit(errorColor("Unexpected arguments"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.ProjectCreateCommand(api, txlib.ProjectCreateArguments{
+								OrganizationSlug:   c.String("organization"),
+								Slug:               c.String("slug"),
+								SourceLanguageCode: c.String("source-language"),
+								Settings:           getProjectSettings(c),
+								Json:               c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "update",
+						Usage:     "Change the settings of a project",
+						ArgsUsage: "<project>",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+						}, projectSettingsFlags()...),
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.Exit(errorColor("Please provide one project"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.ProjectUpdateCommand(&cfg, api, txlib.ProjectUpdateArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								Update:           getProjectSettings(c),
+								Json:             c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name:  "list",
+						Usage: "List the projects you have access to",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Only list the projects of this organization",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the projects as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 0 {
+								return cli.Exit(errorColor("Unexpected arguments"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.ProjectsListCommand(&cfg, api, txlib.ProjectsListArguments{
+								OrganizationSlug: c.String("organization"),
+								Json:             c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "resources",
+				Usage: "List the resources of a project and update their metadata",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "list",
+						Usage:     "List the resources of a project",
+						ArgsUsage: "<project>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the resources as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.Exit(errorColor("Please provide one project"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.ResourcesListCommand(&cfg, api, txlib.ResourcesListArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								Json:             c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name: "sync-meta",
+						Usage: "Apply the name, categories, priority and " +
+							"accept_translations of the local configuration's " +
+							"resources to Transifex",
+						ArgsUsage: "[resource ids...]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "resources",
+								Aliases: []string{"r"},
+								Usage:   "Specify which resources to update",
+							},
+							&cli.StringFlag{
+								Name: "branch",
+								Usage: "Update the resources of a specific branch (use " +
+									"empty argument '' to use the current branch, if " +
+									"it can be determined)",
+								Value: "-1",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Only print what would change",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							resourceIds := c.Args().Slice()
+							if c.String("resources") != "" {
+								resourceIds = append(
+									resourceIds,
+									strings.Split(c.String("resources"), ",")...,
+								)
+							}
+							err = txlib.ResourcesSyncMetaCommand(&cfg, api, txlib.ResourcesSyncMetaArguments{
+								ResourceIds: resourceIds,
+								Branch:      c.String("branch"),
+								DryRun:      c.Bool("dry-run"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "languages",
+				Usage: "List, add and remove the languages of a project",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Add target languages to a project",
+						ArgsUsage: "<project> [<language codes>]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+							&cli.BoolFlag{
+								Name: "sync-from-config",
+								Usage: "Add the languages that the project's resources " +
+									"have local translation files for",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() < 1 {
+								return cli.Exit(errorColor("Please provide a project"), 1)
+							}
+							var languageCodes []string
+							for _, argument := range c.Args().Tail() {
+								for _, code := range strings.Split(argument, ",") {
+									if code = strings.TrimSpace(code); code != "" {
+										languageCodes = append(languageCodes, code)
+									}
+								}
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.LanguagesAddCommand(&cfg, api, txlib.LanguagesAddArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								LanguageCodes:    languageCodes,
+								SyncFromConfig:   c.Bool("sync-from-config"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "remove",
+						Usage:     "Remove target languages, and their translations, from a project",
+						ArgsUsage: "<project> [<language codes>]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+							&cli.BoolFlag{
+								Name: "sync-from-config",
+								Usage: "Remove the languages that none of the project's " +
+									"resources have local translation files for, " +
+									"unless they have translations on Transifex",
+							},
+							&cli.BoolFlag{
+								Name:  "no-interactive",
+								Usage: "Don't ask for confirmation",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() < 1 {
+								return cli.Exit(errorColor("Please provide a project"), 1)
+							}
+							var languageCodes []string
+							for _, argument := range c.Args().Tail() {
+								for _, code := range strings.Split(argument, ",") {
+									if code = strings.TrimSpace(code); code != "" {
+										languageCodes = append(languageCodes, code)
+									}
+								}
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.LanguagesRemoveCommand(&cfg, api, txlib.LanguagesRemoveArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								LanguageCodes:    languageCodes,
+								SyncFromConfig:   c.Bool("sync-from-config"),
+								NoInteractive:    c.Bool("no-interactive"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "list",
+						Usage:     "List the languages of a project",
+						ArgsUsage: "<project>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the languages as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.Exit(errorColor("Please provide one project"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.LanguagesListCommand(&cfg, api, txlib.LanguagesListArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								Json:             c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "branches",
+				Usage: "List and clean up the resources pushed for git branches",
+				Subcommands: []*cli.Command{
+					{
+						Name: "list",
+						Usage: "List the branches that resources were pushed " +
+							"for, with their base and last modification",
+						ArgsUsage: "[<project>]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the branches as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() > 1 {
+								return cli.Exit(errorColor("Please provide at most one project"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.BranchesListCommand(&cfg, api, txlib.BranchesListArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								Json:             c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name: "prune",
+						Usage: "Delete the resources of branches that no longer " +
+							"exist in the git repository",
+						ArgsUsage: "[<project>]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "organization",
+								Aliases: []string{"o"},
+								Usage:   "Organization of the project, if not in the local configuration",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Only print what would be deleted",
+							},
+							&cli.BoolFlag{
+								Name:  "no-interactive",
+								Usage: "Don't ask for confirmation",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() > 1 {
+								return cli.Exit(errorColor("Please provide at most one project"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.BranchesPruneCommand(&cfg, api, txlib.BranchesPruneArguments{
+								Project:          c.Args().First(),
+								OrganizationSlug: c.String("organization"),
+								DryRun:           c.Bool("dry-run"),
+								NoInteractive:    c.Bool("no-interactive"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name: "check",
+				Usage: "Report the source files that changed since they " +
+					"were last pushed",
+				ArgsUsage: "[resource_id...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "resources",
+						Aliases: []string{"r"},
+						Usage:   "Resource ids to check, separated by commas",
+					},
+					&cli.BoolFlag{
+						Name:    "translation",
+						Aliases: []string{"t"},
+						Usage:   "Also check the translation files",
+					},
+					&cli.StringFlag{
+						Name: "mode",
+						Usage: "'warn' to only print a warning, 'block' to " +
+							"fail (default: 'check_mode' of the configuration, " +
+							"or 'warn')",
+					},
+					&cli.BoolFlag{
+						Name: "hook",
+						Usage: "Run from a git hook: ignore the hook's " +
+							"arguments and, unless in 'block' mode, don't fail",
+						Hidden: true,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.LoadFromPaths(
+						c.String("root-config"), c.String("config"),
+					)
+					if err != nil && c.Bool("hook") {
+						// Without a configuration there is no 'check_mode'
+						fmt.Printf(
+							"Warning: could not check for unpushed files: "+
+								"error loading configuration: %s\n", err,
+						)
+						return nil
+					} else if err != nil {
+						return cli.Exit(errorColor(
+							"Error loading configuration: %s", err,
+						), 1)
+					}
+					// The arguments of git hooks are not resource ids
+					var resourceIds []string
+					if !c.Bool("hook") {
+						resourceIds = c.Args().Slice()
+					}
+					if c.String("resources") != "" {
+						resourceIds = append(
+							resourceIds,
+							strings.Split(c.String("resources"), ",")...,
+						)
+					}
+					err = txlib.CheckCommand(&cfg, txlib.CheckArguments{
+						ResourceIds:  resourceIds,
+						Translations: c.Bool("translation"),
+						Mode:         c.String("mode"),
+						Hook:         c.Bool("hook"),
+					}, os.Stdout)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "hooks",
+				Usage: "Manage the git hooks of the client",
+				Subcommands: []*cli.Command{
+					{
+						Name: "install",
+						Usage: "Install git hooks that run 'tx check' before " +
+							"commits and pushes",
+						ArgsUsage: "[pre-commit|pre-push...]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Replace existing hooks",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							err := txlib.HooksInstallCommand(txlib.HooksInstallArguments{
+								Hooks: c.Args().Slice(),
+								Force: c.Bool("force"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "translations",
+				Usage: "List and update the translations of a resource",
+				Subcommands: []*cli.Command{
+					{
+						Name: "list",
+						Usage: "List the translations of a resource in a " +
+							"language, with their review state",
+						ArgsUsage: "<resource_id>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "language",
+								Aliases:  []string{"l"},
+								Usage:    "Language code of the translations",
+								Required: true,
+							},
+							&cli.BoolFlag{
+								Name:  "untranslated",
+								Usage: "Only show strings that are not translated",
+							},
+							&cli.BoolFlag{
+								Name:  "unreviewed",
+								Usage: "Only show translations that are not reviewed",
+							},
+							&cli.StringFlag{
+								Name:  "modified-since",
+								Usage: "Only show translations made after this ISO 8601 date",
+							},
+							&cli.StringFlag{
+								Name:  "key",
+								Usage: "Only show the translation of the string with this key",
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Show at most this many translations",
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the translations as JSON",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.Exit(errorColor("Please provide one resource"), 1)
+							}
+							filter := txapi.ResourceTranslationsFilter{
+								Key:           c.String("key"),
+								ModifiedSince: c.String("modified-since"),
+							}
+							if c.Bool("untranslated") {
+								translated := false
+								filter.Translated = &translated
+							}
+							if c.Bool("unreviewed") {
+								reviewed := false
+								filter.Reviewed = &reviewed
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.TranslationsListCommand(&cfg, api, txlib.TranslationsListArguments{
+								ResourceId:   c.Args().First(),
+								LanguageCode: c.String("language"),
+								Filter:       filter,
+								Limit:        c.Int("limit"),
+								Json:         c.Bool("json"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+					{
+						Name: "update",
+						Usage: "Change many translations at once from a CSV " +
+							"or JSON file",
+						ArgsUsage: "<resource_id>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name: "file",
+								Usage: "CSV or JSON `FILE` with the changes, with " +
+									"the columns/fields key, language, " +
+									"translation, reviewed and proofread",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:    "language",
+								Aliases: []string{"l"},
+								Usage:   "Language code for the changes that don't specify one",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Only show how many translations would change",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.Exit(errorColor("Please provide one resource"), 1)
+							}
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							api, err := getApi(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							err = txlib.TranslationsUpdateCommand(&cfg, api, txlib.TranslationsUpdateArguments{
+								ResourceId:   c.Args().First(),
+								Path:         c.String("file"),
+								LanguageCode: c.String("language"),
+								DryRun:       c.Bool("dry-run"),
+							}, os.Stdout)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "dev",
+				Usage: "Tools for developing and testing localization workflows",
+				Subcommands: []*cli.Command{
+					{
+						Name: "mock-server",
+						Usage: "tx dev mock-server [--port PORT] " +
+							"[--seed FILE] [--store FILE]",
+						Description: "Serve a fake Transifex API on " +
+							"localhost. Point the other commands to it with " +
+							"'--hostname' to try them out offline.",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "port",
+								Usage: "Port to listen on, 0 for any free one",
+								Value: 8080,
+							},
+							&cli.StringFlag{
+								Name: "seed",
+								Usage: "Load organizations, projects and " +
+									"resources from JSON `FILE`",
+							},
+							&cli.StringFlag{
+								Name: "store",
+								Usage: "Save every change to JSON `FILE`, " +
+									"and load it instead of the seed on restart",
+							},
+							&cli.IntFlag{
+								Name:  "page-size",
+								Usage: "Number of items per page of collections",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							err := txlib.MockServerCommand(
+								&txlib.MockServerCommandArguments{
+									Port:     c.Int("port"),
+									Seed:     c.String("seed"),
+									Store:    c.String("store"),
+									PageSize: c.Int("page-size"),
+								},
+							)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
 		},
 		Flags: flags,
 	}
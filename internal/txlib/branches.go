@@ -0,0 +1,352 @@
+package txlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gosimple/slug"
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+/*
+The resources that 'tx push --branch' created for a branch in a project. Their
+slugs are '<slug(branch)>--<resource>' (see getBranchResourceSlug).
+*/
+type branchGroup struct {
+	Project string `json:"project"`
+	// The slug of the branch's name
+	Branch    string              `json:"branch"`
+	Resources []*jsonapi.Resource `json:"-"`
+	// The branches of the resources these were created from; empty for the
+	// resources pushed without a branch
+	Bases    []string `json:"bases"`
+	Modified string   `json:"datetime_modified"`
+}
+
+// How a branch is presented in JSON output
+type branchOutput struct {
+	branchGroup
+	ResourceSlugs []string `json:"resources"`
+}
+
+type BranchesListArguments struct {
+	// See figureOutRemoteProjectId; empty for all the projects of the local
+	// configuration
+	Project          string
+	OrganizationSlug string
+	Json             bool
+}
+
+/*
+BranchesListCommand
+Print the branches that resources were pushed for, grouped per project, as a
+table or as JSON
+*/
+func BranchesListCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args BranchesListArguments,
+	out io.Writer,
+) error {
+	groups, err := getBranchGroups(cfg, &api, args.Project, args.OrganizationSlug)
+	if err != nil {
+		return err
+	}
+	if args.Json {
+		items := make([]branchOutput, 0, len(groups))
+		for _, group := range groups {
+			item := branchOutput{branchGroup: *group, ResourceSlugs: []string{}}
+			for _, resource := range group.Resources {
+				item.ResourceSlugs = append(
+					item.ResourceSlugs, resourceSlugFromId(resource.Id),
+				)
+			}
+			items = append(items, item)
+		}
+		return writeJson(out, items)
+	}
+
+	if len(groups) == 0 {
+		fmt.Fprintln(out, "No branches found")
+		return nil
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "PROJECT\tBRANCH\tRESOURCES\tBASE\tMODIFIED")
+	for _, group := range groups {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\n",
+			group.Project,
+			group.Branch,
+			len(group.Resources),
+			formatBranchBases(group.Bases),
+			group.Modified,
+		)
+	}
+	return table.Flush()
+}
+
+type BranchesPruneArguments struct {
+	// See BranchesListArguments
+	Project          string
+	OrganizationSlug string
+	// Only print what would be deleted
+	DryRun bool
+	// Don't ask for confirmation
+	NoInteractive bool
+}
+
+/*
+BranchesPruneCommand
+Delete the branch resources whose branch no longer exists in the git
+repository, either locally or as a remote-tracking branch. Resources that
+other, remaining, resources were created from are kept.
+*/
+func BranchesPruneCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args BranchesPruneArguments,
+	out io.Writer,
+) error {
+	gitBranches, err := getGitBranches()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, branch := range gitBranches {
+		existing[slug.Make(branch)] = true
+	}
+
+	groups, err := getBranchGroups(cfg, &api, args.Project, args.OrganizationSlug)
+	if err != nil {
+		return err
+	}
+	stale := make(map[string]bool)
+	for _, group := range groups {
+		if !existing[group.Branch] {
+			for _, resource := range group.Resources {
+				stale[resource.Id] = true
+			}
+		}
+	}
+	// Resources that remaining branch resources were created from
+	keptBases := make(map[string]bool)
+	for _, group := range groups {
+		for _, resource := range group.Resources {
+			if baseId := getResourceBaseId(resource); baseId != "" &&
+				!stale[resource.Id] {
+				keptBases[baseId] = true
+			}
+		}
+	}
+
+	var toDelete []*jsonapi.Resource
+	for _, group := range groups {
+		if existing[group.Branch] {
+			continue
+		}
+		for _, resource := range group.Resources {
+			if keptBases[resource.Id] {
+				fmt.Fprintf(out, "Keeping '%s', other resources are based on it\n",
+					resource.Id)
+				continue
+			}
+			toDelete = append(toDelete, resource)
+		}
+	}
+	if len(toDelete) == 0 {
+		fmt.Fprintln(out, "No stale branch resources found")
+		return nil
+	}
+
+	fmt.Fprintln(out, "Resources of branches that no longer exist:")
+	for _, resource := range toDelete {
+		fmt.Fprintf(out, "  %s\n", resource.Id)
+	}
+	if args.DryRun {
+		fmt.Fprintln(out, "Dry run, nothing was deleted")
+		return nil
+	}
+	if !args.NoInteractive && !askConfirmation(fmt.Sprintf(
+		"Delete %d resource(s) and all their translations", len(toDelete),
+	)) {
+		fmt.Fprintln(out, "Nothing was deleted")
+		return nil
+	}
+	for _, resource := range toDelete {
+		err := txapi.DeleteResource(&api, resource)
+		if err != nil {
+			return fmt.Errorf("could not delete '%s': %w", resource.Id, err)
+		}
+	}
+	fmt.Fprintf(out, "Deleted %d resource(s)\n", len(toDelete))
+	return nil
+}
+
+/*
+Fetch the resources of a project, or of all the projects of the local
+configuration, and group the ones that were pushed for a branch. Groups are
+sorted by project and branch.
+*/
+func getBranchGroups(
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	projectArgument, organizationSlug string,
+) ([]*branchGroup, error) {
+	var projectIds []string
+	if projectArgument != "" {
+		projectId, err := figureOutRemoteProjectId(
+			projectArgument, organizationSlug, cfg,
+		)
+		if err != nil {
+			return nil, err
+		}
+		projectIds = []string{projectId}
+	} else {
+		if cfg == nil || cfg.Local == nil || len(cfg.Local.Resources) == 0 {
+			return nil, errors.New(
+				"please provide a project, there are no resources in the " +
+					"local configuration",
+			)
+		}
+		for _, cfgResource := range cfg.Local.Resources {
+			projectId := fmt.Sprintf(
+				"o:%s:p:%s", cfgResource.OrganizationSlug, cfgResource.ProjectSlug,
+			)
+			if !stringSliceContains(projectIds, projectId) {
+				projectIds = append(projectIds, projectId)
+			}
+		}
+		sort.Strings(projectIds)
+	}
+
+	var result []*branchGroup
+	for _, projectId := range projectIds {
+		project, err := txapi.GetProjectById(api, projectId)
+		if err != nil {
+			return nil, err
+		}
+		if project == nil {
+			return nil, fmt.Errorf("project '%s' does not exist", projectId)
+		}
+		resources, err := txapi.GetResources(api, project)
+		if err != nil {
+			return nil, err
+		}
+		allAttributes := make(map[string]txapi.ResourceAttributes)
+		existingSlugs := make(map[string]bool)
+		for _, resource := range resources {
+			var attributes txapi.ResourceAttributes
+			err := resource.MapAttributes(&attributes)
+			if err != nil {
+				return nil, err
+			}
+			allAttributes[resource.Id] = attributes
+			existingSlugs[attributes.Slug] = true
+		}
+		// Branch resource id -> its branch
+		resourceBranches := make(map[string]string)
+		for _, resource := range resources {
+			resourceSlug := allAttributes[resource.Id].Slug
+			if isBranchResource(resource, resourceSlug, existingSlugs) {
+				resourceBranches[resource.Id] = getResourceBranch(resourceSlug)
+			}
+		}
+
+		groups := make(map[string]*branchGroup)
+		var branches []string
+		for _, resource := range resources {
+			branch, exists := resourceBranches[resource.Id]
+			if !exists {
+				continue
+			}
+			attributes := allAttributes[resource.Id]
+			group, exists := groups[branch]
+			if !exists {
+				group = &branchGroup{
+					Project: projectId, Branch: branch, Bases: []string{},
+				}
+				groups[branch] = group
+				branches = append(branches, branch)
+			}
+			group.Resources = append(group.Resources, resource)
+			if attributes.DatetimeModified > group.Modified {
+				group.Modified = attributes.DatetimeModified
+			}
+			if baseId := getResourceBaseId(resource); baseId != "" {
+				// Empty for resources that weren't pushed for a branch
+				base := resourceBranches[baseId]
+				if !stringSliceContains(group.Bases, base) {
+					group.Bases = append(group.Bases, base)
+				}
+			}
+		}
+		sort.Strings(branches)
+		for _, branch := range branches {
+			sort.Strings(groups[branch].Bases)
+			result = append(result, groups[branch])
+		}
+	}
+	return result, nil
+}
+
+// The branch slug of a resource's slug, empty if it can't have been pushed for
+// a branch; see isBranchResource
+func getResourceBranch(resourceSlug string) string {
+	index := strings.Index(resourceSlug, "--")
+	if index <= 0 {
+		return ""
+	}
+	return resourceSlug[:index]
+}
+
+/*
+Whether a resource whose slug looks like '<branch>--<slug>' was actually pushed
+for a branch: it has a base, or a resource with '<slug>' exists in the same
+project ('existingSlugs'). Otherwise it's an ordinary resource with '--' in its
+slug, which branch commands must leave alone.
+*/
+func isBranchResource(
+	resource *jsonapi.Resource, resourceSlug string, existingSlugs map[string]bool,
+) bool {
+	branch := getResourceBranch(resourceSlug)
+	if branch == "" {
+		return false
+	}
+	if getResourceBaseId(resource) != "" {
+		return true
+	}
+	return existingSlugs[resourceSlug[len(branch)+len("--"):]]
+}
+
+// 'o:org:p:proj:r:res' => 'res'
+func resourceSlugFromId(resourceId string) string {
+	parts := strings.Split(resourceId, ":")
+	return parts[len(parts)-1]
+}
+
+func getResourceBaseId(resource *jsonapi.Resource) string {
+	relationship, exists := resource.Relationships["base"]
+	if !exists || relationship.DataSingular == nil {
+		return ""
+	}
+	return relationship.DataSingular.Id
+}
+
+func formatBranchBases(bases []string) string {
+	if len(bases) == 0 {
+		return "-"
+	}
+	result := make([]string, 0, len(bases))
+	for _, base := range bases {
+		if base == "" {
+			base = "(no branch)"
+		}
+		result = append(result, base)
+	}
+	return strings.Join(result, ", ")
+}
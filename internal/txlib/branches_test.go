@@ -0,0 +1,152 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/txapitest"
+)
+
+func getBranchesTestServer(t *testing.T) *txapitest.Server {
+	server := getStringsTestServer(t)
+	for _, item := range []struct{ slug, base string }{
+		{"feature--resslug", "resslug"},
+		{"remote-only--resslug", "resslug"},
+		{"gone--resslug", "resslug"},
+		{"old--resslug", "resslug"},
+		{"newer--resslug", "old--resslug"},
+	} {
+		resource := server.Store.AddResource(
+			"o:orgslug:p:projslug", item.slug, "KEYVALUEJSON",
+			[]byte(`{"hello": "Hello world"}`),
+		)
+		resource.BaseId = "o:orgslug:p:projslug:r:" + item.base
+	}
+	return server
+}
+
+func TestBranchesListCommand(t *testing.T) {
+	server := getBranchesTestServer(t)
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := BranchesListCommand(cfg, server.Connection(), BranchesListArguments{}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 || !strings.HasPrefix(lines[0], "PROJECT") ||
+		!strings.Contains(lines[1], "feature") ||
+		!strings.Contains(lines[1], "(no branch)") ||
+		!strings.Contains(lines[3], "newer") ||
+		!strings.Contains(lines[3], "old") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = BranchesListCommand(cfg, server.Connection(), BranchesListArguments{
+		Project: "projslug", Json: true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []branchOutput
+	err = json.Unmarshal(out.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	if len(items) != 5 || items[0].Branch != "feature" ||
+		items[0].Project != "o:orgslug:p:projslug" ||
+		len(items[0].ResourceSlugs) != 1 ||
+		items[0].ResourceSlugs[0] != "feature--resslug" ||
+		items[2].Bases[0] != "old" {
+		t.Errorf("Got branches %+v", items)
+	}
+}
+
+func TestBranchesPruneCommand(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	initTestGitRepo(t, "feature", "origin/remote-only", "Newer")
+
+	server := getBranchesTestServer(t)
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := BranchesPruneCommand(cfg, server.Connection(), BranchesPruneArguments{
+		DryRun: true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Store.Resources) != 6 {
+		t.Errorf("Dry run deleted resources")
+	}
+	result := out.String()
+	if !strings.Contains(result, "Keeping 'o:orgslug:p:projslug:r:old--resslug'") ||
+		!strings.Contains(result, "  o:orgslug:p:projslug:r:gone--resslug\n") ||
+		strings.Contains(result, "feature--resslug") ||
+		strings.Contains(result, "remote-only--resslug") ||
+		!strings.Contains(result, "Dry run") {
+		t.Errorf("Got output:\n%s", result)
+	}
+
+	originalAskConfirmation := askConfirmation
+	defer func() { askConfirmation = originalAskConfirmation }()
+	askConfirmation = func(label string) bool { return false }
+	out.Reset()
+	err = BranchesPruneCommand(cfg, server.Connection(), BranchesPruneArguments{}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Store.Resources) != 6 ||
+		!strings.Contains(out.String(), "Nothing was deleted") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	askConfirmation = func(label string) bool { return true }
+	out.Reset()
+	err = BranchesPruneCommand(cfg, server.Connection(), BranchesPruneArguments{}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := server.Store.Resources["o:orgslug:p:projslug:r:gone--resslug"]; exists ||
+		len(server.Store.Resources) != 5 ||
+		!strings.Contains(out.String(), "Deleted 1 resource(s)") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+}
+
+func TestBranchesPruneCommandKeepsOrdinaryResources(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	initTestGitRepo(t)
+
+	server := getStringsTestServer(t)
+	// Not pushed for a branch, there is no 'b' resource and no base
+	server.Store.AddResource(
+		"o:orgslug:p:projslug", "a--b", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello world"}`),
+	)
+	// Pushed for a branch without a base, 'resslug' exists
+	server.Store.AddResource(
+		"o:orgslug:p:projslug", "gone--resslug", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello world"}`),
+	)
+
+	var out bytes.Buffer
+	err := BranchesPruneCommand(getStandardConfig(), server.Connection(), BranchesPruneArguments{
+		NoInteractive: true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := server.Store.Resources["o:orgslug:p:projslug:r:a--b"]; !exists {
+		t.Errorf("Ordinary resource 'a--b' was deleted, output:\n%s", out.String())
+	}
+	if _, exists := server.Store.Resources["o:orgslug:p:projslug:r:gone--resslug"]; exists {
+		t.Errorf("Branch resource was not deleted, output:\n%s", out.String())
+	}
+}
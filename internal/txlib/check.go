@@ -0,0 +1,266 @@
+package txlib
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+/*
+The files that 'tx push' uploaded from a clone of a git repository, kept in
+its '.git' directory so that it's never committed
+*/
+type pushState struct {
+	// Path relative to the root of the working tree, with slashes -> SHA-1 of
+	// the content that was pushed
+	Files map[string]string `json:"files"`
+}
+
+const pushStateFileName = "tx_push_state.json"
+
+/*
+Load the push state of the git repository the current directory is in. Also
+returns the root of the working tree and where the state is saved.
+*/
+func loadPushState() (*pushState, string, string, error) {
+	root, gitDir, err := getGitDirs()
+	if err != nil {
+		return nil, "", "", err
+	}
+	path := filepath.Join(gitDir, pushStateFileName)
+	state := pushState{Files: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &state, root, path, nil
+	} else if err != nil {
+		return nil, "", "", err
+	}
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid push state '%s': %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]string)
+	}
+	return &state, root, path, nil
+}
+
+/*
+Record the current content of 'paths' as pushed. Does nothing outside of a
+git repository.
+*/
+func updatePushState(paths []string) error {
+	state, root, statePath, err := loadPushState()
+	if err != nil {
+		// Not a git repository, there is nothing to check against
+		return nil
+	}
+	for _, path := range paths {
+		name, err := getPushStateName(root, path)
+		if err != nil {
+			return err
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		state.Files[name] = hash
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0644)
+}
+
+// The key of 'path' in pushState.Files
+func getPushStateName(root, path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	name, err := filepath.Rel(root, filepath.Join(dir, filepath.Base(path)))
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(name), nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha1.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type CheckArguments struct {
+	// Resources of the local configuration, as in 'tx push'; empty for all
+	ResourceIds []string
+	// Also check the translation files
+	Translations bool
+	// One of config.CheckModes; empty for the local configuration's
+	// 'check_mode'
+	Mode string
+	// Run from a git hook: unless in "block" mode, errors are only printed
+	// so that they don't stop the commit or the push
+	Hook bool
+}
+
+/*
+CheckCommand
+Report the files of the local configuration's resources that changed since
+they were last pushed from this clone of the git repository. Files that were
+never pushed from it are reported if git considers them modified or
+untracked. In "block" mode an error is returned if any are found, so that git
+hooks can stop a commit or a push.
+*/
+func CheckCommand(
+	cfg *config.Config, args CheckArguments, out io.Writer,
+) error {
+	mode := args.Mode
+	if mode == "" && cfg.Local != nil {
+		mode = cfg.Local.CheckMode
+	}
+	if mode == "" {
+		mode = config.CheckModes[0]
+	}
+	if !stringSliceContains(config.CheckModes, mode) {
+		return fmt.Errorf("invalid mode '%s'", mode)
+	}
+	unpushed, err := checkPushedFiles(cfg, args, out)
+	if err != nil && args.Hook && mode != "block" {
+		fmt.Fprintf(out, "Warning: could not check for unpushed files: %s\n", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if unpushed == 0 {
+		fmt.Fprintln(out, "All files have been pushed")
+		return nil
+	}
+	message := fmt.Sprintf(
+		"%d file(s) have not been pushed to Transifex, run 'tx push'", unpushed,
+	)
+	if mode == "block" {
+		return errors.New(message)
+	}
+	fmt.Fprintf(out, "Warning: %s\n", message)
+	return nil
+}
+
+/*
+Print the files of CheckCommand that haven't been pushed and return how many
+they are
+*/
+func checkPushedFiles(
+	cfg *config.Config, args CheckArguments, out io.Writer,
+) (int, error) {
+	cfgResources, err := figureOutResources(args.ResourceIds, cfg)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(cfgResources, func(i, j int) bool {
+		return cfgResources[i].GetAPv3Id() < cfgResources[j].GetAPv3Id()
+	})
+	state, root, _, err := loadPushState()
+	if err != nil {
+		return 0, err
+	}
+	curDir, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+
+	// Only fetched if some files have never been pushed, it can be slow
+	var status git.Status
+	getStatus := func() (git.Status, error) {
+		var err error
+		if status == nil {
+			status, err = getGitStatus()
+		}
+		return status, err
+	}
+
+	unpushed := 0
+	for _, cfgResource := range cfgResources {
+		paths := []string{filepath.Join(curDir, cfgResource.SourceFile)}
+		if args.Translations {
+			var translationPaths []string
+			files, err := searchResourceFiles(
+				cfg, cfgResource, curDir, cfgResource.FileFilter,
+			)
+			if err != nil {
+				return 0, err
+			}
+			for _, path := range files {
+				translationPaths = append(translationPaths, path)
+			}
+			for _, customPath := range cfgResource.Overrides {
+				translationPaths = append(
+					translationPaths, filepath.Join(curDir, customPath),
+				)
+			}
+			sort.Strings(translationPaths)
+			paths = append(paths, translationPaths...)
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			name, err := getPushStateName(root, path)
+			if err != nil {
+				return 0, err
+			}
+			reason := ""
+			if pushedHash, exists := state.Files[name]; exists {
+				hash, err := hashFile(path)
+				if err != nil {
+					return 0, err
+				}
+				if hash != pushedHash {
+					reason = "changed since the last push"
+				}
+			} else {
+				status, err := getStatus()
+				if err != nil {
+					return 0, err
+				}
+				fileStatus, exists := status[name]
+				if exists && fileStatus.Worktree == git.Untracked {
+					reason = "new, never pushed"
+				} else if exists && (fileStatus.Worktree != git.Unmodified ||
+					fileStatus.Staging != git.Unmodified) {
+					reason = "modified, never pushed"
+				}
+			}
+			if reason != "" {
+				fmt.Fprintf(out, "%s.%s: %s (%s)\n",
+					cfgResource.ProjectSlug, cfgResource.ResourceSlug, name, reason)
+				unpushed++
+			}
+		}
+	}
+
+	return unpushed, nil
+}
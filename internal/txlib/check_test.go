@@ -0,0 +1,148 @@
+package txlib
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transifex/cli/pkg/txapitest"
+)
+
+func TestCheckCommand(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.WriteFile("aaa.json", []byte(`{"hello": "world"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initTestGitRepo(t)
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+
+	check := func(mode string) (string, error) {
+		var out bytes.Buffer
+		err := CheckCommand(cfg, CheckArguments{Mode: mode}, &out)
+		return out.String(), err
+	}
+
+	// Committed but never pushed from this clone
+	out, err := check("block")
+	if err != nil || !strings.Contains(out, "All files have been pushed") {
+		t.Errorf("Got output %q, error %v", out, err)
+	}
+
+	// Modified, never pushed
+	err = os.WriteFile("aaa.json", []byte(`{"hello": "world!"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = check("warn")
+	if err != nil || !strings.Contains(out, "aaa.json (modified, never pushed)") ||
+		!strings.Contains(out, "Warning: 1 file(s)") {
+		t.Errorf("Got output %q, error %v", out, err)
+	}
+	_, err = check("block")
+	if err == nil {
+		t.Error("Expected an error in block mode")
+	}
+
+	server := txapitest.NewServer()
+	defer server.Close()
+	server.Store.AddProject("orgslug", "projslug", "en", "el")
+	err = PushCommand(cfg, server.Connection(), PushCommandArguments{
+		Source: true, Force: true, Branch: "-1", Workers: 1, Silent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = check("block")
+	if err != nil || !strings.Contains(out, "All files have been pushed") {
+		t.Errorf("Got output %q, error %v", out, err)
+	}
+
+	// Changed after the push
+	err = os.WriteFile("aaa.json", []byte(`{"hello": "world?"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = check("block")
+	if err == nil || !strings.Contains(out, "aaa.json (changed since the last push)") {
+		t.Errorf("Got output %q, error %v", out, err)
+	}
+}
+
+func TestCheckCommandAfterSkippedPush(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.WriteFile("aaa.json", []byte(`{"hello": "world"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initTestGitRepo(t)
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+
+	server := txapitest.NewServer()
+	defer server.Close()
+	server.Store.AddProject("orgslug", "projslug", "en", "el")
+	args := PushCommandArguments{
+		Source: true, Force: true, Branch: "-1", Workers: 1, Silent: true,
+	}
+	err = PushCommand(cfg, server.Connection(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Older than the remote, so the push skips it
+	err = os.WriteFile("aaa.json", []byte(`{"hello": "world!"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = os.Chtimes("aaa.json", old, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args.Force = false
+	err = PushCommand(cfg, server.Connection(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := server.Store.Resources["o:orgslug:p:projslug:r:resslug"]
+	if strings.Contains(string(resource.Content), "world!") {
+		t.Fatal("The file was pushed")
+	}
+
+	var out bytes.Buffer
+	err = CheckCommand(cfg, CheckArguments{Mode: "block"}, &out)
+	if err != nil || !strings.Contains(out.String(), "All files have been pushed") {
+		t.Errorf("Got output %q, error %v", out.String(), err)
+	}
+}
+
+func TestCheckCommandFromHook(t *testing.T) {
+	// Not a git repository, so the check can't run
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := CheckCommand(cfg, CheckArguments{Hook: true}, &out)
+	if err != nil {
+		t.Errorf("A failing check stopped the hook in warn mode: %s", err)
+	}
+	if !strings.Contains(out.String(), "Warning: could not check") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	err = CheckCommand(cfg, CheckArguments{Hook: true, Mode: "block"}, &out)
+	if err == nil {
+		t.Error("Expected an error in block mode")
+	}
+	err = CheckCommand(cfg, CheckArguments{}, &out)
+	if err == nil {
+		t.Error("Expected an error outside of a hook")
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
 )
 
 func GetClient(cacert string) (http.Client, error) {
@@ -32,5 +36,104 @@ func GetClient(cacert string) (http.Client, error) {
 		transport.TLSClientConfig = &tls.Config{RootCAs: certPool}
 	}
 
-	return http.Client{Transport: transport}, nil
+	return http.Client{
+		Transport:     transport,
+		CheckRedirect: jsonapi.RefuseRedirects,
+	}, nil
+}
+
+/*
+GetRateLimiter
+Return the rate limiter that all the API requests of a command should share.
+The requests-per-second cap comes from the 'requests_per_second' field of the
+host in the root configuration; the host is resolved the same way as in
+GetHostAndToken. Without a cap, the limiter is still useful because it makes
+all workers back off when the server responds with a 'Retry-After'.
+*/
+func GetRateLimiter(cfg *config.Config, hostname string) *jsonapi.RateLimiter {
+	host := getRootHost(cfg, hostname)
+	if host == nil || host.RequestsPerSecond <= 0 {
+		return jsonapi.NewRateLimiter(0, 1)
+	}
+	return jsonapi.NewRateLimiter(
+		host.RequestsPerSecond,
+		int(math.Ceil(host.RequestsPerSecond)),
+	)
+}
+
+/*
+GetRetryPolicy
+Return the retry policy for the API requests of a command: the default one,
+with the overrides of the 'retry_*' fields of the host in the root
+configuration. The host is resolved the same way as in GetHostAndToken.
+*/
+func GetRetryPolicy(cfg *config.Config, hostname string) *jsonapi.RetryPolicy {
+	policy := jsonapi.DefaultRetryPolicy()
+	host := getRootHost(cfg, hostname)
+	if host == nil {
+		return policy
+	}
+	if host.RetryMaxAttempts > 0 {
+		policy.MaxAttempts = host.RetryMaxAttempts
+	}
+	if host.RetryMaxElapsedTime > 0 {
+		policy.MaxElapsedTime = host.RetryMaxElapsedTime
+	}
+	if host.RetryInitialInterval > 0 {
+		policy.InitialInterval = host.RetryInitialInterval
+	}
+	if host.RetryMaxInterval > 0 {
+		policy.MaxInterval = host.RetryMaxInterval
+	}
+	if host.RetryOn != nil {
+		policy.RetryOn = *host.RetryOn
+	}
+	return policy
+}
+
+// The host of the root configuration that 'hostname' refers to, or the active
+// one if 'hostname' is empty
+func getRootHost(cfg *config.Config, hostname string) *config.Host {
+	if cfg.Root == nil {
+		return nil
+	}
+	if hostname != "" {
+		return cfg.FindHost(hostname)
+	}
+	return cfg.GetActiveHost()
+}
+
+/*
+GetHttpTracer
+Return the tracer that all the API connections of a command should share, or
+nil if the user asked neither for HTTP debugging output ('debug') nor for a
+HAR file ('harPath'). The output goes to stderr so that it doesn't get mixed
+with the progress messages.
+*/
+func GetHttpTracer(debug bool, harPath string) *jsonapi.HttpTracer {
+	if !debug && harPath == "" {
+		return nil
+	}
+	tracer := jsonapi.NewHttpTracer(nil)
+	if debug {
+		tracer.Writer = os.Stderr
+	}
+	return tracer
+}
+
+/*
+SaveHttpTrace
+Write the requests recorded by 'tracer' to 'harPath' in HAR format so that
+they can be attached to a support ticket. Does nothing if either is empty.
+*/
+func SaveHttpTrace(tracer *jsonapi.HttpTracer, harPath string) error {
+	if tracer == nil || harPath == "" {
+		return nil
+	}
+	file, err := os.Create(harPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return tracer.WriteHar(file, "tx", Version)
 }
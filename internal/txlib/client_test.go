@@ -0,0 +1,39 @@
+package txlib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestGetRetryPolicy(t *testing.T) {
+	retryOn := jsonapi.RetryThrottled
+	cfg := &config.Config{
+		Root: &config.RootConfig{Hosts: []config.Host{
+			{Name: "https://app.transifex.com"},
+			{
+				Name:                "https://other.transifex.com",
+				RetryMaxAttempts:    2,
+				RetryMaxElapsedTime: time.Minute,
+				RetryOn:             &retryOn,
+			},
+		}},
+		Local: &config.LocalConfig{Host: "https://app.transifex.com"},
+	}
+	defaultPolicy := jsonapi.DefaultRetryPolicy()
+
+	policy := GetRetryPolicy(cfg, "")
+	if policy.MaxAttempts != defaultPolicy.MaxAttempts ||
+		policy.RetryOn != defaultPolicy.RetryOn {
+		t.Errorf("Got policy %+v, expected the default one", policy)
+	}
+
+	policy = GetRetryPolicy(cfg, "https://other.transifex.com")
+	if policy.MaxAttempts != 2 || policy.MaxElapsedTime != time.Minute ||
+		policy.InitialInterval != defaultPolicy.InitialInterval ||
+		policy.RetryOn != jsonapi.RetryThrottled {
+		t.Errorf("Got policy %+v", policy)
+	}
+}
@@ -18,6 +18,14 @@ type LocalConfig struct {
 	LanguageMappings map[string]string
 	Resources        []Resource
 	Path             string
+	// What 'tx check' does when source files haven't been pushed, one of
+	// CheckModes; empty means "warn"
+	CheckMode string
+	// Patterns, in .gitignore syntax, of the files that are left out when
+	// looking for the files of resources
+	Ignore []string
+	// Also leave out the files that git ignores
+	UseGitignore bool
 }
 
 type Resource struct {
@@ -34,8 +42,21 @@ type Resource struct {
 	ResourceName         string
 	ReplaceEditedStrings bool
 	KeepTranslations     bool
+	// Metadata that 'tx resources sync-meta' applies to the remote resource;
+	// empty or nil means it's not managed by the local configuration
+	Categories         []string
+	Priority           string
+	AcceptTranslations *bool
+	// Added to the 'Ignore' patterns of the LocalConfig for this resource
+	Ignore []string
 }
 
+// The values of 'check_mode'
+var CheckModes = []string{"warn", "block"}
+
+// The priorities the API accepts for a resource
+var ResourcePriorities = []string{"normal", "high", "urgent"}
+
 func loadLocalConfig() (*LocalConfig, error) {
 	localPath, err := findLocalPath("")
 	if err != nil {
@@ -83,6 +104,29 @@ func loadLocalConfigFromBytes(data []byte) (*LocalConfig, error) {
 	if result.Host == "" {
 		return nil, errors.New("local config's main section has no host")
 	}
+	result.CheckMode = mainSection.Key("check_mode").String()
+	if result.CheckMode != "" {
+		valid := false
+		for _, candidate := range CheckModes {
+			if result.CheckMode == candidate {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf(
+				"'check_mode' needs to be one of %s, got '%s'",
+				strings.Join(CheckModes, ", "), result.CheckMode,
+			)
+		}
+	}
+	result.Ignore = splitList(mainSection.Key("ignore").String())
+	if mainSection.HasKey("use_gitignore") {
+		result.UseGitignore, err = mainSection.Key("use_gitignore").Bool()
+		if err != nil {
+			return nil, fmt.Errorf("invalid use_gitignore: %w", err)
+		}
+	}
 	languageMappings := mainSection.Key("lang_map").String()
 	if languageMappings != "" {
 		for _, mapping := range strings.Split(languageMappings, ",") {
@@ -143,6 +187,34 @@ func loadLocalConfigFromBytes(data []byte) (*LocalConfig, error) {
 			}
 		}
 
+		var acceptTranslations *bool
+		if section.HasKey("accept_translations") {
+			value, err := section.Key("accept_translations").Bool()
+			if err != nil {
+				return nil, fmt.Errorf(
+					"'accept_translations' needs to be 'true' or 'false': %s", err,
+				)
+			}
+			acceptTranslations = &value
+		}
+
+		priority := section.Key("priority").String()
+		if priority != "" {
+			valid := false
+			for _, candidate := range ResourcePriorities {
+				if priority == candidate {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, fmt.Errorf(
+					"'priority' needs to be one of %s, got '%s'",
+					strings.Join(ResourcePriorities, ", "), priority,
+				)
+			}
+		}
+
 		resource := Resource{
 			OrganizationSlug:     organizationSlug,
 			ProjectSlug:          projectSlug,
@@ -157,6 +229,10 @@ func loadLocalConfigFromBytes(data []byte) (*LocalConfig, error) {
 			ResourceName:         section.Key("resource_name").String(),
 			ReplaceEditedStrings: replaceEditedStrings,
 			KeepTranslations:     keepTranslations,
+			Categories:           splitList(section.Key("categories").String()),
+			Priority:             priority,
+			AcceptTranslations:   acceptTranslations,
+			Ignore:               splitList(section.Key("ignore").String()),
 		}
 
 		// Get first the perc in string to check if exists because .Key returns
@@ -237,6 +313,24 @@ func (localCfg LocalConfig) saveToWriter(file io.Writer) error {
 			return err
 		}
 	}
+	if localCfg.CheckMode != "" {
+		_, err = main.NewKey("check_mode", localCfg.CheckMode)
+		if err != nil {
+			return err
+		}
+	}
+	if len(localCfg.Ignore) != 0 {
+		_, err = main.NewKey("ignore", strings.Join(localCfg.Ignore, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	if localCfg.UseGitignore {
+		_, err = main.NewKey("use_gitignore", "true")
+		if err != nil {
+			return err
+		}
+	}
 
 	for _, resource := range localCfg.Resources {
 		section, err := cfg.NewSection(resource.Name())
@@ -314,6 +408,39 @@ func (localCfg LocalConfig) saveToWriter(file io.Writer) error {
 		section.NewKey(
 			"keep_translations", strconv.FormatBool(resource.KeepTranslations),
 		)
+
+		if len(resource.Categories) != 0 {
+			_, err := section.NewKey(
+				"categories", strings.Join(resource.Categories, ", "),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(resource.Ignore) != 0 {
+			_, err := section.NewKey("ignore", strings.Join(resource.Ignore, ", "))
+			if err != nil {
+				return err
+			}
+		}
+
+		if resource.Priority != "" {
+			_, err := section.NewKey("priority", resource.Priority)
+			if err != nil {
+				return err
+			}
+		}
+
+		if resource.AcceptTranslations != nil {
+			_, err := section.NewKey(
+				"accept_translations",
+				strconv.FormatBool(*resource.AcceptTranslations),
+			)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	_, err = cfg.WriteTo(file)
@@ -332,6 +459,13 @@ func localConfigsEqual(left, right *LocalConfig) bool {
 	if left.Host != right.Host {
 		return false
 	}
+	if left.CheckMode != right.CheckMode {
+		return false
+	}
+	if strings.Join(left.Ignore, ",") != strings.Join(right.Ignore, ",") ||
+		left.UseGitignore != right.UseGitignore {
+		return false
+	}
 
 	if len(left.LanguageMappings) != len(right.LanguageMappings) {
 		return false
@@ -402,6 +536,26 @@ func localConfigsEqual(left, right *LocalConfig) bool {
 		if leftResource.ReplaceEditedStrings != rightResource.ReplaceEditedStrings {
 			return false
 		}
+
+		if strings.Join(leftResource.Categories, ",") !=
+			strings.Join(rightResource.Categories, ",") {
+			return false
+		}
+		if leftResource.Priority != rightResource.Priority {
+			return false
+		}
+		if strings.Join(leftResource.Ignore, ",") !=
+			strings.Join(rightResource.Ignore, ",") {
+			return false
+		}
+		if (leftResource.AcceptTranslations == nil) !=
+			(rightResource.AcceptTranslations == nil) {
+			return false
+		}
+		if leftResource.AcceptTranslations != nil &&
+			*leftResource.AcceptTranslations != *rightResource.AcceptTranslations {
+			return false
+		}
 	}
 
 	return true
@@ -494,3 +648,15 @@ func (localCfg *Resource) GetAPv3Id() string {
 		localCfg.ResourceSlug,
 	)
 }
+
+// 'a, b,,c' => ["a", "b", "c"]; nil for an empty value
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.Trim(item, " ")
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
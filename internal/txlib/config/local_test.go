@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -57,6 +58,7 @@ func TestLoadLocalConfig(t *testing.T) {
 }
 
 func TestSaveAndLoadLocalConfig(t *testing.T) {
+	acceptTranslations := false
 	expected := LocalConfig{
 		Host: "My Host",
 		LanguageMappings: map[string]string{
@@ -80,6 +82,9 @@ func TestSaveAndLoadLocalConfig(t *testing.T) {
 					"ee": "ff",
 					"gg": "hh",
 				},
+				Categories:         []string{"My Category", "Other"},
+				Priority:           "high",
+				AcceptTranslations: &acceptTranslations,
 			},
 		},
 	}
@@ -184,3 +189,110 @@ func TestChangeSaveAndLoadLocalConfig(t *testing.T) {
 		)
 	}
 }
+
+func TestLoadResourceMetadata(t *testing.T) {
+	localCfg, err := loadLocalConfigFromBytes([]byte(`
+[main]
+host = https://app.transifex.com
+
+[o:org:p:proj:r:res]
+file_filter = locale/<lang>.json
+categories = frontend, , checkout
+priority = urgent
+accept_translations = true
+
+[o:org:p:proj:r:other]
+file_filter = locale/<lang>.po
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := localCfg.Resources[0]
+	if other.Categories != nil || other.Priority != "" ||
+		other.AcceptTranslations != nil {
+		t.Errorf("Expected no metadata, got %+v", other)
+	}
+
+	resource := localCfg.Resources[1]
+	if strings.Join(resource.Categories, "|") != "frontend|checkout" {
+		t.Errorf("Got wrong categories %v", resource.Categories)
+	}
+	if resource.Priority != "urgent" {
+		t.Errorf("Got wrong priority '%s'", resource.Priority)
+	}
+	if resource.AcceptTranslations == nil || !*resource.AcceptTranslations {
+		t.Errorf("Got wrong accept_translations %v", resource.AcceptTranslations)
+	}
+
+	_, err = loadLocalConfigFromBytes([]byte(`
+[main]
+host = https://app.transifex.com
+
+[o:org:p:proj:r:res]
+priority = critical
+`))
+	if err == nil || !strings.Contains(err.Error(), "'priority'") {
+		t.Errorf("Expected an error about the priority, got %v", err)
+	}
+}
+
+func TestLoadCheckMode(t *testing.T) {
+	localCfg, err := loadLocalConfigFromBytes([]byte(`
+[main]
+host = https://app.transifex.com
+check_mode = block
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localCfg.CheckMode != "block" {
+		t.Errorf("Got check mode '%s'", localCfg.CheckMode)
+	}
+
+	_, err = loadLocalConfigFromBytes([]byte(`
+[main]
+host = https://app.transifex.com
+check_mode = fail
+`))
+	if err == nil {
+		t.Error("Expected an error for an invalid check mode")
+	}
+}
+
+func TestLoadIgnorePatterns(t *testing.T) {
+	localCfg, err := loadLocalConfigFromBytes([]byte(`
+[main]
+host = https://app.transifex.com
+ignore = node_modules, *.new
+use_gitignore = true
+
+[o:org:p:proj:r:res]
+file_filter = locale/<lang>.json
+ignore = locale/drafts/
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(localCfg.Ignore, "|") != "node_modules|*.new" ||
+		!localCfg.UseGitignore {
+		t.Errorf("Got ignore %v, use_gitignore %v",
+			localCfg.Ignore, localCfg.UseGitignore)
+	}
+	if strings.Join(localCfg.Resources[0].Ignore, "|") != "locale/drafts/" {
+		t.Errorf("Got resource ignore %v", localCfg.Resources[0].Ignore)
+	}
+
+	var saved bytes.Buffer
+	err = localCfg.saveToWriter(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := loadLocalConfigFromBytes(saved.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !localConfigsEqual(localCfg, reloaded) {
+		t.Errorf("Configuration changed after saving:\n%s", saved.String())
+	}
+}
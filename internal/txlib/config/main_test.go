@@ -21,7 +21,7 @@ func TestGetActiveHost(t *testing.T) {
 
 	activeHost := cfg.GetActiveHost()
 	if activeHost != &cfg.Root.Hosts[0] {
-		t.Errorf("Found wrong host '%s', expected '{aaa AAA}'", activeHost)
+		t.Errorf("Found wrong host '%+v', expected '{aaa AAA}'", activeHost)
 	}
 }
 
@@ -1,13 +1,17 @@
 package config
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"os/user"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/transifex/cli/pkg/jsonapi"
 	"gopkg.in/ini.v1"
 )
 
@@ -24,6 +28,15 @@ type Host struct {
 	Password     string
 	RestHostname string
 	Token        string
+	// Client-side cap for the requests sent to this host, 0 means no cap
+	RequestsPerSecond float64
+	// Overrides of the default retry policy for this host; zero values (and a
+	// nil RetryOn) keep the defaults
+	RetryMaxAttempts     int
+	RetryMaxElapsedTime  time.Duration
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+	RetryOn              *jsonapi.ErrorClass
 }
 
 func loadRootConfig() (*RootConfig, error) {
@@ -71,6 +84,20 @@ func loadRootConfigFromBytes(data []byte) (*RootConfig, error) {
 			RestHostname: section.Key("rest_hostname").String(),
 			Token:        section.Key("token").String(),
 		}
+		if section.HasKey("requests_per_second") {
+			host.RequestsPerSecond, err = section.Key("requests_per_second").Float64()
+			if err != nil || host.RequestsPerSecond < 0 {
+				return nil, fmt.Errorf(
+					"'requests_per_second' of host '%s' needs to be a positive "+
+						"number",
+					section.Name(),
+				)
+			}
+		}
+		err = loadHostRetrySettings(section, &host)
+		if err != nil {
+			return nil, err
+		}
 		result.Hosts = append(result.Hosts, host)
 	}
 
@@ -79,6 +106,56 @@ func loadRootConfigFromBytes(data []byte) (*RootConfig, error) {
 	return &result, nil
 }
 
+func loadHostRetrySettings(section *ini.Section, host *Host) error {
+	if section.HasKey("retry_max_attempts") {
+		value, err := section.Key("retry_max_attempts").Int()
+		if err != nil || value < 1 {
+			return fmt.Errorf(
+				"'retry_max_attempts' of host '%s' needs to be a positive "+
+					"integer",
+				section.Name(),
+			)
+		}
+		host.RetryMaxAttempts = value
+	}
+
+	durations := []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"retry_max_elapsed_time", &host.RetryMaxElapsedTime},
+		{"retry_initial_interval", &host.RetryInitialInterval},
+		{"retry_max_interval", &host.RetryMaxInterval},
+	}
+	for _, duration := range durations {
+		if !section.HasKey(duration.key) {
+			continue
+		}
+		value, err := section.Key(duration.key).Duration()
+		if err != nil || value <= 0 {
+			return fmt.Errorf(
+				"'%s' of host '%s' needs to be a positive duration, eg '30s' "+
+					"or '5m'",
+				duration.key, section.Name(),
+			)
+		}
+		*duration.value = value
+	}
+
+	if section.HasKey("retry_on") {
+		retryOn, err := jsonapi.ParseErrorClasses(
+			section.Key("retry_on").String(),
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"'retry_on' of host '%s' is invalid: %w", section.Name(), err,
+			)
+		}
+		host.RetryOn = &retryOn
+	}
+	return nil
+}
+
 func (rootCfg *RootConfig) sortHosts() {
 	sort.Slice(rootCfg.Hosts, func(i, j int) bool {
 		left := rootCfg.Hosts[i].Name
@@ -152,6 +229,49 @@ func (rootCfg *RootConfig) saveToWriter(file io.Writer) error {
 				return err
 			}
 		}
+
+		if host.RequestsPerSecond > 0 {
+			_, err := section.NewKey(
+				"requests_per_second",
+				strconv.FormatFloat(host.RequestsPerSecond, 'f', -1, 64),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if host.RetryMaxAttempts > 0 {
+			_, err := section.NewKey(
+				"retry_max_attempts", strconv.Itoa(host.RetryMaxAttempts),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		durations := []struct {
+			key   string
+			value time.Duration
+		}{
+			{"retry_max_elapsed_time", host.RetryMaxElapsedTime},
+			{"retry_initial_interval", host.RetryInitialInterval},
+			{"retry_max_interval", host.RetryMaxInterval},
+		}
+		for _, duration := range durations {
+			if duration.value > 0 {
+				_, err := section.NewKey(duration.key, duration.value.String())
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if host.RetryOn != nil {
+			_, err := section.NewKey("retry_on", host.RetryOn.String())
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	_, err := cfg.WriteTo(file)
@@ -191,6 +311,19 @@ func rootConfigsEqual(left, right *RootConfig) bool {
 		if leftHost.Token != rightHost.Token {
 			return false
 		}
+		if leftHost.RequestsPerSecond != rightHost.RequestsPerSecond {
+			return false
+		}
+		if leftHost.RetryMaxAttempts != rightHost.RetryMaxAttempts ||
+			leftHost.RetryMaxElapsedTime != rightHost.RetryMaxElapsedTime ||
+			leftHost.RetryInitialInterval != rightHost.RetryInitialInterval ||
+			leftHost.RetryMaxInterval != rightHost.RetryMaxInterval {
+			return false
+		}
+		if (leftHost.RetryOn == nil) != (rightHost.RetryOn == nil) ||
+			(leftHost.RetryOn != nil && *leftHost.RetryOn != *rightHost.RetryOn) {
+			return false
+		}
 	}
 	return true
 }
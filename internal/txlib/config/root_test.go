@@ -3,6 +3,9 @@ package config
 import (
 	"bytes"
 	"testing"
+	"time"
+
+	"github.com/transifex/cli/pkg/jsonapi"
 )
 
 func TestLoadExampleRootConfig(t *testing.T) {
@@ -27,7 +30,7 @@ func TestLoadExampleRootConfig(t *testing.T) {
 
 	if !rootConfigsEqual(rootCfg, &expected) {
 		t.Errorf(
-			"Root config is wrong; got %s, expected %s",
+			"Root config is wrong; got %+v, expected %+v",
 			rootCfg,
 			expected,
 		)
@@ -35,6 +38,7 @@ func TestLoadExampleRootConfig(t *testing.T) {
 }
 
 func TestSaveAndLoadRootConfig(t *testing.T) {
+	retryOn := jsonapi.RetryThrottled | jsonapi.RetryTimeouts
 	expected := RootConfig{
 		Hosts: []Host{
 			{
@@ -46,6 +50,21 @@ func TestSaveAndLoadRootConfig(t *testing.T) {
 				RestHostname: "My RestHostname",
 				Token:        "My Token",
 			},
+			{
+				Name:              "Other Name",
+				RestHostname:      "Other RestHostname",
+				Token:             "Other Token",
+				RequestsPerSecond: 2.5,
+			},
+			{
+				Name:                 "Retry Name",
+				RestHostname:         "Retry RestHostname",
+				RetryMaxAttempts:     3,
+				RetryMaxElapsedTime:  time.Minute,
+				RetryInitialInterval: 500 * time.Millisecond,
+				RetryMaxInterval:     10 * time.Second,
+				RetryOn:              &retryOn,
+			},
 		},
 	}
 
@@ -62,9 +81,51 @@ func TestSaveAndLoadRootConfig(t *testing.T) {
 
 	if !rootConfigsEqual(&expected, newRootCfg) {
 		t.Errorf(
-			"Root config is wrong; got %s, expected %s",
+			"Root config is wrong; got %+v, expected %+v",
 			newRootCfg,
 			expected,
 		)
 	}
 }
+
+func TestLoadRootConfigInvalidRequestsPerSecond(t *testing.T) {
+	_, err := loadRootConfigFromBytes([]byte(
+		"[https://app.transifex.com]\nrequests_per_second = fast\n",
+	))
+	if err == nil {
+		t.Error("Expected error for invalid 'requests_per_second'")
+	}
+}
+
+func TestLoadRootConfigRetrySettings(t *testing.T) {
+	rootCfg, err := loadRootConfigFromBytes([]byte(
+		"[https://app.transifex.com]\n" +
+			"retry_max_attempts = 2\n" +
+			"retry_max_elapsed_time = 30s\n" +
+			"retry_on = throttled, server_errors\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := rootCfg.Hosts[0]
+	if host.RetryMaxAttempts != 2 || host.RetryMaxElapsedTime != 30*time.Second ||
+		host.RetryInitialInterval != 0 || host.RetryOn == nil ||
+		*host.RetryOn != jsonapi.RetryThrottled|jsonapi.RetryServerErrors {
+		t.Errorf("Got host %+v", host)
+	}
+
+	for _, setting := range []string{
+		"retry_max_attempts = 0",
+		"retry_max_attempts = many",
+		"retry_max_interval = 10",
+		"retry_initial_interval = -1s",
+		"retry_on = throttled,everything",
+	} {
+		_, err := loadRootConfigFromBytes([]byte(
+			"[https://app.transifex.com]\n" + setting + "\n",
+		))
+		if err == nil {
+			t.Errorf("Expected error for '%s'", setting)
+		}
+	}
+}
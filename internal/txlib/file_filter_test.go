@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/transifex/cli/pkg/assert"
@@ -171,3 +173,45 @@ func TestNormaliseFileFilterWinBased(t *testing.T) {
 
 	assert.Equal(t, result, expected)
 }
+
+func TestSearchResourceFilesIgnore(t *testing.T) {
+	afterTest := beforeFileFilterTest(t)
+	defer afterTest()
+	for _, dir := range []string{"en", "el", "fr", "node_modules", "build"} {
+		err := os.Mkdir(dir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, "file.json"), nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(".gitignore", []byte("# Build output\nbuild/\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initTestGitRepo(t)
+
+	cfg := getStandardConfig()
+	cfg.Local.Ignore = []string{"node_modules"}
+	cfgResource := &cfg.Local.Resources[0]
+	cfgResource.Ignore = []string{"fr/"}
+
+	languageCodes := func() []string {
+		files, err := searchResourceFiles(cfg, cfgResource, ".", "<lang>/file.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for languageCode := range files {
+			result = append(result, languageCode)
+		}
+		sort.Strings(result)
+		return result
+	}
+	assert.Equal(t, strings.Join(languageCodes(), ","), "build,el,en")
+
+	cfg.Local.UseGitignore = true
+	assert.Equal(t, strings.Join(languageCodes(), ","), "el,en")
+}
@@ -2,7 +2,11 @@ package txlib
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/transifex/cli/internal/txlib/config"
 )
 
 const PathSeparator = string(os.PathSeparator)
@@ -171,3 +175,99 @@ func normaliseFileFilter(fileFilter string) string {
 	}
 	return fileFilter
 }
+
+/*
+Leaves out, of the files that searchFileFilter finds for a resource, the ones
+that match the 'ignore' patterns of the local configuration or of the resource
+(.gitignore syntax, relative to the current directory) and, if 'use_gitignore'
+is set, the ones that git ignores
+*/
+type fileIgnorer struct {
+	curDir  string
+	matcher gitignore.Matcher
+	gitRoot string
+	git     gitignore.Matcher
+}
+
+func newFileIgnorer(
+	cfg *config.Config, cfgResource *config.Resource,
+) (*fileIgnorer, error) {
+	result := &fileIgnorer{}
+	var patterns []string
+	useGitignore := false
+	if cfg != nil && cfg.Local != nil {
+		patterns = append(patterns, cfg.Local.Ignore...)
+		useGitignore = cfg.Local.UseGitignore
+	}
+	patterns = append(patterns, cfgResource.Ignore...)
+	if len(patterns) > 0 {
+		curDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		var parsed []gitignore.Pattern
+		for _, pattern := range patterns {
+			parsed = append(parsed, gitignore.ParsePattern(pattern, nil))
+		}
+		result.curDir = curDir
+		result.matcher = gitignore.NewMatcher(parsed)
+	}
+	if useGitignore {
+		// Outside of a git repository there is nothing to ignore
+		result.git, result.gitRoot, _ = getGitignoreMatcher()
+	}
+	return result, nil
+}
+
+func (ignorer *fileIgnorer) isIgnored(path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	match := func(matcher gitignore.Matcher, root, path string) bool {
+		relative, err := filepath.Rel(root, path)
+		if err != nil || strings.HasPrefix(relative, "..") {
+			return false
+		}
+		return matcher.Match(
+			strings.Split(filepath.ToSlash(relative), "/"), false,
+		)
+	}
+	if ignorer.matcher != nil && match(ignorer.matcher, ignorer.curDir, path) {
+		return true
+	}
+	if ignorer.git != nil {
+		// The root of the git repository has its symlinks resolved
+		dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err == nil && match(
+			ignorer.git, ignorer.gitRoot, filepath.Join(dir, filepath.Base(path)),
+		) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove the ignored files from the result of searchFileFilter
+func (ignorer *fileIgnorer) filter(files map[string]string) map[string]string {
+	for languageCode, path := range files {
+		if ignorer.isIgnored(path) {
+			delete(files, languageCode)
+		}
+	}
+	return files
+}
+
+/*
+Like searchFileFilter, leaving out the files that the local configuration says
+should be ignored for the resource (see fileIgnorer)
+*/
+func searchResourceFiles(
+	cfg *config.Config, cfgResource *config.Resource, root, fileFilter string,
+) (map[string]string, error) {
+	ignorer, err := newFileIgnorer(cfg, cfgResource)
+	if err != nil {
+		return nil, err
+	}
+	return ignorer.filter(searchFileFilter(root, fileFilter)), nil
+}
@@ -1,12 +1,23 @@
 package txlib
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/gosimple/slug"
 )
 
 func getGitBranch() string {
@@ -43,43 +54,663 @@ func getGitBranchFromGoGit() string {
 	}
 }
 
+/*
+Return the time 'path' was last committed, zero if it can't be found. The first
+call builds an index of the whole repository's history (see
+getGitTimestampIndex) that the rest of the calls from the same directory reuse,
+so that comparing thousands of files doesn't run 'git log' for each one.
+*/
 func getLastCommitDate(path string) time.Time {
-	result := getLastCommitDateFromBinary(path)
-	if result != (time.Time{}) {
-		return result
+	curDir, err := os.Getwd()
+	if err != nil {
+		return time.Time{}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(curDir, path)
+	}
+	// The index has the real paths of files, in case the repository is
+	// reached through a symlink
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return time.Time{}
 	}
-	return getLastCommitDateFromGoGit(path)
+	return getGitTimestampIndex(curDir)[filepath.Join(dir, filepath.Base(path))]
 }
 
-func getLastCommitDateFromBinary(path string) time.Time {
+// Working directory -> absolute path -> time of the last commit that changed it
+var gitTimestampIndexes = make(map[string]map[string]time.Time)
+var gitTimestampIndexesLock sync.Mutex
+
+func getGitTimestampIndex(curDir string) map[string]time.Time {
+	gitTimestampIndexesLock.Lock()
+	defer gitTimestampIndexesLock.Unlock()
+	index, exists := gitTimestampIndexes[curDir]
+	if !exists {
+		index = getGitTimestampIndexFromBinary()
+		if index == nil {
+			index = getGitTimestampIndexFromGoGit()
+		}
+		// An empty index is cached too, there is no point in retrying
+		gitTimestampIndexes[curDir] = index
+	}
+	return index
+}
+
+/*
+Build the index with a single 'git log' over the history of HEAD: commits are
+listed newest first, so the first one that mentions a path is the last one
+that changed it. Returns nil if git is not available or this is not a git
+repository.
+*/
+func getGitTimestampIndexFromBinary() map[string]time.Time {
+	rootBytes, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(strings.TrimSpace(string(rootBytes)))
+	if err != nil {
+		return nil
+	}
 	outBytes, err := exec.Command(
-		"git", "log", "--max-count=1", "--format=format:%at", "--", path,
+		"git", "-c", "core.quotePath=false", "log", "--name-only",
+		"--no-renames", "--format=format:%x00%at",
 	).Output()
 	if err != nil {
-		return time.Time{}
+		return nil
 	}
-	outInt, err := strconv.Atoi(strings.TrimSpace(string(outBytes)))
+	result := make(map[string]time.Time)
+	var current time.Time
+	for _, line := range strings.Split(string(outBytes), "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			timestamp, err := strconv.ParseInt(line[1:], 10, 64)
+			if err != nil {
+				return nil
+			}
+			current = time.Unix(timestamp, 0)
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(line))
+		if _, exists := result[path]; !exists {
+			result[path] = current
+		}
+	}
+	return result
+}
+
+/*
+Same as getGitTimestampIndexFromBinary, walking the history with go-git. Like
+'git log', merge commits are not diffed. Returns an empty index on errors.
+*/
+func getGitTimestampIndexFromGoGit() map[string]time.Time {
+	result := make(map[string]time.Time)
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
+	)
 	if err != nil {
-		return time.Time{}
+		return result
 	}
-	return time.Unix(int64(outInt), 0)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return result
+	}
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return result
+	}
+	commits, err := repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return result
+	}
+	add := func(name string, when time.Time) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if _, exists := result[path]; !exists {
+			result[path] = when
+		}
+	}
+	_ = commits.ForEach(func(commit *object.Commit) error {
+		if commit.NumParents() > 1 {
+			return nil
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		if commit.NumParents() == 0 {
+			return tree.Files().ForEach(func(file *object.File) error {
+				add(file.Name, commit.Author.When)
+				return nil
+			})
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return err
+		}
+		parentTree, err := parent.Tree()
+		if err != nil {
+			return err
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			// Deleted files don't need a timestamp
+			if change.To.Name != "" {
+				add(change.To.Name, commit.Author.When)
+			}
+		}
+		return nil
+	})
+	return result
 }
 
-func getLastCommitDateFromGoGit(path string) time.Time {
-	// TODO: check if parent folder is repo
-	repo, err := git.PlainOpen(".")
+/*
+Return the names of the branches of the git repository the current directory
+is in: local branches and the remote-tracking ones (without the remote's name).
+Remote branches are only as fresh as the last 'git fetch'.
+*/
+func getGitBranches() ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
+	)
 	if err != nil {
-		return time.Time{}
+		return nil, fmt.Errorf("could not open git repository: %w", err)
 	}
-	cIter, err := repo.Log(
-		&git.LogOptions{FileName: &path, Order: git.LogOrderCommitterTime},
+	tips, err := getGitBranchTips(repo)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(tips))
+	for name := range tips {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// How far back getGitParentBranch looks into the history of a branch
+const gitParentBranchMaxDepth = 5000
+
+/*
+Find the branch that 'branch' was most likely created from, for stacked
+feature branches: among the other local and remote-tracking branches, the one
+whose common ancestor with 'branch' is the fewest commits away from the tip of
+'branch'. Ties go to the branch with the fewest commits of its own since that
+ancestor, then to the name. Branches that contain all of 'branch' and more
+(branches stacked on top of it) are not candidates. Branches that point to the
+same commit are, so that a branch without commits of its own yet is matched to
+the branch it was created from; the checked out branch is left out of those
+since it is 'branch' itself under another name. Returns an empty string if
+nothing is found.
+*/
+func getGitParentBranch(branch string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
 	)
 	if err != nil {
-		return time.Time{}
+		return "", fmt.Errorf("could not open git repository: %w", err)
 	}
-	commit, err := cIter.Next()
+	tips, err := getGitBranchTips(repo)
 	if err != nil {
-		return time.Time{}
+		return "", err
+	}
+	headRef, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	checkedOut := ""
+	if headRef.Name().IsBranch() {
+		checkedOut = headRef.Name().Short()
+	}
+	head, exists := tips[branch]
+	if !exists {
+		head = headRef.Hash()
+	}
+	headDepths, err := getGitCommitDepths(repo, head, nil)
+	if err != nil {
+		return "", err
+	}
+
+	result := ""
+	bestDistance, bestOwnCommits := -1, -1
+	names := make([]string, 0, len(tips))
+	for name := range tips {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if slug.Make(name) == slug.Make(branch) {
+			continue
+		}
+		// Walking back from the candidate, stop at the first commits that
+		// 'branch' also has; the closest of them is the merge base
+		ownDepths, err := getGitCommitDepths(repo, tips[name], headDepths)
+		if err != nil {
+			return "", err
+		}
+		distance, ownCommits := -1, -1
+		for hash, ownDepth := range ownDepths {
+			headDepth, common := headDepths[hash]
+			if !common {
+				continue
+			}
+			if distance == -1 || headDepth < distance ||
+				(headDepth == distance && ownDepth < ownCommits) {
+				distance, ownCommits = headDepth, ownDepth
+			}
+		}
+		if distance == -1 || (distance == 0 && ownCommits > 0) {
+			// Unrelated, or stacked on top of 'branch'
+			continue
+		}
+		if distance == 0 && name == checkedOut {
+			continue
+		}
+		if bestDistance == -1 || distance < bestDistance ||
+			(distance == bestDistance && ownCommits < bestOwnCommits) {
+			result, bestDistance, bestOwnCommits = name, distance, ownCommits
+		}
+	}
+	return result, nil
+}
+
+/*
+Branch name -> the commit it points to, for local and remote-tracking
+branches. Local branches win over remote-tracking ones with the same name.
+*/
+func getGitBranchTips(repo *git.Repository) (map[string]plumbing.Hash, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]plumbing.Hash)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if name.IsBranch() {
+			result[name.Short()] = ref.Hash()
+		} else if name.IsRemote() {
+			parts := strings.SplitN(name.Short(), "/", 2)
+			if len(parts) != 2 || parts[1] == "HEAD" {
+				return nil
+			}
+			if _, exists := result[parts[1]]; !exists {
+				result[parts[1]] = ref.Hash()
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+/*
+Commit -> how many commits away from 'start' it is, walking back through
+parents breadth-first. Commits in 'stopAt' are recorded but not walked past.
+*/
+func getGitCommitDepths(
+	repo *git.Repository, start plumbing.Hash, stopAt map[plumbing.Hash]int,
+) (map[plumbing.Hash]int, error) {
+	result := map[plumbing.Hash]int{start: 0}
+	queue := []plumbing.Hash{start}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		depth := result[hash]
+		if _, stop := stopAt[hash]; stop || depth >= gitParentBranchMaxDepth {
+			continue
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range commit.ParentHashes {
+			if _, seen := result[parent]; !seen {
+				result[parent] = depth + 1
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return result, nil
+}
+
+/*
+Return the absolute paths of the files that changed between 'ref' and HEAD,
+plus the ones that are modified or untracked in the working tree. Files that
+were renamed or deleted are included under their old paths as well.
+*/
+func getGitChangedFiles(ref string) (map[string]bool, error) {
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not open git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	getTree := func(revision string) (*object.Tree, error) {
+		hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve '%s': %w", revision, err)
+		}
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return nil, err
+		}
+		return commit.Tree()
+	}
+	fromTree, err := getTree(ref)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := getTree("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool)
+	add := func(name string) {
+		if name != "" {
+			result[filepath.Join(root, filepath.FromSlash(name))] = true
+		}
+	}
+	for _, change := range changes {
+		add(change.From.Name)
+		add(change.To.Name)
+	}
+	names, err := getGitWorktreeChanges(root)
+	if err == errNoGitBinary {
+		// go-git hashes every file of the working tree, which is slow in
+		// big repositories, so it's only used without the git binary
+		var status git.Status
+		status, err = worktree.Status()
+		names = nil
+		for name, fileStatus := range status {
+			if fileStatus.Worktree != git.Unmodified ||
+				fileStatus.Staging != git.Unmodified {
+				names = append(names, name)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		add(name)
+	}
+	return result, nil
+}
+
+var errNoGitBinary = errors.New("git is not installed")
+
+/*
+Return the paths, relative to 'root' and with slashes, of the files that are
+modified, staged or untracked in the working tree, using 'git status'. Renamed
+files are returned under both paths. Returns errNoGitBinary if git isn't
+installed.
+*/
+func getGitWorktreeChanges(root string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errNoGitBinary
+	}
+	command := exec.Command(
+		"git", "status", "--porcelain", "-z", "--untracked-files=all",
+	)
+	command.Dir = root
+	outBytes, err := command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the status of the git repository: %w", err)
+	}
+	var result []string
+	entries := strings.Split(string(outBytes), "\x00")
+	for i := 0; i < len(entries); i++ {
+		// 'XY path', followed by the original path for renames and copies
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		result = append(result, entry[3:])
+		if (entry[0] == 'R' || entry[0] == 'C') && i+1 < len(entries) {
+			i++
+			result = append(result, entries[i])
+		}
+	}
+	return result, nil
+}
+
+/*
+Stage 'paths' and commit them with 'message', using the author of the git
+configuration. If 'branchName' is set, a new branch is created from HEAD and
+checked out first, keeping the changes in the working tree. Returns the hash
+of the commit, or an empty string if none of the files had changes, and how
+many files were committed. Fails if other files have staged changes, since
+they would end up in the commit too.
+*/
+func gitCommitFiles(
+	paths []string, message, branchName string,
+) (string, int, error) {
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
+	)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not open git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", 0, err
+	}
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return "", 0, err
+	}
+
+	var names []string
+	for _, path := range paths {
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return "", 0, err
+		}
+		dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			return "", 0, err
+		}
+		name, err := filepath.Rel(root, filepath.Join(dir, filepath.Base(path)))
+		if err != nil || strings.HasPrefix(name, "..") {
+			return "", 0, fmt.Errorf("'%s' is outside the git repository", path)
+		}
+		names = append(names, filepath.ToSlash(name))
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return "", 0, err
+	}
+	var changed []string
+	for _, name := range names {
+		// Files that aren't in the status are unmodified
+		fileStatus, exists := status[name]
+		if exists && (fileStatus.Worktree != git.Unmodified ||
+			fileStatus.Staging != git.Unmodified) {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) == 0 {
+		return "", 0, nil
+	}
+
+	var staged []string
+	for name, fileStatus := range status {
+		if fileStatus.Staging != git.Unmodified &&
+			fileStatus.Staging != git.Untracked &&
+			!stringSliceContains(names, name) {
+			staged = append(staged, name)
+		}
+	}
+	if len(staged) > 0 {
+		sort.Strings(staged)
+		return "", 0, fmt.Errorf(
+			"other files have staged changes (%s), commit or unstage them first",
+			strings.Join(staged, ", "),
+		)
+	}
+
+	if branchName != "" {
+		err = worktree.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(branchName),
+			Create: true,
+			Keep:   true,
+		})
+		if err != nil {
+			return "", 0, fmt.Errorf("could not create branch '%s': %w", branchName, err)
+		}
+	}
+	for _, name := range changed {
+		_, err = worktree.Add(name)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	hash, err := worktree.Commit(message, &git.CommitOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	return hash.String(), len(changed), nil
+}
+
+/*
+Return the root of the working tree of the git repository the current
+directory is in, and the repository's '.git' directory
+*/
+func getGitDirs() (string, string, error) {
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("could not open git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", "", err
+	}
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", "", errors.New("git repository is not on the filesystem")
+	}
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return "", "", err
+	}
+	return root, storage.Filesystem().Root(), nil
+}
+
+/*
+Return the status of the files of the working tree, keyed by their path
+relative to its root, with slashes. Unmodified files are left out.
+*/
+func getGitStatus() (git.Status, error) {
+	repo, err := git.PlainOpenWithOptions(
+		".", &git.PlainOpenOptions{DetectDotGit: true},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not open git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	return worktree.Status()
+}
+
+// Root of the working tree -> what its .gitignore files say
+var gitignoreMatchers = make(map[string]gitignore.Matcher)
+var gitignoreMatchersLock sync.Mutex
+
+/*
+Return a matcher for the patterns of the .gitignore files of the git repository
+the current directory is in, plus its '.git/info/exclude', and the root of its
+working tree. Paths given to the matcher are relative to that root. Patterns
+are only read once per repository.
+*/
+func getGitignoreMatcher() (gitignore.Matcher, string, error) {
+	root, gitDir, err := getGitDirs()
+	if err != nil {
+		return nil, "", err
+	}
+	gitignoreMatchersLock.Lock()
+	defer gitignoreMatchersLock.Unlock()
+	matcher, exists := gitignoreMatchers[root]
+	if !exists {
+		patterns := readGitignoreFile(
+			filepath.Join(gitDir, "info", "exclude"), nil,
+		)
+		patterns, err = readGitignorePatterns(root, nil, patterns)
+		if err != nil {
+			return nil, "", err
+		}
+		matcher = gitignore.NewMatcher(patterns)
+		gitignoreMatchers[root] = matcher
+	}
+	return matcher, root, nil
+}
+
+/*
+Add the patterns of the .gitignore files under 'path' (relative to 'root') to
+'patterns'. Like git, directories that are ignored are not looked into.
+*/
+func readGitignorePatterns(
+	root string, path []string, patterns []gitignore.Pattern,
+) ([]gitignore.Pattern, error) {
+	dir := filepath.Join(append([]string{root}, path...)...)
+	patterns = append(
+		patterns, readGitignoreFile(filepath.Join(dir, ".gitignore"), path)...,
+	)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	matcher := gitignore.NewMatcher(patterns)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == ".git" {
+			continue
+		}
+		subPath := append(append([]string{}, path...), entry.Name())
+		if matcher.Match(subPath, true) {
+			continue
+		}
+		patterns, err = readGitignorePatterns(root, subPath, patterns)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return patterns, nil
+}
+
+// The patterns of a .gitignore-like file, none if it can't be read
+func readGitignoreFile(path string, domain []string) []gitignore.Pattern {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var result []gitignore.Pattern
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, gitignore.ParsePattern(line, domain))
 	}
-	return commit.Author.When
+	return result
 }
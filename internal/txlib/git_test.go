@@ -0,0 +1,252 @@
+package txlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+/*
+Check out 'branch', creating it from the current HEAD if 'create' is set, and
+commit a change to 'file' on it. Other uncommitted changes are kept if 'branch'
+is already checked out.
+*/
+func commitOnTestBranch(
+	t *testing.T, repo *git.Repository, branch, file string, create bool,
+) plumbing.Hash {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Name() != plumbing.NewBranchReferenceName(branch) {
+		err = worktree.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(branch), Create: create,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Valid JSON, so that it can also be pushed as a source file
+	content := fmt.Sprintf(`{"%s": "%s"}`, branch, time.Now())
+	err = os.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = worktree.Add(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("Change "+file, &git.CommitOptions{
+		Author: &object.Signature{
+			Name: "Test", Email: "test@example.com", When: time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestGetGitParentBranch(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	repo := initTestGitRepo(t)
+
+	// main: c0 - c1, feat-a: c0 - a1 - a2, Feat/B: a2 - b1
+	commitOnTestBranch(t, repo, "feat-a", "a.txt", true)
+	commitOnTestBranch(t, repo, "feat-a", "a.txt", false)
+	tip := commitOnTestBranch(t, repo, "Feat/B", "b.txt", true)
+	commitOnTestBranch(t, repo, "main", "main.txt", false)
+	// The remote-tracking branch of 'Feat/B' itself is not a candidate
+	err := repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("origin", "feat-b"), tip,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Just created from 'Feat/B', without commits of its own, and checked out
+	err = repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewBranchReferenceName("feat-c"), tip,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feat-c"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for branch, expected := range map[string]string{
+		"Feat/B": "feat-a",
+		"feat-a": "main",
+		"feat-c": "Feat/B",
+		// Pushed under a name that isn't a git branch
+		"custom": "Feat/B",
+	} {
+		parent, err := getGitParentBranch(branch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parent != expected {
+			t.Errorf("Got parent '%s' for '%s', expected '%s'", parent, branch, expected)
+		}
+	}
+}
+
+func TestGetGitChangedFiles(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	for _, name := range []string{"a.txt", "b.txt", "old.txt"} {
+		err := os.WriteFile(name, []byte(name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := initTestGitRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile("a.txt", []byte("modified"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = worktree.Move("old.txt", "new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll("locale", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join("locale", "c.txt"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	curDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := func() {
+		t.Helper()
+		changed, err := getGitChangedFiles("HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for path := range changed {
+			name, err := filepath.Rel(curDir, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, filepath.ToSlash(name))
+		}
+		sort.Strings(names)
+		expected := "a.txt,locale/c.txt,new.txt,old.txt"
+		if strings.Join(names, ",") != expected {
+			t.Errorf("Got changed files %v, expected %s", names, expected)
+		}
+	}
+	test()
+
+	// Without the git binary
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", "")
+	test()
+}
+
+func TestGetLastCommitDate(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	repo := initTestGitRepo(t)
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(when time.Time, files ...string) {
+		for _, file := range files {
+			err := os.MkdirAll(filepath.Dir(file), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(file, []byte(when.String()), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = worktree.Add(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := worktree.Commit("Change files", &git.CommitOptions{
+			Author: &object.Signature{
+				Name: "Test", Email: "test@example.com", When: when,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit(first, "a.txt", "locale/b.txt")
+	commit(second, "a.txt")
+	err = os.WriteFile("untracked.txt", nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	curDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]time.Time{
+		"a.txt":                                  second,
+		"locale/b.txt":                           first,
+		filepath.Join(curDir, "locale", "b.txt"): first,
+		"untracked.txt":                          {},
+	}
+	for path, when := range expected {
+		if result := getLastCommitDate(path); !result.Equal(when) {
+			t.Errorf("Got %s for '%s', expected %s", result, path, when)
+		}
+	}
+
+	// Both ways of building the index agree
+	fromBinary := getGitTimestampIndexFromBinary()
+	if fromBinary == nil {
+		t.Skip("git is not available")
+	}
+	fromGoGit := getGitTimestampIndexFromGoGit()
+	if len(fromBinary) != len(fromGoGit) {
+		t.Errorf("Got %d paths from git and %d from go-git",
+			len(fromBinary), len(fromGoGit))
+	}
+	for path, when := range fromBinary {
+		if !fromGoGit[path].Equal(when) {
+			t.Errorf("Got %s for '%s' from go-git, expected %s",
+				fromGoGit[path], path, when)
+		}
+	}
+}
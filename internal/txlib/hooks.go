@@ -0,0 +1,97 @@
+package txlib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The git hooks 'tx hooks install' writes by default
+var defaultGitHooks = []string{"pre-commit", "pre-push"}
+
+// Marks the hooks that 'tx hooks install' wrote, so that they can be replaced
+const gitHookMarker = "# Installed by 'tx hooks install'"
+
+/*
+The content of the hooks. 'tx' is looked up in the PATH when the hook runs, the
+path of the current executable may not exist after an upgrade. If it can't be
+found, or if 'tx check' fails in "warn" mode, the commit or push goes ahead.
+*/
+const gitHookContent = `#!/bin/sh
+` + gitHookMarker + `
+if ! command -v tx >/dev/null 2>&1; then
+	echo "Warning: 'tx' was not found in the PATH, skipping 'tx check'" >&2
+	exit 0
+fi
+exec tx check --hook "$@"
+`
+
+type HooksInstallArguments struct {
+	// Names of the git hooks; empty for defaultGitHooks
+	Hooks []string
+	// Replace hooks that weren't written by 'tx hooks install'
+	Force bool
+}
+
+/*
+HooksInstallCommand
+Write git hooks that run 'tx check', so that developers are warned, or stopped,
+when they commit or push source files that they haven't pushed to Transifex.
+Whether they warn or block is up to the 'check_mode' of the local
+configuration.
+*/
+func HooksInstallCommand(args HooksInstallArguments, out io.Writer) error {
+	hooks := args.Hooks
+	if len(hooks) == 0 {
+		hooks = defaultGitHooks
+	}
+	for _, hook := range hooks {
+		if hook != "pre-commit" && hook != "pre-push" {
+			return fmt.Errorf(
+				"unsupported hook '%s', should be one of: %s",
+				hook, strings.Join(defaultGitHooks, ", "),
+			)
+		}
+	}
+	_, gitDir, err := getGitDirs()
+	if err != nil {
+		return err
+	}
+	hooksDir := filepath.Join(gitDir, "hooks")
+
+	// Check all the hooks first so that nothing is installed if one of them
+	// can't be
+	for _, hook := range hooks {
+		existing, err := os.ReadFile(filepath.Join(hooksDir, hook))
+		if err == nil && !args.Force &&
+			!strings.Contains(string(existing), gitHookMarker) {
+			return fmt.Errorf(
+				"the '%s' hook already exists, use '--force' to replace it",
+				hook,
+			)
+		} else if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	err = os.MkdirAll(hooksDir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		path := filepath.Join(hooksDir, hook)
+		err = os.WriteFile(path, []byte(gitHookContent), 0755)
+		if err != nil {
+			return err
+		}
+		// WriteFile doesn't change the mode of existing files
+		err = os.Chmod(path, 0755)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Installed the '%s' hook\n", hook)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package txlib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHooksInstallCommand(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	initTestGitRepo(t)
+	err := os.MkdirAll(filepath.Join(".git", "hooks"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prePush := filepath.Join(".git", "hooks", "pre-push")
+	err = os.WriteFile(prePush, []byte("#!/bin/sh\nmake test\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = HooksInstallCommand(HooksInstallArguments{}, &out)
+	if err == nil || !strings.Contains(err.Error(), "'pre-push' hook already exists") {
+		t.Errorf("Expected an error about the existing hook, got %v", err)
+	}
+	_, err = os.Stat(filepath.Join(".git", "hooks", "pre-commit"))
+	if !os.IsNotExist(err) {
+		t.Error("Installed 'pre-commit' even though 'pre-push' failed")
+	}
+	err = HooksInstallCommand(HooksInstallArguments{Force: true}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hook := range []string{"pre-commit", "pre-push"} {
+		content, err := os.ReadFile(filepath.Join(".git", "hooks", hook))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "exec tx check --hook \"$@\"\n") ||
+			!strings.Contains(string(content), gitHookMarker) {
+			t.Errorf("Got '%s' hook:\n%s", hook, content)
+		}
+	}
+
+	// Hooks written by the client can be replaced without '--force'
+	err = HooksInstallCommand(
+		HooksInstallArguments{Hooks: []string{"pre-push"}}, &out,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = HooksInstallCommand(
+		HooksInstallArguments{Hooks: []string{"post-merge"}}, &out,
+	)
+	if err == nil {
+		t.Error("Expected an error for an unsupported hook")
+	}
+}
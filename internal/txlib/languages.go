@@ -0,0 +1,521 @@
+package txlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+type LanguagesListArguments struct {
+	// See figureOutRemoteProjectId
+	Project          string
+	OrganizationSlug string
+	Json             bool
+}
+
+// How a language of a project is presented in JSON output
+type languageOutput struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Rtl    bool   `json:"rtl"`
+	Source bool   `json:"source"`
+}
+
+/*
+LanguagesListCommand
+Print the source language and the target languages of a project, as a table or
+as JSON
+*/
+func LanguagesListCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args LanguagesListArguments,
+	out io.Writer,
+) error {
+	project, err := getRemoteProject(
+		cfg, &api, args.Project, args.OrganizationSlug,
+	)
+	if err != nil {
+		return err
+	}
+	items, err := getProjectLanguageOutputs(&api, project)
+	if err != nil {
+		return err
+	}
+	if args.Json {
+		return writeJson(out, items)
+	}
+
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "CODE\tNAME\tRTL\tSOURCE")
+	for _, item := range items {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n",
+			item.Code, item.Name, yesNo(item.Rtl), yesNo(item.Source))
+	}
+	return table.Flush()
+}
+
+/*
+Return the source language of a project followed by its target languages,
+sorted by code
+*/
+func getProjectLanguageOutputs(
+	api *jsonapi.Connection, project *jsonapi.Resource,
+) ([]languageOutput, error) {
+	newItem := func(language *jsonapi.Resource) (languageOutput, error) {
+		var attributes txapi.LanguageAttributes
+		err := language.MapAttributes(&attributes)
+		return languageOutput{
+			Code: attributes.Code, Name: attributes.Name, Rtl: attributes.Rtl,
+		}, err
+	}
+
+	items := []languageOutput{}
+	relationship, exists := project.Relationships["source_language"]
+	if exists && relationship.DataSingular != nil {
+		sourceLanguage, err := api.Get("languages", relationship.DataSingular.Id)
+		if err != nil {
+			return nil, err
+		}
+		item, err := newItem(&sourceLanguage)
+		if err != nil {
+			return nil, err
+		}
+		item.Source = true
+		items = append(items, item)
+	}
+
+	languages, err := txapi.GetProjectLanguages(project)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return strings.ToLower(codes[i]) < strings.ToLower(codes[j])
+	})
+	for _, code := range codes {
+		item, err := newItem(languages[code])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+type LanguagesAddArguments struct {
+	// See figureOutRemoteProjectId
+	Project          string
+	OrganizationSlug string
+	LanguageCodes    []string
+	// Add the languages that the project's resources in the local
+	// configuration have files for, instead of 'LanguageCodes'
+	SyncFromConfig bool
+}
+
+/*
+LanguagesAddCommand
+Add target languages to a project. Languages the project already has are
+skipped.
+*/
+func LanguagesAddCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args LanguagesAddArguments,
+	out io.Writer,
+) error {
+	project, current, wanted, err := prepareLanguageChanges(
+		cfg, &api, args.Project, args.OrganizationSlug, args.LanguageCodes,
+		args.SyncFromConfig,
+	)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, code := range wanted {
+		if !stringSliceContains(current, code) {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) == 0 {
+		fmt.Fprintf(out, "Project '%s' already has all the languages\n", project.Id)
+		return nil
+	}
+	err = txapi.AddProjectLanguages(project, missing)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Added %s to project '%s'\n",
+		strings.Join(missing, ", "), project.Id)
+	return nil
+}
+
+type LanguagesRemoveArguments struct {
+	// See figureOutRemoteProjectId
+	Project          string
+	OrganizationSlug string
+	LanguageCodes    []string
+	// Remove the languages that none of the project's resources in the local
+	// configuration have files for, instead of 'LanguageCodes'; languages
+	// that any remote resource has translations for are kept
+	SyncFromConfig bool
+	// Don't ask for confirmation
+	NoInteractive bool
+}
+
+/*
+LanguagesRemoveCommand
+Remove target languages, and all their translations, from a project. Asks for
+confirmation first, unless 'NoInteractive' is set.
+*/
+func LanguagesRemoveCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args LanguagesRemoveArguments,
+	out io.Writer,
+) error {
+	project, current, wanted, err := prepareLanguageChanges(
+		cfg, &api, args.Project, args.OrganizationSlug, args.LanguageCodes,
+		args.SyncFromConfig,
+	)
+	if err != nil {
+		return err
+	}
+	var extra []string
+	for _, code := range current {
+		// With 'SyncFromConfig', 'wanted' holds the languages to keep
+		if stringSliceContains(wanted, code) != args.SyncFromConfig {
+			extra = append(extra, code)
+		}
+	}
+	if args.SyncFromConfig && len(extra) > 0 {
+		extra, err = skipTranslatedLanguages(&api, project, extra, out)
+		if err != nil {
+			return err
+		}
+	}
+	if len(extra) == 0 {
+		fmt.Fprintf(out, "No languages to remove from project '%s'\n", project.Id)
+		return nil
+	}
+
+	if !args.NoInteractive && !askConfirmation(fmt.Sprintf(
+		"Remove %s and all their translations from project '%s'",
+		strings.Join(extra, ", "), project.Id,
+	)) {
+		fmt.Fprintln(out, "Nothing was removed")
+		return nil
+	}
+	err = txapi.RemoveProjectLanguages(project, extra)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Removed %s from project '%s'\n",
+		strings.Join(extra, ", "), project.Id)
+	return nil
+}
+
+/*
+Return the languages out of 'codes' that no remote resource of a project has
+translations for. The rest are reported to 'out'; they may be used by
+resources that are not in the local configuration, so removing them because
+there are no local files for them would lose translations.
+*/
+func skipTranslatedLanguages(
+	api *jsonapi.Connection,
+	project *jsonapi.Resource,
+	codes []string,
+	out io.Writer,
+) ([]string, error) {
+	stats, err := txapi.GetProjectStats(api, project)
+	if err != nil {
+		return nil, err
+	}
+	translatedIn := make(map[string][]string)
+	for _, stat := range stats {
+		var attributes txapi.ResourceLanguageStatsAttributes
+		err := stat.MapAttributes(&attributes)
+		if err != nil {
+			return nil, err
+		}
+		language := stat.Relationships["language"]
+		resource := stat.Relationships["resource"]
+		if attributes.TranslatedStrings == 0 || language == nil ||
+			language.DataSingular == nil || resource == nil ||
+			resource.DataSingular == nil {
+			continue
+		}
+		code := strings.TrimPrefix(language.DataSingular.Id, "l:")
+		translatedIn[code] = append(
+			translatedIn[code], resourceSlugFromId(resource.DataSingular.Id),
+		)
+	}
+
+	var result []string
+	for _, code := range codes {
+		resources, exists := translatedIn[code]
+		if !exists {
+			result = append(result, code)
+			continue
+		}
+		sort.Strings(resources)
+		fmt.Fprintf(
+			out, "Keeping %s, it has translations in: %s\n",
+			code, strings.Join(resources, ", "),
+		)
+	}
+	return result, nil
+}
+
+/*
+Fetch the project that the languages of 'tx languages add/remove' refer to,
+the codes of its current target languages and the codes the user asked for:
+either 'codes' or, if 'syncFromConfig', the ones found in the local files
+*/
+func prepareLanguageChanges(
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	projectArgument, organizationSlug string,
+	codes []string,
+	syncFromConfig bool,
+) (*jsonapi.Resource, []string, []string, error) {
+	if syncFromConfig && len(codes) > 0 {
+		return nil, nil, nil, errors.New(
+			"please provide either languages or '--sync-from-config', not both",
+		)
+	}
+	if !syncFromConfig && len(codes) == 0 {
+		return nil, nil, nil, errors.New("please provide some languages")
+	}
+	project, err := getRemoteProject(cfg, api, projectArgument, organizationSlug)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if syncFromConfig {
+		codes, err = getLocalLanguageCodes(cfg, project)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(codes) == 0 {
+			// Most likely the wrong directory or a wrong 'file_filter';
+			// carrying on would remove all the languages
+			return nil, nil, nil, fmt.Errorf(
+				"no translation files found for project '%s', check the "+
+					"'file_filter' of its resources and that you are in the "+
+					"root directory of the local configuration",
+				project.Id,
+			)
+		}
+	}
+	languages, err := txapi.GetProjectLanguages(project)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	current := make([]string, 0, len(languages))
+	for code := range languages {
+		current = append(current, code)
+	}
+	sort.Strings(current)
+	return project, current, codes, nil
+}
+
+/*
+Return the remote codes of the languages that the resources of a project in
+the local configuration have translation files for, sorted. Language mappings
+and per-language file overrides are taken into account; the source languages
+are left out.
+*/
+func getLocalLanguageCodes(
+	cfg *config.Config, project *jsonapi.Resource,
+) ([]string, error) {
+	curDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	var projectAttributes txapi.ProjectAttributes
+	err = project.MapAttributes(&projectAttributes)
+	if err != nil {
+		return nil, err
+	}
+	sourceLanguageId := ""
+	if relationship, exists := project.Relationships["source_language"]; exists &&
+		relationship.DataSingular != nil {
+		sourceLanguageId = relationship.DataSingular.Id
+	}
+
+	found := false
+	result := []string{}
+	for _, cfgResource := range cfg.FindResourcesByProject(projectAttributes.Slug) {
+		if fmt.Sprintf("o:%s:p:%s", cfgResource.OrganizationSlug,
+			cfgResource.ProjectSlug) != project.Id {
+			continue
+		}
+		found = true
+		err = checkFileFilter(cfgResource.FileFilter)
+		if err != nil {
+			return nil, err
+		}
+		localToRemote := reverseMap(
+			makeRemoteToLocalLanguageMappings(*cfg, *cfgResource),
+		)
+		localCodes, err := searchResourceFiles(
+			cfg, cfgResource, curDir, cfgResource.FileFilter,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for code := range cfgResource.Overrides {
+			localCodes[code] = ""
+		}
+		for localCode := range localCodes {
+			code, exists := localToRemote[localCode]
+			if !exists {
+				code = localCode
+			}
+			if code == cfgResource.SourceLanguage ||
+				fmt.Sprintf("l:%s", code) == sourceLanguageId ||
+				stringSliceContains(result, code) {
+				continue
+			}
+			result = append(result, code)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf(
+			"project '%s' has no resources in the local configuration",
+			project.Id,
+		)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+/*
+A language code, matched by '<lang>' in a file filter, that isn't a Transifex
+language
+*/
+type unknownLanguageCode struct {
+	code string
+	path string
+	// The Transifex language that 'code' is another spelling of, eg 'pt_BR'
+	// for 'pt-br'; a 'lang_map' entry can map the two
+	mapTo     string
+	mapToName string
+	// Other Transifex languages that 'code' may have meant
+	suggestions []string
+}
+
+func (unknown unknownLanguageCode) String() string {
+	if unknown.mapTo != "" {
+		return fmt.Sprintf(
+			"'%s' (%s) is not a language code, add '%s: %s' to 'lang_map' "+
+				"to use it for %s",
+			unknown.code, unknown.path, unknown.mapTo, unknown.code,
+			unknown.mapToName,
+		)
+	}
+	if len(unknown.suggestions) > 0 {
+		return fmt.Sprintf(
+			"'%s' (%s) is not a language code, did you mean '%s'?",
+			unknown.code, unknown.path,
+			strings.Join(unknown.suggestions, "', '"),
+		)
+	}
+	return fmt.Sprintf(
+		"'%s' (%s) is not a language code, skipping", unknown.code, unknown.path,
+	)
+}
+
+// How many languages an unknown code is compared to is not limited, so keep
+// the list of suggestions short
+const maxLanguageSuggestions = 3
+
+/*
+Check the language codes of the files of a resource (as returned by
+searchFileFilter, after 'lang_map' is applied) against 'languages', the
+Transifex languages keyed by code. The codes that aren't there are returned,
+sorted, with the languages they may have meant.
+*/
+func findUnknownLanguageCodes(
+	files map[string]string, languages map[string]*jsonapi.Resource,
+) ([]unknownLanguageCode, error) {
+	var result []unknownLanguageCode
+	for code, path := range files {
+		if _, exists := languages[code]; exists {
+			continue
+		}
+		unknown := unknownLanguageCode{code: code, path: path}
+		normalised := normaliseLanguageCode(code)
+		base := strings.SplitN(normalised, "_", 2)[0]
+		for candidate, language := range languages {
+			normalisedCandidate := normaliseLanguageCode(candidate)
+			if normalisedCandidate == normalised {
+				var attributes txapi.LanguageAttributes
+				err := language.MapAttributes(&attributes)
+				if err != nil {
+					return nil, err
+				}
+				unknown.mapTo = candidate
+				unknown.mapToName = attributes.Name
+			} else if normalisedCandidate == base ||
+				editDistance(normalisedCandidate, normalised) == 1 {
+				unknown.suggestions = append(unknown.suggestions, candidate)
+			}
+		}
+		sort.Strings(unknown.suggestions)
+		if len(unknown.suggestions) > maxLanguageSuggestions {
+			unknown.suggestions = unknown.suggestions[:maxLanguageSuggestions]
+		}
+		result = append(result, unknown)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].code < result[j].code
+	})
+	return result, nil
+}
+
+// 'pt-BR' => 'pt_br'
+func normaliseLanguageCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", "_"))
+}
+
+// The Levenshtein distance between two strings
+func editDistance(left, right string) int {
+	previous := make([]int, len(right)+1)
+	current := make([]int, len(right)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(left); i++ {
+		current[0] = i
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(
+				previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost),
+			)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(right)]
+}
+
+func minInt(left, right int) int {
+	if left < right {
+		return left
+	}
+	return right
+}
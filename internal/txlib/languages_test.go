@@ -0,0 +1,219 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestLanguagesListCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := LanguagesListCommand(cfg, server.Connection(), LanguagesListArguments{
+		Project: "o:orgslug:p:projslug",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "en ") ||
+		!strings.HasPrefix(lines[2], "el ") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = LanguagesListCommand(cfg, server.Connection(), LanguagesListArguments{
+		Project: "projslug",
+		Json:    true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []languageOutput
+	err = json.Unmarshal(out.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	if len(items) != 2 || !items[0].Source || items[1].Code != "el" ||
+		items[1].Source {
+		t.Errorf("Got languages %+v", items)
+	}
+}
+
+func TestLanguagesAddAndRemoveCommands(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+	project := server.Store.Projects["o:orgslug:p:projslug"]
+
+	var out bytes.Buffer
+	err := LanguagesAddCommand(cfg, server.Connection(), LanguagesAddArguments{
+		Project:       "projslug",
+		LanguageCodes: []string{"el", "fr", "de"},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(project.Languages, ",") != "el,fr,de" ||
+		out.String() != "Added fr, de to project 'o:orgslug:p:projslug'\n" {
+		t.Errorf("Got languages %v and output %q", project.Languages, out.String())
+	}
+
+	var asked string
+	originalAskConfirmation := askConfirmation
+	askConfirmation = func(label string) bool {
+		asked = label
+		return false
+	}
+	defer func() { askConfirmation = originalAskConfirmation }()
+	out.Reset()
+	err = LanguagesRemoveCommand(cfg, server.Connection(), LanguagesRemoveArguments{
+		Project:       "projslug",
+		LanguageCodes: []string{"fr", "it"},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(asked, "Remove fr and all their translations") ||
+		len(project.Languages) != 3 {
+		t.Errorf("Asked %q, got languages %v", asked, project.Languages)
+	}
+}
+
+func TestLanguagesSyncFromConfig(t *testing.T) {
+	server := getStringsTestServer(t)
+	project := server.Store.Projects["o:orgslug:p:projslug"]
+	project.Languages = []string{"el", "de"}
+	cfg := getStandardConfig()
+	cfg.Local.LanguageMappings = map[string]string{"pt_BR": "pt-br"}
+	afterTest := beforeTest(t, []string{"el", "fr", "pt-br"}, nil)
+	defer afterTest()
+
+	var out bytes.Buffer
+	err := LanguagesAddCommand(cfg, server.Connection(), LanguagesAddArguments{
+		Project:        "projslug",
+		SyncFromConfig: true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(project.Languages, ",") != "el,de,fr,pt_BR" {
+		t.Errorf("Got languages %v", project.Languages)
+	}
+
+	err = LanguagesRemoveCommand(cfg, server.Connection(), LanguagesRemoveArguments{
+		Project:        "projslug",
+		SyncFromConfig: true,
+		NoInteractive:  true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(project.Languages, ",") != "el,fr,pt_BR" {
+		t.Errorf("Got languages %v", project.Languages)
+	}
+
+	err = LanguagesAddCommand(cfg, server.Connection(), LanguagesAddArguments{
+		Project:        "projslug",
+		LanguageCodes:  []string{"fr"},
+		SyncFromConfig: true,
+	}, &out)
+	if err == nil {
+		t.Error("Expected an error for languages with '--sync-from-config'")
+	}
+}
+
+func TestFindUnknownLanguageCodes(t *testing.T) {
+	languages := make(map[string]*jsonapi.Resource)
+	for code, name := range map[string]string{
+		"el": "Greek", "fr": "French", "pt": "Portuguese",
+		"pt_BR": "Portuguese (Brazil)", "pt_PT": "Portuguese (Portugal)",
+	} {
+		languages[code] = &jsonapi.Resource{
+			Type:       "languages",
+			Id:         "l:" + code,
+			Attributes: map[string]interface{}{"code": code, "name": name},
+		}
+	}
+
+	unknown, err := findUnknownLanguageCodes(map[string]string{
+		"el":     "locale/el.json",
+		"pt-br":  "locale/pt-br.json",
+		"fr_XX":  "locale/fr_XX.json",
+		"backup": "locale/backup.json",
+	}, languages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, item := range unknown {
+		messages = append(messages, item.String())
+	}
+	expected := []string{
+		"'backup' (locale/backup.json) is not a language code, skipping",
+		"'fr_XX' (locale/fr_XX.json) is not a language code, did you mean 'fr'?",
+		"'pt-br' (locale/pt-br.json) is not a language code, add " +
+			"'pt_BR: pt-br' to 'lang_map' to use it for Portuguese (Brazil)",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Got messages:\n%s", strings.Join(messages, "\n"))
+	}
+}
+
+func TestLanguagesSyncFromConfigWithoutFiles(t *testing.T) {
+	server := getStringsTestServer(t)
+	project := server.Store.Projects["o:orgslug:p:projslug"]
+	project.Languages = []string{"el", "de"}
+	cfg := getStandardConfig()
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	var out bytes.Buffer
+	err := LanguagesRemoveCommand(cfg, server.Connection(), LanguagesRemoveArguments{
+		Project:        "projslug",
+		SyncFromConfig: true,
+		NoInteractive:  true,
+	}, &out)
+	if err == nil || !strings.Contains(err.Error(), "no translation files") {
+		t.Errorf("Got error %v", err)
+	}
+	if strings.Join(project.Languages, ",") != "el,de" {
+		t.Errorf("Got languages %v", project.Languages)
+	}
+}
+
+func TestLanguagesSyncFromConfigKeepsTranslatedLanguages(t *testing.T) {
+	server := getStringsTestServer(t)
+	project := server.Store.Projects["o:orgslug:p:projslug"]
+	project.Languages = []string{"el", "de", "it"}
+	// Not in the local configuration
+	other := server.Store.AddResource(
+		project.Id, "other", "KEYVALUEJSON", []byte(`{"hello": "Hello"}`),
+	)
+	err := server.Store.SetTranslation(other.Id, "de", []byte(`{"hello": "Hallo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	afterTest := beforeTest(t, []string{"el"}, nil)
+	defer afterTest()
+
+	var out bytes.Buffer
+	err = LanguagesRemoveCommand(getStandardConfig(), server.Connection(), LanguagesRemoveArguments{
+		Project:        "projslug",
+		SyncFromConfig: true,
+		NoInteractive:  true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(project.Languages, ",") != "el,de" {
+		t.Errorf("Got languages %v", project.Languages)
+	}
+	if !strings.Contains(out.String(), "Keeping de, it has translations in: other\n") {
+		t.Errorf("Got output %q", out.String())
+	}
+}
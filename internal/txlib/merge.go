@@ -3,7 +3,12 @@ package txlib
 import (
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/transifex/cli/internal/txlib/config"
@@ -13,118 +18,556 @@ import (
 )
 
 type MergeCommandArguments struct {
-	ResourceId         string
+	// Resources of the local configuration, as in 'tx push'; empty for all
+	ResourceIds        []string
 	Branch             string
 	ConflictResolution string
 	Force              bool
 	Skip               bool
 	Silent             bool
+	Workers            int
+	// Only print how the branch and base resources differ, without merging
+	Preview bool
+	// Print the preview as JSON
+	Json bool
 }
 
+// The outcomes of a merge, as they appear in the report
+const (
+	mergeResultMerged   = "merged"
+	mergeResultConflict = "conflict"
+	mergeResultDiverged = "diverged"
+	mergeResultNotFound = "not found"
+	mergeResultFailed   = "failed"
+	mergeResultNotRun   = "not run"
+)
+
+type mergeResult struct {
+	// '<project>.<resource>' of the branch resource
+	ResourceId string
+	Result     string
+	Detail     string
+}
+
+/*
+MergeCommand
+Merge the branch resources of many resources into their bases, in parallel,
+and print a report of how each merge went. Unless 'Skip' is set, the first
+failure stops the merges that haven't started yet. Returns an error if any of
+the resources was not found or not merged.
+*/
 func MergeCommand(
 	cfg *config.Config,
 	api jsonapi.Connection,
 	args MergeCommandArguments,
+	out io.Writer,
 ) error {
+	if !isValidResolutionPolicy(args.ConflictResolution) {
+		return fmt.Errorf("invalid resolution policy %s", args.ConflictResolution)
+	}
 	args.Branch = figureOutBranch(args.Branch)
+	if args.Branch == "" {
+		return errors.New(
+			"could not figure out the branch, please provide it with '--branch'",
+		)
+	}
 
-	cfgResources, err := figureOutResources([]string{args.ResourceId}, cfg)
+	cfgResources, err := figureOutResources(args.ResourceIds, cfg)
 	if err != nil {
 		return err
 	}
-
 	applyBranchToResources(cfgResources, args.Branch)
+	sort.Slice(cfgResources, func(i, j int) bool {
+		return cfgResources[i].GetAPv3Id() < cfgResources[j].GetAPv3Id()
+	})
+
+	if args.Preview {
+		return previewMerges(&api, cfgResources, args.Json, out)
+	}
+
+	if args.Workers < 1 {
+		args.Workers = 1
+	}
+	results := make([]mergeResult, len(cfgResources))
+	var mutex sync.Mutex
+	pool := worker_pool.New(args.Workers, len(cfgResources), args.Silent)
+	for i, cfgResource := range cfgResources {
+		results[i] = mergeResult{
+			ResourceId: fmt.Sprintf(
+				"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+			),
+			Result: mergeResultNotRun,
+		}
+		pool.Add(&MergeResourceTask{
+			api:         &api,
+			cfgResource: cfgResource,
+			args:        args,
+			report: func(i int) func(string, string) {
+				return func(result, detail string) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					results[i].Result = result
+					results[i].Detail = detail
+				}
+			}(i),
+		})
+	}
+	pool.Start()
+	<-pool.Wait()
+
+	printMergeReport(out, results)
+	if pool.IsAborted {
+		return errors.New("Aborted")
+	}
+	return mergeResultsError(results)
+}
+
+// An error if any of the resources was not merged, for whatever reason
+func mergeResultsError(results []mergeResult) error {
+	notFound, notMerged := 0, 0
+	for _, result := range results {
+		if result.Result == mergeResultNotFound {
+			notFound++
+		} else if result.Result != mergeResultMerged {
+			notMerged++
+		}
+	}
+	if notFound == 0 && notMerged == 0 {
+		return nil
+	}
+	var reasons []string
+	if notFound > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d not found", notFound))
+	}
+	if notMerged > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d failed", notMerged))
+	}
+	return fmt.Errorf(
+		"%d of %d resource(s) were not merged (%s)",
+		notFound+notMerged, len(results), strings.Join(reasons, ", "),
+	)
+}
 
-	cfgResource := cfgResources[0]
+type MergeResourceTask struct {
+	api         *jsonapi.Connection
+	cfgResource *config.Resource
+	args        MergeCommandArguments
+	// Records the outcome of the merge for the report
+	report func(result, detail string)
+}
+
+func (task *MergeResourceTask) Run(send func(string), abort func()) {
+	cfgResource := task.cfgResource
+	args := task.args
 
-	err = mergeResource(&api, cfgResource, args)
+	sendMessage := func(body string, force bool) {
+		if args.Silent && !force {
+			return
+		}
+		send(fmt.Sprintf(
+			"%s.%s - %s", cfgResource.ProjectSlug, cfgResource.ResourceSlug, body,
+		))
+	}
 
-	return err
+	err := mergeResource(task.api, cfgResource, args, func(msg string) {
+		sendMessage(msg, false)
+	})
+	if err != nil {
+		result := classifyMergeError(err)
+		task.report(result, err.Error())
+		sendMessage(err.Error(), true)
+		if !args.Skip && result != mergeResultNotFound {
+			abort()
+		}
+		return
+	}
+	task.report(mergeResultMerged, "")
+	sendMessage("Done", false)
 }
 
+// Errors of mergeResource for resources that were not pushed to the branch
+var errMergeResourceNotFound = errors.New("branch resource not found")
+
+// Errors of previewMerge for resources that have no base to compare with
+var errMergeResourceNotBranch = errors.New("not a branch of another resource")
+
 func mergeResource(
-	api *jsonapi.Connection, cfgResource *config.Resource, args MergeCommandArguments,
+	api *jsonapi.Connection,
+	cfgResource *config.Resource,
+	args MergeCommandArguments,
+	send func(string),
 ) error {
 	isValidPolicy := isValidResolutionPolicy(args.ConflictResolution)
 	if !isValidPolicy {
 		return fmt.Errorf("invalid resolution policy %s", args.ConflictResolution)
 	}
 
-	resourceId := fmt.Sprintf(
-		"o:%s:p:%s:r:%s",
-		cfgResource.OrganizationSlug,
-		cfgResource.ProjectSlug,
-		cfgResource.ResourceSlug,
-	)
-
 	// Get Resource from Server
-	resource, err := txapi.GetResourceById(api, resourceId)
+	resource, err := txapi.GetResourceById(api, cfgResource.GetAPv3Id())
 	if err != nil {
-		return fmt.Errorf("error getting resource '%s - %s - %s'",
+		return fmt.Errorf("error getting resource '%s - %s - %s': %w",
 			cfgResource.OrganizationSlug,
 			cfgResource.ProjectSlug,
-			cfgResource.ResourceSlug)
+			cfgResource.ResourceSlug,
+			err)
 	}
 
 	if resource == nil {
-		return fmt.Errorf("resource not found '%s - %s - %s'",
-			cfgResource.OrganizationSlug,
-			cfgResource.ProjectSlug,
-			cfgResource.ResourceSlug)
+		return errMergeResourceNotFound
 	}
 
-	var merge *jsonapi.Resource
-	merge, err = txapi.CreateAsyncResourceMerge(api, resource, args.ConflictResolution, args.Force)
+	// Not retried here, a merge must not be started twice; the connection
+	// retries the POST only if the server couldn't have received it
+	send("Starting merge")
+	merge, err := txapi.CreateAsyncResourceMerge(
+		api, resource, args.ConflictResolution, args.Force,
+	)
 	if err != nil {
 		return err
 	}
 
-	pool := worker_pool.New(1, 1, args.Silent)
-	pool.Add(&MergeResourcePollTask{merge, args})
-	pool.Start()
-	<-pool.Wait()
-	if pool.IsAborted {
-		return errors.New("Aborted")
+	return handleRetry(
+		func() error {
+			return txapi.PollResourceMerge(merge, time.Second)
+		},
+		"Polling merge task status",
+		send,
+	)
+}
+
+/*
+Tell from the error of a merge whether the branch wasn't pushed, the sources
+diverged, there were conflicts the API couldn't resolve or something else
+went wrong
+*/
+func classifyMergeError(err error) string {
+	if errors.Is(err, errMergeResourceNotFound) {
+		return mergeResultNotFound
+	}
+	var mergeError *txapi.ResourceAsyncMergeAttributes
+	if errors.As(err, &mergeError) {
+		for _, item := range mergeError.Errors {
+			text := strings.ToLower(item.Code + " " + item.Detail)
+			if strings.Contains(text, "diverge") {
+				return mergeResultDiverged
+			}
+			if strings.Contains(text, "conflict") {
+				return mergeResultConflict
+			}
+		}
 	}
+	return mergeResultFailed
+}
 
-	return nil
+func printMergeReport(out io.Writer, results []mergeResult) {
+	if len(results) == 0 {
+		fmt.Fprintln(out, "No resources to merge")
+		return
+	}
+	counts := make(map[string]int)
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "RESOURCE\tRESULT\tDETAILS")
+	for _, result := range results {
+		counts[result.Result]++
+		fmt.Fprintf(table, "%s\t%s\t%s\n",
+			result.ResourceId, result.Result, truncateText(result.Detail, 80))
+	}
+	table.Flush()
+
+	var summary []string
+	for _, result := range []string{
+		mergeResultMerged, mergeResultConflict, mergeResultDiverged,
+		mergeResultNotFound, mergeResultFailed, mergeResultNotRun,
+	} {
+		if counts[result] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[result], result))
+		}
+	}
+	fmt.Fprintln(out, strings.Join(summary, ", "))
+	if counts[mergeResultDiverged] > 0 {
+		fmt.Fprintln(out, "Use '--force' to merge diverged resources anyway")
+	}
 }
 
-type MergeResourcePollTask struct {
-	merge *jsonapi.Resource
-	args  MergeCommandArguments
+// Changes to a string since the branch was created, as they appear in previews
+const (
+	mergeChangeAdded   = "added"
+	mergeChangeChanged = "changed"
+	mergeChangeDeleted = "deleted"
+)
+
+// How a source string differs between a branch resource and its base
+type mergeStringChange struct {
+	Key     string `json:"key"`
+	Context string `json:"context"`
+	// What happened to the string on each side; empty if nothing did
+	Branch   string `json:"branch"`
+	Base     string `json:"base"`
+	Conflict bool   `json:"conflict"`
+	// The string's text on each side; nil if it's not there
+	BranchStrings map[string]string `json:"branch_strings"`
+	BaseStrings   map[string]string `json:"base_strings"`
 }
 
-func (task *MergeResourcePollTask) Run(send func(string), abort func()) {
-	merge := task.merge
-	args := task.args
+// How a merge preview of a resource is presented in JSON output
+type mergePreviewOutput struct {
+	// '<project>.<resource>' of the branch resource and its base
+	Resource string              `json:"resource"`
+	Base     string              `json:"base"`
+	Changes  []mergeStringChange `json:"changes"`
+	// Set if the preview couldn't be made, for example because the resource
+	// was not pushed to the branch
+	Error string `json:"error,omitempty"`
+}
 
-	parts := strings.Split(merge.Relationships["base"].DataSingular.Id, ":")
-	sendMessage := func(body string, force bool) {
-		if args.Silent && !force {
-			return
+func previewMerges(
+	api *jsonapi.Connection,
+	cfgResources []*config.Resource,
+	asJson bool,
+	out io.Writer,
+) error {
+	items := make([]mergePreviewOutput, 0, len(cfgResources))
+	failed := 0
+	for _, cfgResource := range cfgResources {
+		item := mergePreviewOutput{
+			Resource: fmt.Sprintf(
+				"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+			),
+			Changes: []mergeStringChange{},
 		}
-		send(fmt.Sprintf(
-			"%s.%s - %s", parts[3], parts[5], body,
-		))
+		base, changes, err := previewMerge(api, cfgResource)
+		if errors.Is(err, errMergeResourceNotFound) ||
+			errors.Is(err, errMergeResourceNotBranch) {
+			item.Error = err.Error()
+			failed++
+		} else if err != nil {
+			return err
+		} else {
+			item.Base = fmt.Sprintf(
+				"%s.%s", cfgResource.ProjectSlug, resourceSlugFromId(base.Id),
+			)
+			item.Changes = changes
+		}
+		items = append(items, item)
+	}
+	if asJson {
+		err := writeJson(out, items)
+		if err != nil {
+			return err
+		}
+		return previewMergesError(failed, len(items))
 	}
 
-	err := handleRetry(
-		func() error {
-			return txapi.PollResourceMerge(
-				merge,
-				time.Second,
+	for i, item := range items {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		if item.Error != "" {
+			fmt.Fprintf(out, "%s: %s\n", item.Resource, item.Error)
+			continue
+		}
+		fmt.Fprintf(out, "%s -> %s\n", item.Resource, item.Base)
+		if len(item.Changes) == 0 {
+			fmt.Fprintln(out, "No differences")
+			continue
+		}
+		conflicts := 0
+		table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "KEY\tBRANCH\tBASE\tCONFLICT\tBRANCH TEXT\tBASE TEXT")
+		for _, change := range item.Changes {
+			if change.Conflict {
+				conflicts++
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				truncateText(change.Key, 40),
+				dashIfEmpty(change.Branch),
+				dashIfEmpty(change.Base),
+				yesNo(change.Conflict),
+				truncateText(joinPluralForms(change.BranchStrings), 30),
+				truncateText(joinPluralForms(change.BaseStrings), 30),
 			)
-		},
-		"Polling merge task status",
-		func(msg string) { sendMessage(msg, false) },
+		}
+		table.Flush()
+		fmt.Fprintf(out, "%d string(s) differ, %d conflict(s)\n",
+			len(item.Changes), conflicts)
+	}
+	return previewMergesError(failed, len(items))
+}
+
+// An error if any of the resources could not be previewed, like a merge would
+func previewMergesError(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d resource(s) could not be previewed", failed, total)
+}
+
+/*
+Fetch the source strings of a branch resource and its base and tell how they
+differ. The API doesn't keep the strings as they were when the branch was
+created, so which side changed a string is told from its timestamps: whatever
+was created or modified after the branch resource was created is a change of
+that side. Strings changed on both sides are conflicts, which the merge will
+resolve according to its conflict resolution policy.
+*/
+func previewMerge(
+	api *jsonapi.Connection, cfgResource *config.Resource,
+) (*jsonapi.Resource, []mergeStringChange, error) {
+	resource, err := txapi.GetResourceById(api, cfgResource.GetAPv3Id())
+	if err != nil {
+		return nil, nil, err
+	}
+	if resource == nil {
+		return nil, nil, errMergeResourceNotFound
+	}
+	baseId := getResourceBaseId(resource)
+	if baseId == "" {
+		return nil, nil, errMergeResourceNotBranch
+	}
+	base, err := txapi.GetResourceById(api, baseId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if base == nil {
+		return nil, nil, fmt.Errorf("base resource '%s' not found", baseId)
+	}
+	var attributes txapi.ResourceAttributes
+	err = resource.MapAttributes(&attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	forked := parseApiTime(attributes.DatetimeCreated)
+
+	branchStrings, err := getResourceStringsByHash(api, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseStrings, err := getResourceStringsByHash(api, base)
+	if err != nil {
+		return nil, nil, err
+	}
+	after := func(timestamp string) bool {
+		return parseApiTime(timestamp).After(forked)
+	}
+
+	changes := []mergeStringChange{}
+	addChange := func(
+		branchString, baseString *txapi.ResourceStringAttributes,
+		branchChange, baseChange string,
+	) {
+		change := mergeStringChange{
+			Branch:   branchChange,
+			Base:     baseChange,
+			Conflict: branchChange != "" && baseChange != "",
+		}
+		for _, item := range []*txapi.ResourceStringAttributes{branchString, baseString} {
+			if item != nil {
+				change.Key, change.Context = item.Key, item.Context
+			}
+		}
+		if branchString != nil {
+			change.BranchStrings = branchString.Strings
+		}
+		if baseString != nil {
+			change.BaseStrings = baseString.Strings
+		}
+		changes = append(changes, change)
+	}
+
+	for _, hash := range sortedResourceStringHashes(branchStrings, baseStrings) {
+		branchString, inBranch := branchStrings[hash]
+		baseString, inBase := baseStrings[hash]
+		switch {
+		case inBranch && inBase:
+			if reflect.DeepEqual(branchString.Strings, baseString.Strings) {
+				continue
+			}
+			branchChange, baseChange := "", ""
+			if after(branchString.StringsDatetimeModified) {
+				branchChange = mergeChangeChanged
+			}
+			if after(baseString.StringsDatetimeModified) {
+				baseChange = mergeChangeChanged
+			}
+			if branchChange == "" && baseChange == "" {
+				// Can't tell, so let the user look at it
+				branchChange, baseChange = mergeChangeChanged, mergeChangeChanged
+			}
+			addChange(branchString, baseString, branchChange, baseChange)
+		case inBranch:
+			if after(branchString.DatetimeCreated) {
+				addChange(branchString, nil, mergeChangeAdded, "")
+			} else if after(branchString.StringsDatetimeModified) {
+				addChange(branchString, nil, mergeChangeChanged, mergeChangeDeleted)
+			} else {
+				addChange(branchString, nil, "", mergeChangeDeleted)
+			}
+		case inBase:
+			if after(baseString.DatetimeCreated) {
+				addChange(nil, baseString, "", mergeChangeAdded)
+			} else if after(baseString.StringsDatetimeModified) {
+				addChange(nil, baseString, mergeChangeDeleted, mergeChangeChanged)
+			} else {
+				addChange(nil, baseString, mergeChangeDeleted, "")
+			}
+		}
+	}
+	return base, changes, nil
+}
+
+func getResourceStringsByHash(
+	api *jsonapi.Connection, resource *jsonapi.Resource,
+) (map[string]*txapi.ResourceStringAttributes, error) {
+	items, err := txapi.GetResourceStrings(
+		api, resource, txapi.ResourceStringsFilter{}, jsonapi.ListOptions{},
 	)
 	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
+		return nil, err
+	}
+	result := make(map[string]*txapi.ResourceStringAttributes, len(items))
+	for _, item := range items {
+		var attributes txapi.ResourceStringAttributes
+		err := item.MapAttributes(&attributes)
+		if err != nil {
+			return nil, err
 		}
-		return
+		result[attributes.StringHash] = &attributes
 	}
-	sendMessage("Done", false)
+	return result, nil
+}
+
+// The string hashes of both resources, sorted by key and context
+func sortedResourceStringHashes(
+	left, right map[string]*txapi.ResourceStringAttributes,
+) []string {
+	var result []string
+	sortKeys := make(map[string]string)
+	for _, items := range []map[string]*txapi.ResourceStringAttributes{left, right} {
+		for hash, item := range items {
+			if _, exists := sortKeys[hash]; !exists {
+				result = append(result, hash)
+				sortKeys[hash] = item.Key + "\x00" + item.Context
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return sortKeys[result[i]] < sortKeys[result[j]]
+	})
+	return result
+}
+
+// Timestamps that can't be parsed are treated as very old
+func parseApiTime(timestamp string) time.Time {
+	result, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return result
+}
+
+func dashIfEmpty(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
 }
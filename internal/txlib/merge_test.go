@@ -1,36 +1,289 @@
 package txlib
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+	"github.com/transifex/cli/pkg/txapitest"
 )
 
 const (
-	branchResourceUrl = "/resources/o:orgslug:p:projslug:r:resslug"
+	branchResourceUrl = "/resources/o:orgslug:p:projslug:r:resslug?include=project"
 )
 
 func TestMergeSuccess(t *testing.T) {
 	mockData := getMockedDataForResourceMerge()
 	api := jsonapi.GetTestConnection(mockData)
-	commandArgs := MergeCommandArguments{"projslug.resslug", "the_branch", "USE_HEAD", false, false, false}
+	commandArgs := MergeCommandArguments{
+		ResourceIds:        []string{"projslug.resslug"},
+		Branch:             "the_branch",
+		ConflictResolution: "USE_HEAD",
+		Workers:            1,
+	}
 	resource := getStandardConfigMerge().FindResource("projslug.resslug")
-	err := mergeResource(&api, resource, commandArgs)
+	err := mergeResource(&api, resource, commandArgs, func(string) {})
 	assert.Nil(t, err)
 }
 
 func TestMergeInvalidPolicy(t *testing.T) {
 	mockData := getMockedDataForResourceMerge()
 	api := jsonapi.GetTestConnection(mockData)
-	commandArgs := MergeCommandArguments{"projslug.resslug", "the_branch", "INVALID_POLICY", false, false, false}
+	commandArgs := MergeCommandArguments{
+		ResourceIds:        []string{"projslug.resslug"},
+		Branch:             "the_branch",
+		ConflictResolution: "INVALID_POLICY",
+		Workers:            1,
+	}
 	resource := getStandardConfigMerge().FindResource("projslug.resslug")
-	err := mergeResource(&api, resource, commandArgs)
+	err := mergeResource(&api, resource, commandArgs, func(string) {})
 	assert.NotNil(t, err)
 
 }
 
+func TestMergeCommandManyResources(t *testing.T) {
+	server := getStringsTestServer(t)
+	projectId := "o:orgslug:p:projslug"
+	for _, slug := range []string{"other", "missing"} {
+		server.Store.AddResource(
+			projectId, slug, "KEYVALUEJSON", []byte(`{"hello": "Hello"}`),
+		)
+	}
+	base := server.Store.Resources[projectId+":r:resslug"]
+	branch := server.Store.AddResource(
+		projectId, "feature--resslug", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello there"}`),
+	)
+	branch.BaseId = base.Id
+	branch.BaseContent = base.Content
+	diverged := server.Store.AddResource(
+		projectId, "feature--other", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello there"}`),
+	)
+	diverged.BaseId = projectId + ":r:other"
+	diverged.BaseContent = []byte(`{"hello": "Hi"}`)
+
+	cfg := getStandardConfig()
+	for _, slug := range []string{"other", "missing"} {
+		cfgResource := cfg.Local.Resources[0]
+		cfgResource.ResourceSlug = slug
+		cfg.Local.Resources = append(cfg.Local.Resources, cfgResource)
+	}
+
+	var out bytes.Buffer
+	err := MergeCommand(cfg, server.Connection(), MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Skip:               true,
+		Silent:             true,
+		Workers:            3,
+	}, &out)
+	assert.EqualError(t, err, "2 of 3 resource(s) were not merged (1 not found, 1 failed)")
+	result := out.String()
+	assert.Regexp(t, `projslug.feature--missing +not found`, result)
+	assert.Regexp(t, `projslug.feature--other +diverged +merge failed - diverged`, result)
+	assert.Regexp(t, `projslug.feature--resslug +merged`, result)
+	assert.Contains(t, result, "1 merged, 1 diverged, 1 not found")
+	assert.Equal(t, `{"hello": "Hello there"}`, string(base.Content))
+
+	// Without '--skip', the divergence stops the merges that follow
+	cfg = getStandardConfig()
+	cfg.Local.Resources[0].ResourceSlug = "other"
+	cfg.Local.Resources = append(cfg.Local.Resources, getStandardConfig().Local.Resources[0])
+	out.Reset()
+	err = MergeCommand(cfg, server.Connection(), MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Silent:             true,
+		Workers:            1,
+	}, &out)
+	assert.NotNil(t, err)
+	assert.Regexp(t, `projslug.feature--resslug +not run`, out.String())
+
+	cfg = getStandardConfig()
+	cfg.Local.Resources[0].ResourceSlug = "other"
+	out.Reset()
+	err = MergeCommand(cfg, server.Connection(), MergeCommandArguments{
+		ResourceIds:        []string{"projslug.*"},
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Force:              true,
+		Silent:             true,
+		Workers:            2,
+	}, &out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "1 merged")
+}
+
+func TestMergeCommandPreview(t *testing.T) {
+	server := getStringsTestServer(t)
+	projectId := "o:orgslug:p:projslug"
+	forked := "2022-01-02T00:00:00Z"
+	before, after := "2022-01-01T00:00:00Z", "2022-01-03T00:00:00Z"
+
+	base := server.Store.Resources[projectId+":r:resslug"]
+	base.Content = []byte(`{"hello": "Hello world!", "bye": "Goodbye", ` +
+		`"same": "Same", "new_base": "New", "removed": "Removed"}`)
+	base.Created, base.Modified = before, after
+	base.Strings = map[string]*txapitest.StringMetadata{
+		"hello":    {Created: before, TextModified: after},
+		"bye":      {Created: before, TextModified: before},
+		"same":     {Created: before, TextModified: before},
+		"new_base": {Created: after, TextModified: after},
+		"removed":  {Created: before, TextModified: before},
+	}
+	branch := server.Store.AddResource(
+		projectId, "feature--resslug", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hi world", "bye": "Goodbye!", "same": "Same", `+
+			`"new_branch": "Fresh"}`),
+	)
+	branch.BaseId = base.Id
+	branch.Created, branch.Modified = forked, after
+	branch.Strings = map[string]*txapitest.StringMetadata{
+		"hello":      {Created: forked, TextModified: after},
+		"bye":        {Created: forked, TextModified: after},
+		"same":       {Created: forked, TextModified: forked},
+		"new_branch": {Created: after, TextModified: after},
+	}
+
+	var out bytes.Buffer
+	err := MergeCommand(getStandardConfig(), server.Connection(), MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Preview:            true,
+		Json:               true,
+	}, &out)
+	assert.Nil(t, err)
+	var items []mergePreviewOutput
+	err = json.Unmarshal(out.Bytes(), &items)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "projslug.feature--resslug", items[0].Resource)
+	assert.Equal(t, "projslug.resslug", items[0].Base)
+	changes := make(map[string]string)
+	for _, change := range items[0].Changes {
+		changes[change.Key] = fmt.Sprintf(
+			"%s/%s/%t", change.Branch, change.Base, change.Conflict,
+		)
+	}
+	assert.Equal(t, map[string]string{
+		"bye":        "changed//false",
+		"hello":      "changed/changed/true",
+		"new_base":   "/added/false",
+		"new_branch": "added//false",
+		"removed":    "deleted//false",
+	}, changes)
+	// Nothing was merged
+	assert.Equal(t, "Hello world!", stringValue(t, base.Content, "hello"))
+
+	out.Reset()
+	err = MergeCommand(getStandardConfig(), server.Connection(), MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Preview:            true,
+	}, &out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "projslug.feature--resslug -> projslug.resslug")
+	assert.Regexp(t, `hello +changed +changed +yes +Hi world +Hello world!`, out.String())
+	assert.Contains(t, out.String(), "5 string(s) differ, 1 conflict(s)")
+
+	out.Reset()
+	err = MergeCommand(getStandardConfig(), server.Connection(), MergeCommandArguments{
+		Branch:             "other",
+		ConflictResolution: "USE_HEAD",
+		Preview:            true,
+	}, &out)
+	assert.EqualError(t, err, "1 of 1 resource(s) could not be previewed")
+	assert.Contains(t, out.String(), "projslug.other--resslug: branch resource not found")
+}
+
+func TestMergeCommandPreviewReportsEachResource(t *testing.T) {
+	server := getStringsTestServer(t)
+	projectId := "o:orgslug:p:projslug"
+	for _, slug := range []string{"other", "missing"} {
+		server.Store.AddResource(
+			projectId, slug, "KEYVALUEJSON", []byte(`{"hello": "Hello"}`),
+		)
+	}
+	// Pushed without a base, so there is nothing to compare it with
+	server.Store.AddResource(
+		projectId, "feature--other", "KEYVALUEJSON", []byte(`{"hello": "Hi"}`),
+	)
+	branch := server.Store.AddResource(
+		projectId, "feature--resslug", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello"}`),
+	)
+	branch.BaseId = projectId + ":r:resslug"
+
+	cfg := getStandardConfig()
+	for _, slug := range []string{"other", "missing"} {
+		cfgResource := cfg.Local.Resources[0]
+		cfgResource.ResourceSlug = slug
+		cfg.Local.Resources = append(cfg.Local.Resources, cfgResource)
+	}
+
+	var out bytes.Buffer
+	err := MergeCommand(cfg, server.Connection(), MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Preview:            true,
+		Json:               true,
+	}, &out)
+	assert.EqualError(t, err, "2 of 3 resource(s) could not be previewed")
+	var items []mergePreviewOutput
+	err = json.Unmarshal(out.Bytes(), &items)
+	assert.Nil(t, err)
+	errorsByResource := make(map[string]string)
+	for _, item := range items {
+		errorsByResource[item.Resource] = item.Error
+	}
+	assert.Equal(t, map[string]string{
+		"projslug.feature--missing": "branch resource not found",
+		"projslug.feature--other":   "not a branch of another resource",
+		"projslug.feature--resslug": "",
+	}, errorsByResource)
+}
+
+func stringValue(t *testing.T, content []byte, key string) string {
+	var values map[string]string
+	err := json.Unmarshal(content, &values)
+	assert.Nil(t, err)
+	return values[key]
+}
+
+func TestMergeCommandResourceNotFound(t *testing.T) {
+	server := getStringsTestServer(t)
+	var out bytes.Buffer
+	err := MergeCommand(getStandardConfig(), server.Connection(), MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Silent:             true,
+		Workers:            1,
+	}, &out)
+	assert.EqualError(t, err, "1 of 1 resource(s) were not merged (1 not found)")
+	assert.Regexp(t, `projslug.feature--resslug +not found`, out.String())
+}
+
+func TestClassifyMergeError(t *testing.T) {
+	mergeError := &txapi.ResourceAsyncMergeAttributes{Status: "FAILED"}
+	mergeError.Errors = append(mergeError.Errors, struct {
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+	}{"merge_conflict", "Unresolved conflicts"})
+	assert.Equal(t, mergeResultConflict,
+		classifyMergeError(fmt.Errorf("merge failed - %w", mergeError)))
+	assert.Equal(t, mergeResultNotFound,
+		classifyMergeError(errMergeResourceNotFound))
+	assert.Equal(t, mergeResultFailed, classifyMergeError(errors.New("oops")))
+}
+
 func getStandardConfigMerge() *config.Config {
 	return &config.Config{
 		Local: &config.LocalConfig{
@@ -177,3 +430,26 @@ func getMockedDataForResourceMerge() jsonapi.MockData {
 		"/resource_async_merges/some_uuid": mergePollingEndpoint(),
 	}
 }
+
+func TestMergeCommandReplay(t *testing.T) {
+	api, replayer := getCassetteConnection(t, "merge_cassette")
+
+	var out bytes.Buffer
+	err := MergeCommand(getStandardConfig(), api, MergeCommandArguments{
+		Branch:             "feature",
+		ConflictResolution: "USE_HEAD",
+		Silent:             true,
+		Workers:            1,
+	}, &out)
+	assert.Nil(t, err)
+	assert.Regexp(t, `projslug.feature--resslug +merged`, out.String())
+	assert.Equal(t, 0, replayer.Remaining())
+	var merge string
+	for _, request := range replayer.Requests {
+		if request.Method == "POST" {
+			merge = string(request.Body)
+		}
+	}
+	assert.True(t, strings.Contains(merge, `"conflict_resolution":"USE_HEAD"`) &&
+		strings.Contains(merge, "feature--resslug"), merge)
+}
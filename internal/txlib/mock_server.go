@@ -0,0 +1,92 @@
+package txlib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/transifex/cli/pkg/txapitest"
+)
+
+type MockServerCommandArguments struct {
+	Port int
+	// JSON file with the initial state of the server
+	Seed string
+	// JSON file to persist the state of the server to; loaded instead of
+	// 'Seed' if it already exists
+	Store string
+	// Number of items per page of collections, 0 for the default
+	PageSize int
+}
+
+/*
+GetMockServer
+Return a fake API server set up according to the arguments, not yet listening
+*/
+func GetMockServer(
+	arguments *MockServerCommandArguments,
+) (*txapitest.Server, error) {
+	path := arguments.Seed
+	if arguments.Store != "" {
+		if _, err := os.Stat(arguments.Store); err == nil {
+			path = arguments.Store
+		}
+	}
+
+	store := txapitest.NewStore()
+	if path != "" {
+		var err error
+		store, err = txapitest.LoadStore(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	server := txapitest.NewHandler(store)
+	if arguments.PageSize > 0 {
+		server.PageSize = arguments.PageSize
+	}
+	if arguments.Store != "" {
+		storePath := arguments.Store
+		server.OnChange = func(store *txapitest.Store) {
+			err := store.Save(storePath)
+			if err != nil {
+				fmt.Fprintln(
+					os.Stderr,
+					color.RedString("Could not save store: %s", err),
+				)
+			}
+		}
+	}
+	return server, nil
+}
+
+/*
+MockServerCommand
+Serve a fake API on localhost, for trying out the client or running end-to-end
+tests without network access. Blocks until the process is interrupted.
+*/
+func MockServerCommand(arguments *MockServerCommandArguments) error {
+	server, err := GetMockServer(arguments)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", arguments.Port))
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("http://%s", listener.Addr())
+
+	fmt.Printf("Mock API listening on %s with %d projects and %d resources\n",
+		url, len(server.Store.Projects), len(server.Store.Resources))
+	fmt.Printf("Use it with: tx --hostname %s --token anything push\n", url)
+
+	err = http.Serve(listener, server)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package txlib
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+func TestMockServerPersistsChanges(t *testing.T) {
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed.json")
+	storePath := filepath.Join(dir, "store.json")
+	err := os.WriteFile(seed, []byte(`{"projects": {"o:org:p:proj": {
+		"slug": "proj", "organization_id": "o:org", "source_language": "en"
+	}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	arguments := MockServerCommandArguments{Seed: seed, Store: storePath}
+	server, err := GetMockServer(&arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	server.URL = httpServer.URL
+	api := server.Connection()
+
+	organization, err := txapi.GetOrganization(&api, "org")
+	if err != nil || organization == nil {
+		t.Fatalf("Got organization %v, error %v", organization, err)
+	}
+	project, err := txapi.GetProject(&api, organization, "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = txapi.CreateResource(
+		&api, project.Id, "Resource", "res", "PO", "",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restarting picks up the saved store rather than the seed
+	server, err = GetMockServer(&arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := server.Store.Resources["o:org:p:proj:r:res"]; !exists {
+		t.Errorf("Created resource was not persisted")
+	}
+
+	_, err = GetMockServer(&MockServerCommandArguments{
+		Seed: filepath.Join(dir, "missing.json"),
+	})
+	if err == nil {
+		t.Error("Expected an error for a missing seed file")
+	}
+}
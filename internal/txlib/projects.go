@@ -0,0 +1,257 @@
+package txlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+type ProjectsListArguments struct {
+	// Only list the projects of this organization, empty for all the
+	// organizations the user is a member of
+	OrganizationSlug string
+	Json             bool
+}
+
+// How a project is presented in JSON output
+type projectOutput struct {
+	Id               string `json:"id"`
+	Organization     string `json:"organization"`
+	Slug             string `json:"slug"`
+	Name             string `json:"name"`
+	Private          bool   `json:"private"`
+	Archived         bool   `json:"archived"`
+	DatetimeModified string `json:"datetime_modified"`
+	// Whether any of the project's resources are in the local configuration
+	InConfig bool `json:"in_config"`
+}
+
+/*
+ProjectsListCommand
+Print the projects of an organization, or of all the user's organizations, as
+a table or as JSON
+*/
+func ProjectsListCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args ProjectsListArguments,
+	out io.Writer,
+) error {
+	var organizations []*jsonapi.Resource
+	if args.OrganizationSlug != "" {
+		organization, err := txapi.GetOrganization(&api, args.OrganizationSlug)
+		if err != nil {
+			return err
+		}
+		if organization == nil {
+			return fmt.Errorf(
+				"organization '%s' does not exist", args.OrganizationSlug,
+			)
+		}
+		organizations = []*jsonapi.Resource{organization}
+	} else {
+		var err error
+		organizations, err = txapi.GetOrganizations(&api)
+		if err != nil {
+			return err
+		}
+	}
+
+	items := []projectOutput{}
+	for _, organization := range organizations {
+		var organizationAttributes txapi.OrganizationAttributes
+		err := organization.MapAttributes(&organizationAttributes)
+		if err != nil {
+			return err
+		}
+		projects, err := txapi.GetProjects(&api, organization)
+		if err != nil {
+			return err
+		}
+		for _, project := range projects {
+			var attributes txapi.ProjectAttributes
+			err := project.MapAttributes(&attributes)
+			if err != nil {
+				return err
+			}
+			items = append(items, projectOutput{
+				Id:               project.Id,
+				Organization:     organizationAttributes.Slug,
+				Slug:             attributes.Slug,
+				Name:             attributes.Name,
+				Private:          attributes.Private,
+				Archived:         attributes.Archived,
+				DatetimeModified: attributes.Modified,
+				InConfig: isProjectInConfig(
+					cfg, organizationAttributes.Slug, attributes.Slug,
+				),
+			})
+		}
+	}
+	if args.Json {
+		return writeJson(out, items)
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No projects found")
+		return nil
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ORGANIZATION\tSLUG\tNAME\tPRIVATE\tMODIFIED\tIN CONFIG")
+	for _, item := range items {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			item.Organization,
+			item.Slug,
+			truncateText(item.Name, 40),
+			yesNo(item.Private),
+			item.DatetimeModified,
+			yesNo(item.InConfig),
+		)
+	}
+	return table.Flush()
+}
+
+func isProjectInConfig(
+	cfg *config.Config, organizationSlug, projectSlug string,
+) bool {
+	if cfg == nil || cfg.Local == nil {
+		return false
+	}
+	for _, cfgResource := range cfg.Local.Resources {
+		if cfgResource.OrganizationSlug == organizationSlug &&
+			cfgResource.ProjectSlug == projectSlug {
+			return true
+		}
+	}
+	return false
+}
+
+type ProjectCreateArguments struct {
+	OrganizationSlug   string
+	Slug               string
+	SourceLanguageCode string
+	// The project's name, private flag and other settings
+	Settings txapi.ProjectUpdate
+	Json     bool
+}
+
+/*
+ProjectCreateCommand
+Create a project on Transifex and print it
+*/
+func ProjectCreateCommand(
+	api jsonapi.Connection,
+	args ProjectCreateArguments,
+	out io.Writer,
+) error {
+	if args.OrganizationSlug == "" || args.Slug == "" ||
+		args.SourceLanguageCode == "" {
+		return errors.New(
+			"please provide the organization, slug and source language " +
+				"of the project",
+		)
+	}
+	private := args.Settings.Private == nil || *args.Settings.Private
+	if !private && (args.Settings.RepositoryURL == nil ||
+		*args.Settings.RepositoryURL == "") {
+		return errors.New("public projects need a repository URL")
+	}
+	organization, err := txapi.GetOrganization(&api, args.OrganizationSlug)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return fmt.Errorf(
+			"organization '%s' does not exist", args.OrganizationSlug,
+		)
+	}
+	existing, err := txapi.GetProject(&api, organization, args.Slug)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf(
+			"project '%s' already exists in organization '%s'",
+			args.Slug, args.OrganizationSlug,
+		)
+	}
+
+	project, err := txapi.CreateProject(
+		&api, organization, args.Slug, args.SourceLanguageCode, args.Settings,
+	)
+	if err != nil {
+		return err
+	}
+	return printProject(out, project, args.Json)
+}
+
+type ProjectUpdateArguments struct {
+	// See figureOutRemoteProjectId
+	Project          string
+	OrganizationSlug string
+	Update           txapi.ProjectUpdate
+	Json             bool
+}
+
+/*
+ProjectUpdateCommand
+Change the settings of a project and print it
+*/
+func ProjectUpdateCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args ProjectUpdateArguments,
+	out io.Writer,
+) error {
+	project, err := getRemoteProject(
+		cfg, &api, args.Project, args.OrganizationSlug,
+	)
+	if err != nil {
+		return err
+	}
+	err = txapi.UpdateProject(project, args.Update)
+	if err != nil {
+		return err
+	}
+	return printProject(out, project, args.Json)
+}
+
+// How a single project is presented in JSON output
+type projectDetailsOutput struct {
+	Id string `json:"id"`
+	txapi.ProjectAttributes
+}
+
+func printProject(out io.Writer, project *jsonapi.Resource, asJson bool) error {
+	item := projectDetailsOutput{Id: project.Id}
+	err := project.MapAttributes(&item.ProjectAttributes)
+	if err != nil {
+		return err
+	}
+	if item.Tags == nil {
+		item.Tags = []string{}
+	}
+	if asJson {
+		return writeJson(out, item)
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "Id:\t%s\n", item.Id)
+	fmt.Fprintf(table, "Name:\t%s\n", item.Name)
+	fmt.Fprintf(table, "Private:\t%s\n", yesNo(item.Private))
+	fmt.Fprintf(table, "Archived:\t%s\n", yesNo(item.Archived))
+	fmt.Fprintf(table, "Description:\t%s\n", item.Description)
+	fmt.Fprintf(table, "Tags:\t%s\n", strings.Join(item.Tags, ", "))
+	fmt.Fprintf(table, "TM fillup:\t%s\n", yesNo(item.TMFillup))
+	fmt.Fprintf(table, "Instructions URL:\t%s\n", item.InstructionsURL)
+	fmt.Fprintf(table, "Homepage URL:\t%s\n", item.HomepageURL)
+	fmt.Fprintf(table, "Repository URL:\t%s\n", item.RepositoryURL)
+	fmt.Fprintf(table, "License:\t%s\n", item.License)
+	fmt.Fprintf(table, "Modified:\t%s\n", item.Modified)
+	return table.Flush()
+}
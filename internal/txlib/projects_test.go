@@ -0,0 +1,135 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+func TestProjectsListCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	server.Store.AddProject("orgslug", "another", "en")
+	server.Store.AddProject("otherorg", "third", "en")
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := ProjectsListCommand(cfg, server.Connection(), ProjectsListArguments{}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "ORGANIZATION") ||
+		!strings.Contains(out.String(), "otherorg") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = ProjectsListCommand(cfg, server.Connection(), ProjectsListArguments{
+		OrganizationSlug: "orgslug",
+		Json:             true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []projectOutput
+	err = json.Unmarshal(out.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	inConfig := map[string]bool{}
+	for _, item := range items {
+		inConfig[item.Slug] = item.InConfig
+	}
+	if len(items) != 2 || !inConfig["projslug"] || inConfig["another"] {
+		t.Errorf("Got projects %+v", items)
+	}
+
+	err = ProjectsListCommand(cfg, server.Connection(), ProjectsListArguments{
+		OrganizationSlug: "missing",
+	}, &out)
+	if err == nil {
+		t.Error("Expected an error for a missing organization")
+	}
+}
+
+func TestProjectCreateAndUpdateCommands(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+
+	description := "The web app"
+	var out bytes.Buffer
+	err := ProjectCreateCommand(server.Connection(), ProjectCreateArguments{
+		OrganizationSlug:   "orgslug",
+		Slug:               "web",
+		SourceLanguageCode: "en",
+		Settings:           txapi.ProjectUpdate{Description: &description},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := server.Store.Projects["o:orgslug:p:web"]
+	if project == nil || !project.Private || project.SourceLanguage != "en" ||
+		project.Description != "The web app" {
+		t.Fatalf("Got project %+v", project)
+	}
+	if !strings.Contains(out.String(), "The web app") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	err = ProjectCreateCommand(server.Connection(), ProjectCreateArguments{
+		OrganizationSlug:   "orgslug",
+		Slug:               "web",
+		SourceLanguageCode: "en",
+	}, &out)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Got error %v", err)
+	}
+
+	private := false
+	err = ProjectCreateCommand(server.Connection(), ProjectCreateArguments{
+		OrganizationSlug:   "orgslug",
+		Slug:               "public",
+		SourceLanguageCode: "en",
+		Settings:           txapi.ProjectUpdate{Private: &private},
+	}, &out)
+	if err == nil || !strings.Contains(err.Error(), "repository URL") {
+		t.Errorf("Got error %v", err)
+	}
+
+	tags := []string{"frontend"}
+	tmFillup := true
+	instructions := "https://example.com/style-guide"
+	out.Reset()
+	err = ProjectUpdateCommand(cfg, server.Connection(), ProjectUpdateArguments{
+		Project:          "web",
+		OrganizationSlug: "orgslug",
+		Update: txapi.ProjectUpdate{
+			Tags: &tags, TMFillup: &tmFillup, InstructionsURL: &instructions,
+		},
+		Json: true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item projectDetailsOutput
+	err = json.Unmarshal(out.Bytes(), &item)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	if !item.TMFillup || item.InstructionsURL != instructions ||
+		strings.Join(project.Tags, ",") != "frontend" ||
+		item.Description != "The web app" {
+		t.Errorf("Got project %+v", item)
+	}
+
+	err = ProjectUpdateCommand(cfg, server.Connection(), ProjectUpdateArguments{
+		Project: "o:orgslug:p:web",
+		Update:  txapi.ProjectUpdate{Private: &private},
+	}, &out)
+	if err == nil || !project.Private {
+		t.Errorf("Made the project public without a repository URL: %v", err)
+	}
+}
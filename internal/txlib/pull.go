@@ -6,6 +6,8 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
@@ -34,6 +36,13 @@ type PullCommandArguments struct {
 	Workers           int
 	Silent            bool
 	Pseudo            bool
+	// Commit the downloaded files to git afterwards
+	Commit bool
+	// A text/template for the commit message, see pullCommitMessageData;
+	// empty for defaultPullCommitMessageTemplate
+	CommitMessageTemplate string
+	// Create this git branch for the commit
+	BranchName string
 }
 
 func PullCommand(
@@ -52,42 +61,183 @@ func PullCommand(
 	})
 
 	if !args.Silent {
-		fmt.Print("# Getting info about resources\n\n")
+		fmt.Print("# Pulling resources\n\n")
 	}
 
-	filePullTaskChannel := make(chan *FilePullTask)
-	var filePullTasks []*FilePullTask
-	pool := worker_pool.New(args.Workers, len(cfgResources), args.Silent)
+	// Each resource schedules its file downloads as soon as its info is
+	// available instead of waiting for all the other resources
+	scheduler := worker_pool.NewScheduler(args.Workers, args.Silent)
+	pipeline := &pullPipeline{scheduler: scheduler}
+	restoreApi := adaptConcurrencyToRetries(api, scheduler, args.Workers)
+	defer restoreApi()
 	for _, cfgResource := range cfgResources {
-		pool.Add(&ResourcePullTask{cfgResource, api, args, filePullTaskChannel, cfg})
+		scheduler.Add(&ResourcePullTask{cfgResource, api, args, pipeline, cfg})
 	}
-	pool.Start()
+	scheduler.Start()
+	<-scheduler.Wait()
 
-	waitChanel := pool.Wait()
-	exitfor := false
-	for !exitfor {
-		select {
-		case task := <-filePullTaskChannel:
-			filePullTasks = append(filePullTasks, task)
-		case <-waitChanel:
-			exitfor = true
-		}
-	}
-	if pool.IsAborted {
+	if scheduler.IsAborted {
 		return errors.New("Aborted")
 	}
 	if args.Silent {
-		var names []string
-		for _, cfgResource := range cfgResources {
-			names = append(names, fmt.Sprintf(
-				"%s.%s",
-				cfgResource.ProjectSlug,
-				cfgResource.ResourceSlug,
-			))
+		pipeline.printSummary(cfgResources)
+	}
+	if args.Commit {
+		return commitPulledFiles(pipeline.filePullTasks, args)
+	}
+
+	return nil
+}
+
+const defaultPullCommitMessageTemplate = `Update translations from Transifex
+
+{{.Details}}`
+
+/*
+What a '--commit-message-template' can refer to, eg '{{.Languages}}'
+*/
+type pullCommitMessageData struct {
+	// 'project.resource' of the resources that files were pulled for
+	Resources string
+	// The codes of the languages that files were pulled for
+	Languages string
+	// How many files were pulled
+	Files int
+	// One line per resource: '- project.resource: el (100%), fr (85%)'
+	Details string
+}
+
+/*
+Commit the files that the FilePullTasks wrote, with a message that lists them
+along with how much of each language is translated
+*/
+func commitPulledFiles(
+	filePullTasks []*FilePullTask, args *PullCommandArguments,
+) error {
+	var paths []string
+	var written []*FilePullTask
+	for _, task := range filePullTasks {
+		if task.writtenPath != "" {
+			paths = append(paths, task.writtenPath)
+			written = append(written, task)
 		}
-		fmt.Printf("Got info about resources: %s\n", strings.Join(names, ", "))
 	}
+	if len(written) == 0 {
+		fmt.Println("No files were pulled, nothing to commit")
+		return nil
+	}
+	message, err := makePullCommitMessage(written, args.CommitMessageTemplate)
+	if err != nil {
+		return err
+	}
+	hash, committed, err := gitCommitFiles(paths, message, args.BranchName)
+	if err != nil {
+		return fmt.Errorf("could not commit the pulled files: %w", err)
+	}
+	if hash == "" {
+		fmt.Println("The pulled files have no changes, nothing to commit")
+		return nil
+	}
+	if args.BranchName != "" {
+		fmt.Printf("Committed %d file(s) as %s on branch '%s'\n",
+			committed, hash[:7], args.BranchName)
+	} else {
+		fmt.Printf("Committed %d file(s) as %s\n", committed, hash[:7])
+	}
+	return nil
+}
 
+func makePullCommitMessage(
+	filePullTasks []*FilePullTask, messageTemplate string,
+) (string, error) {
+	if messageTemplate == "" {
+		messageTemplate = defaultPullCommitMessageTemplate
+	}
+	parsed, err := template.New("message").Parse(messageTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+
+	tasks := append([]*FilePullTask{}, filePullTasks...)
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].resource.Id != tasks[j].resource.Id {
+			return tasks[i].resource.Id < tasks[j].resource.Id
+		}
+		return tasks[i].languageCode < tasks[j].languageCode
+	})
+	var resources, languages, details []string
+	for i, task := range tasks {
+		name := fmt.Sprintf(
+			"%s.%s", task.cfgResource.ProjectSlug, task.cfgResource.ResourceSlug,
+		)
+		if i == 0 || task.resource.Id != tasks[i-1].resource.Id {
+			resources = append(resources, name)
+			details = append(details, fmt.Sprintf("- %s: ", name))
+		} else {
+			details[len(details)-1] += ", "
+		}
+		item := "source"
+		if task.languageCode != "" {
+			item = task.languageCode
+			if !stringSliceContains(languages, item) {
+				languages = append(languages, item)
+			}
+			var attributes txapi.ResourceLanguageStatsAttributes
+			err := task.stats.MapAttributes(&attributes)
+			if err != nil {
+				return "", err
+			}
+			if attributes.TotalStrings > 0 {
+				item += fmt.Sprintf(" (%d%%)",
+					attributes.TranslatedStrings*100/attributes.TotalStrings)
+			}
+		}
+		details[len(details)-1] += item
+	}
+	sort.Strings(languages)
+
+	var message strings.Builder
+	err = parsed.Execute(&message, pullCommitMessageData{
+		Resources: strings.Join(resources, ", "),
+		Languages: strings.Join(languages, ", "),
+		Files:     len(tasks),
+		Details:   strings.Join(details, "\n"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+	return message.String(), nil
+}
+
+/*
+Keeps track of the FilePullTasks that the ResourcePullTasks schedule so that a
+summary can be printed in silent mode after everything is finished.
+*/
+type pullPipeline struct {
+	scheduler     *worker_pool.Scheduler
+	mutex         sync.Mutex
+	filePullTasks []*FilePullTask
+}
+
+func (pipeline *pullPipeline) addFilePullTask(task *FilePullTask) {
+	pipeline.mutex.Lock()
+	pipeline.filePullTasks = append(pipeline.filePullTasks, task)
+	pipeline.mutex.Unlock()
+	pipeline.scheduler.Add(task)
+}
+
+func (pipeline *pullPipeline) printSummary(cfgResources []*config.Resource) {
+	var names []string
+	for _, cfgResource := range cfgResources {
+		names = append(names, fmt.Sprintf(
+			"%s.%s",
+			cfgResource.ProjectSlug,
+			cfgResource.ResourceSlug,
+		))
+	}
+	fmt.Printf("Got info about resources: %s\n", strings.Join(names, ", "))
+
+	filePullTasks := pipeline.filePullTasks
 	if len(filePullTasks) > 0 {
 		sort.Slice(filePullTasks, func(i, j int) bool {
 			left := filePullTasks[i]
@@ -98,55 +248,37 @@ func PullCommand(
 				return left.languageCode < right.languageCode
 			}
 		})
-
-		if !args.Silent {
-			fmt.Print("\n# Pulling files\n\n")
-		}
-		pool = worker_pool.New(args.Workers, len(filePullTasks), args.Silent)
-		for _, task := range filePullTasks {
-			pool.Add(task)
-		}
-		pool.Start()
-		<-pool.Wait()
-
-		if pool.IsAborted {
-			return errors.New("Aborted")
-		}
-		if args.Silent {
-			var names []string
-			for _, filePullTask := range filePullTasks {
-				var languageCode string
-				if filePullTask.languageCode == "" {
-					languageCode = "source"
-				} else {
-					languageCode = filePullTask.languageCode
-				}
-				names = append(names, fmt.Sprintf(
-					"%s: %s",
-					filePullTask.cfgResource.ResourceSlug,
-					languageCode,
-				))
+		names = nil
+		for _, filePullTask := range filePullTasks {
+			var languageCode string
+			if filePullTask.languageCode == "" {
+				languageCode = "source"
+			} else {
+				languageCode = filePullTask.languageCode
 			}
-			fmt.Printf("Pulled files: %s\n", strings.Join(names, ", "))
+			names = append(names, fmt.Sprintf(
+				"%s: %s",
+				filePullTask.cfgResource.ResourceSlug,
+				languageCode,
+			))
 		}
+		fmt.Printf("Pulled files: %s\n", strings.Join(names, ", "))
 	}
-
-	return nil
 }
 
 type ResourcePullTask struct {
-	cfgResource         *config.Resource
-	api                 *jsonapi.Connection
-	args                *PullCommandArguments
-	filePullTaskChannel chan *FilePullTask
-	cfg                 *config.Config
+	cfgResource *config.Resource
+	api         *jsonapi.Connection
+	args        *PullCommandArguments
+	pipeline    *pullPipeline
+	cfg         *config.Config
 }
 
 func (task *ResourcePullTask) Run(send func(string), abort func()) {
 	cfgResource := task.cfgResource
 	api := task.api
 	args := task.args
-	filePullTaskChannel := task.filePullTaskChannel
+	pipeline := task.pipeline
 	cfg := task.cfg
 
 	sendMessage := func(body string, force bool) {
@@ -240,16 +372,14 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 	}
 
 	if args.Source {
-		filePullTaskChannel <- &FilePullTask{
-			cfgResource,
-			"",
-			args,
-			api,
-			resource,
-			stats[sourceLanguage.Id],
-			"",
-			remoteToLocalLanguageMappings,
-		}
+		pipeline.addFilePullTask(&FilePullTask{
+			cfgResource:                   cfgResource,
+			args:                          args,
+			api:                           api,
+			resource:                      resource,
+			stats:                         stats[sourceLanguage.Id],
+			remoteToLocalLanguageMappings: remoteToLocalLanguageMappings,
+		})
 	}
 
 	if args.Translations || !args.Source {
@@ -276,7 +406,14 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 				-1,
 			)
 		}
-		localFiles := searchFileFilter(".", fileFilter)
+		localFiles, err := searchResourceFiles(cfg, cfgResource, ".", fileFilter)
+		if err != nil {
+			sendMessage(err.Error(), true)
+			if !args.Skip {
+				abort()
+			}
+			return
+		}
 
 		for localLanguageCode, filePath := range cfgResource.Overrides {
 			filePath = setFileTypeExtensions(args.FileType, filePath)
@@ -339,16 +476,16 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 			}
 			parts := strings.Split(languageId, ":")
 			languageCode := parts[1]
-			filePullTaskChannel <- &FilePullTask{
-				cfgResource,
-				languageCode,
-				args,
-				api,
-				resource,
-				info.stats,
-				info.filePath,
-				remoteToLocalLanguageMappings,
-			}
+			pipeline.addFilePullTask(&FilePullTask{
+				cfgResource:                   cfgResource,
+				languageCode:                  languageCode,
+				args:                          args,
+				api:                           api,
+				resource:                      resource,
+				stats:                         info.stats,
+				filePath:                      info.filePath,
+				remoteToLocalLanguageMappings: remoteToLocalLanguageMappings,
+			})
 		}
 	}
 	sendMessage("Done", false)
@@ -363,6 +500,8 @@ type FilePullTask struct {
 	stats                         *jsonapi.Resource
 	filePath                      string
 	remoteToLocalLanguageMappings map[string]string
+	// The file that was downloaded, empty if it was skipped or failed
+	writtenPath string
 }
 
 func (task *FilePullTask) Run(send func(string), abort func()) {
@@ -478,6 +617,7 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			}
 			return
 		}
+		task.writtenPath = sourceFile
 	} else {
 		if filePath != "" {
 			// Remote language file exists and so does local
@@ -593,6 +733,7 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			}
 			return
 		}
+		task.writtenPath = filePath
 	}
 	sendMessage("Done", false)
 }
@@ -631,7 +772,6 @@ func shouldSkipDownload(
 
 	if !force {
 		if useGitTimestamps {
-			// TODO: check if parent folder is repo
 			localTime = getLastCommitDate(path)
 			if localTime == (time.Time{}) {
 				return shouldSkipDownload(path, remoteStat,
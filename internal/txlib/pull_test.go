@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/cassette"
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -21,7 +22,7 @@ func TestPullCommandResourceExists(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:             getResourceEndpoint(),
+		resourceUrlWithProject:  getResourceWithProjectEndpoint(),
 		projectUrl:              getProjectEndpoint(),
 		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
 		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
@@ -45,8 +46,8 @@ func TestPullCommandResourceExists(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleTranslationDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, translationDownloadUrl)
@@ -54,6 +55,41 @@ func TestPullCommandResourceExists(t *testing.T) {
 	assertFileContent(t, "aaa-el.json", "This is the content")
 }
 
+func TestPullCommandReplay(t *testing.T) {
+	api, replayer := getCassetteConnection(t, "pull_cassette")
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	arguments := PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		Force:             true,
+		All:               true,
+		MinimumPercentage: -1,
+		Workers:           1,
+		Silent:            true,
+	}
+
+	err := PullCommand(getStandardConfig(), &api, &arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFileContent(t, "aaa-el.json", `{"hello": "κόσμε"}`)
+	if replayer.Remaining() != 0 {
+		t.Errorf("%d recorded requests were not made", replayer.Remaining())
+	}
+	var download cassette.Request
+	for _, request := range replayer.Requests {
+		if request.Method == "POST" {
+			download = request
+		}
+	}
+	if !strings.Contains(string(download.Body), `"id":"l:el"`) {
+		t.Errorf("Unexpected download request: %s", download.Body)
+	}
+}
+
 func TestPullCommandFileExists(t *testing.T) {
 	afterTest := beforeTest(t, []string{"el"}, nil)
 	defer afterTest()
@@ -64,7 +100,7 @@ func TestPullCommandFileExists(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:             getResourceEndpoint(),
+		resourceUrlWithProject:  getResourceWithProjectEndpoint(),
 		projectUrl:              getProjectEndpoint(),
 		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
 		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
@@ -88,8 +124,8 @@ func TestPullCommandFileExists(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleTranslationDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, translationDownloadUrl)
@@ -107,7 +143,7 @@ func TestPullCommandDownloadSource(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:            getResourceEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
 		projectUrl:             getProjectEndpoint(),
 		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
 		sourceDownloadsUrl:     getSourceDownloadsEndpoint(),
@@ -132,8 +168,8 @@ func TestPullCommandDownloadSource(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimpleSourceDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, sourceDownloadUrl)
@@ -148,8 +184,8 @@ func TestPullCommandSkipOnTranslatedMinPerc(t *testing.T) {
 	cfg := getStandardConfig()
 
 	mockData := jsonapi.MockData{
-		resourceUrl: getResourceEndpoint(),
-		projectUrl:  getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: jsonapi.GetMockTextResponse(fmt.Sprintf(
 			`{"data": [{"type": "resource_language_stats",
 			            "id": "%s:l:en",
@@ -181,8 +217,8 @@ func TestPullCommandSkipOnTranslatedMinPerc(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -196,8 +232,8 @@ func TestPullCommandProceedOnEqualTranslatedMinPerc(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl: getResourceEndpoint(),
-		projectUrl:  getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: jsonapi.GetMockTextResponse(fmt.Sprintf(
 			`{"data": [{"type": "resource_language_stats",
 			            "id": "%s:l:en",
@@ -232,8 +268,8 @@ func TestPullCommandProceedOnEqualTranslatedMinPerc(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleTranslationDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, translationDownloadUrl)
@@ -252,7 +288,7 @@ func TestPullCommandOverrides(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:             getResourceEndpoint(),
+		resourceUrlWithProject:  getResourceWithProjectEndpoint(),
 		projectUrl:              getProjectEndpoint(),
 		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
 		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
@@ -276,8 +312,8 @@ func TestPullCommandOverrides(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleTranslationDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, translationDownloadUrl)
@@ -296,7 +332,7 @@ func TestPullCommandMultipleLangParameters(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:             getResourceEndpoint(),
+		resourceUrlWithProject:  getResourceWithProjectEndpoint(),
 		projectUrl:              getProjectEndpoint(),
 		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
 		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
@@ -320,8 +356,8 @@ func TestPullCommandMultipleLangParameters(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleTranslationDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, translationDownloadUrl)
@@ -336,8 +372,8 @@ func TestPullCommandSkipOnReviewedMinPerc(t *testing.T) {
 	cfg := getStandardConfig()
 
 	mockData := jsonapi.MockData{
-		resourceUrl: getResourceEndpoint(),
-		projectUrl:  getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: jsonapi.GetMockTextResponse(fmt.Sprintf(
 			`{"data": [{"type": "resource_language_stats",
 			            "id": "%s:l:en",
@@ -369,8 +405,8 @@ func TestPullCommandSkipOnReviewedMinPerc(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -381,8 +417,8 @@ func TestPullCommandSkipOnProofreadMinPerc(t *testing.T) {
 	cfg := getStandardConfig()
 
 	mockData := jsonapi.MockData{
-		resourceUrl: getResourceEndpoint(),
-		projectUrl:  getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: jsonapi.GetMockTextResponse(fmt.Sprintf(
 			`{"data": [{"type": "resource_language_stats",
 			            "id": "%s:l:en",
@@ -414,8 +450,8 @@ func TestPullCommandSkipOnProofreadMinPerc(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -427,8 +463,8 @@ func TestPullCommandPercentageArgumentShouldWinOverResource(t *testing.T) {
 	cfg.Local.Resources[0].MinimumPercentage = 30
 
 	mockData := jsonapi.MockData{
-		resourceUrl: getResourceEndpoint(),
-		projectUrl:  getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: jsonapi.GetMockTextResponse(fmt.Sprintf(
 			`{"data": [{"type": "resource_language_stats",
 			            "id": "%s:l:en",
@@ -460,8 +496,8 @@ func TestPullCommandPercentageArgumentShouldWinOverResource(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -472,8 +508,8 @@ func TestPercentageWinsOverForce(t *testing.T) {
 	cfg := getStandardConfig()
 
 	mockData := jsonapi.MockData{
-		resourceUrl: getResourceEndpoint(),
-		projectUrl:  getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: jsonapi.GetMockTextResponse(fmt.Sprintf(
 			`{"data": [{"type": "resource_language_stats",
 			            "id": "%s:l:en",
@@ -506,8 +542,8 @@ func TestPercentageWinsOverForce(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -559,11 +595,11 @@ func TestDownloadPseudoTranslations(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:          getResourceEndpoint(),
-		projectUrl:           getProjectEndpoint(),
-		statsUrlAllLanguages: getStatsEndpointAllLanguages(),
-		sourceDownloadsUrl:   getSourceDownloadsEndpoint(),
-		sourceDownloadUrl:    getDownloadEndpoint(ts.URL),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
+		sourceDownloadsUrl:     getSourceDownloadsEndpoint(),
+		sourceDownloadUrl:      getDownloadEndpoint(ts.URL),
 	}
 
 	api := jsonapi.GetTestConnection(mockData)
@@ -585,8 +621,8 @@ func TestDownloadPseudoTranslations(t *testing.T) {
 	}
 
 	assertFileContent(t, "locale/el_pseudo/aaa-el_pseudo.json", "This is the content")
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleSourceDownload(t, mockData, "true")
 	testSimpleGet(t, mockData, sourceDownloadUrl)
@@ -603,7 +639,7 @@ func TestKeepNewFilesSource(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:            getResourceEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
 		projectUrl:             getProjectEndpoint(),
 		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
 		sourceDownloadsUrl:     getSourceDownloadsEndpoint(),
@@ -630,8 +666,8 @@ func TestKeepNewFilesSource(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimpleSourceDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, sourceDownloadUrl)
@@ -651,7 +687,7 @@ func TestKeepNewFilesSourceOnlyWithDisableOverride(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:            getResourceEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
 		projectUrl:             getProjectEndpoint(),
 		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
 		sourceDownloadsUrl:     getSourceDownloadsEndpoint(),
@@ -677,8 +713,8 @@ func TestKeepNewFilesSourceOnlyWithDisableOverride(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimpleSourceDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, sourceDownloadUrl)
@@ -700,7 +736,7 @@ func TestKeepNewFilesTranslation(t *testing.T) {
 	defer ts.Close()
 
 	mockData := jsonapi.MockData{
-		resourceUrl:             getResourceEndpoint(),
+		resourceUrlWithProject:  getResourceWithProjectEndpoint(),
 		projectUrl:              getProjectEndpoint(),
 		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
 		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
@@ -726,8 +762,8 @@ func TestKeepNewFilesTranslation(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleTranslationDownload(t, mockData, "false")
 	testSimpleGet(t, mockData, translationDownloadUrl)
@@ -820,3 +856,116 @@ func testSimpleSourceDownload(
 		),
 	)
 }
+
+func TestPullCommandCommit(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	repo := initTestGitRepo(t)
+	repoConfig, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoConfig.User.Name = "Test"
+	repoConfig.User.Email = "test@example.com"
+	err = repo.SetConfig(repoConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := getStringsTestServer(t)
+	err = server.Store.SetTranslation(
+		"o:orgslug:p:projslug:r:resslug", "el", []byte(`{"hello": "Γεια"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := server.Connection()
+	pull := func() error {
+		cfg := getStandardConfig()
+		cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+		return PullCommand(cfg, &api, &PullCommandArguments{
+			FileType:              "default",
+			Mode:                  "default",
+			Force:                 true,
+			All:                   true,
+			Branch:                "-1",
+			MinimumPercentage:     -1,
+			Workers:               1,
+			Silent:                true,
+			Commit:                true,
+			CommitMessageTemplate: "Sync {{.Languages}}\n\n{{.Details}}",
+			BranchName:            "tx-sync",
+		})
+	}
+	err = pull()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Name().Short() != "tx-sync" {
+		t.Errorf("Committed on branch '%s'", head.Name().Short())
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Sync el\n\n- projslug.resslug: el (50%)"
+	if commit.Message != expected {
+		t.Errorf("Got commit message %q, expected %q", commit.Message, expected)
+	}
+	stats, err := commit.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Name != "aaa-el.json" {
+		t.Errorf("Got committed files %v", stats)
+	}
+
+	// Nothing changed since, so there is nothing to commit
+	err = pull()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newHead, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newHead.Hash() != head.Hash() {
+		t.Error("Unchanged files were committed")
+	}
+
+	// Files the user has staged must not end up in the commit
+	err = os.WriteFile("other.txt", []byte("work in progress"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = worktree.Add("other.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = server.Store.SetTranslation(
+		"o:orgslug:p:projslug:r:resslug", "el", []byte(`{"hello": "Γειά"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pull()
+	if err == nil || !strings.Contains(err.Error(), "other.txt") {
+		t.Errorf("Expected an error about the staged file, got %v", err)
+	}
+	newHead, err = repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newHead.Hash() != head.Hash() {
+		t.Error("Committed with unrelated staged changes")
+	}
+}
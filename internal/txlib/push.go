@@ -7,9 +7,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
 	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
 	"github.com/transifex/cli/pkg/txapi"
@@ -32,6 +34,8 @@ type PushCommandArguments struct {
 	Silent               bool
 	ReplaceEditedStrings bool
 	KeepTranslations     bool
+	// Only push the resources whose files changed in git since this ref
+	ChangedSince string
 }
 
 func PushCommand(
@@ -46,169 +50,266 @@ func PushCommand(
 		return err
 	}
 
+	if args.ChangedSince != "" {
+		changedFiles, err := getGitChangedFiles(args.ChangedSince)
+		if err != nil {
+			return err
+		}
+		cfgResources, err = filterChangedResources(
+			cfg, cfgResources, changedFiles, args.Translation,
+		)
+		if err != nil {
+			return err
+		}
+		if len(cfgResources) == 0 {
+			if !args.Silent {
+				fmt.Printf(
+					"No resources changed since '%s'\n", args.ChangedSince,
+				)
+			}
+			return nil
+		}
+	}
+
 	applyBranchToResources(cfgResources, args.Branch)
 
 	sort.Slice(cfgResources, func(i, j int) bool {
 		return cfgResources[i].GetAPv3Id() < cfgResources[j].GetAPv3Id()
 	})
 
-	// Step 1: Resources
+	// Without an explicit base, branches stacked on other branches are based
+	// on their parent branch's resources, if it has any
+	detectedBase := ""
+	var detectError error
+	if args.Branch != "" && args.Base == "-1" {
+		detectedBase, detectError = getGitParentBranch(args.Branch)
+		if errors.Is(detectError, git.ErrRepositoryNotExists) {
+			// Branches that don't come from git, nothing to detect
+			detectError = nil
+		}
+	}
 
 	if !args.Silent {
-		fmt.Print("# Getting info about resources\n\n")
+		fmt.Print("# Pushing resources\n\n")
+		if detectError != nil {
+			fmt.Printf(
+				"Could not detect the parent branch, not using a base: %s\n\n",
+				detectError,
+			)
+		} else if detectedBase != "" {
+			fmt.Printf("Detected parent branch '%s'\n\n", detectedBase)
+		}
 	}
 
-	pool := worker_pool.New(args.Workers, len(cfgResources), args.Silent)
-	sourceTaskChannel := make(chan *SourceFilePushTask)
-	translationTaskChannel := make(chan *TranslationFileTask)
-	targetLanguagesChannel := make(chan TargetLanguageMessage)
+	// Each resource gets its own pipeline (info -> source -> translations) so
+	// that a slow resource doesn't hold back the rest
+	scheduler := worker_pool.NewScheduler(args.Workers, args.Silent)
+	pipeline := &pushPipeline{
+		scheduler:       scheduler,
+		languageTasks:   make(map[string]worker_pool.TaskId),
+		targetLanguages: make(map[string][]string),
+		detectedBase:    detectedBase,
+	}
+	restoreApi := adaptConcurrencyToRetries(&api, scheduler, args.Workers)
+	defer restoreApi()
 	for _, cfgResource := range cfgResources {
-		pool.Add(
-			&ResourcePushTask{
-				cfg,
-				cfgResource,
-				sourceTaskChannel,
-				translationTaskChannel,
-				&api,
-				args,
-				targetLanguagesChannel,
-			},
+		scheduler.Add(
+			&ResourcePushTask{cfg, cfgResource, &api, args, pipeline},
 		)
 	}
-	pool.Start()
+	scheduler.Start()
+	<-scheduler.Wait()
 
-	var sourceFileTasks []*SourceFilePushTask
-	var translationFileTasks []*TranslationFileTask
-	projects := make(map[string]*jsonapi.Resource)
-	targetLanguages := make(map[string][]string)
+	// Files that were uploaded before an abort were still pushed. The push
+	// itself went through, so failing to record it is only a warning
+	err = pipeline.savePushState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save the push state: %s\n", err)
+	}
 
-	waitChannel := pool.Wait()
-	exitfor := false
-	for !exitfor {
-		select {
-		case sourceFileTask := <-sourceTaskChannel:
-			sourceFileTasks = append(sourceFileTasks, sourceFileTask)
+	if scheduler.IsAborted {
+		return errors.New("Aborted")
+	}
+	if args.Silent {
+		pipeline.printSummary(cfgResources)
+	}
+	// Reported in silent mode too, these files were not pushed
+	if len(pipeline.unknownLanguages) > 0 {
+		sort.Strings(pipeline.unknownLanguages)
+		fmt.Fprintln(os.Stderr, "\nUnknown language codes in local files:")
+		for _, message := range pipeline.unknownLanguages {
+			fmt.Fprintf(os.Stderr, "  %s\n", message)
+		}
+	}
 
-		case translationFileTask := <-translationTaskChannel:
-			translationFileTasks = append(translationFileTasks, translationFileTask)
+	return nil
+}
 
-		case targetLanguageMessage := <-targetLanguagesChannel:
-			project := targetLanguageMessage.project
-			languageId := targetLanguageMessage.languageId
+/*
+Keeps track of the tasks that the ResourcePushTasks schedule so that target
+languages are only created once per project and so that a summary can be
+printed in silent mode after everything is finished.
+*/
+type pushPipeline struct {
+	scheduler            *worker_pool.Scheduler
+	mutex                sync.Mutex
+	languageTasks        map[string]worker_pool.TaskId
+	targetLanguages      map[string][]string
+	sourceFileTasks      []*SourceFilePushTask
+	translationFileTasks []*TranslationFileTask
+	// The git branch that the pushed branch was created from, if it could be
+	// figured out; see getGitParentBranch
+	detectedBase string
+	// Codes of local files that aren't Transifex languages (any of them, not
+	// only the project's target languages), printed to stderr after
+	// everything is finished so that they aren't lost among the progress
+	// messages
+	unknownLanguages []string
+}
 
-			_, exists := projects[project.Id]
-			if !exists {
-				projects[project.Id] = project
-			}
+func (pipeline *pushPipeline) addSourceFileTask(
+	task *SourceFilePushTask,
+) worker_pool.TaskId {
+	pipeline.mutex.Lock()
+	pipeline.sourceFileTasks = append(pipeline.sourceFileTasks, task)
+	pipeline.mutex.Unlock()
+	return pipeline.scheduler.Add(task)
+}
 
-			languages, exists := targetLanguages[project.Id]
-			if !exists {
-				targetLanguages[project.Id] = []string{}
-				languages = targetLanguages[project.Id]
-			}
-			if !stringSliceContains(languages, languageId) {
-				targetLanguages[project.Id] = append(
-					targetLanguages[project.Id],
-					languageId,
-				)
-			}
+func (pipeline *pushPipeline) addUnknownLanguages(
+	cfgResource *config.Resource, unknownLanguages []unknownLanguageCode,
+) {
+	pipeline.mutex.Lock()
+	defer pipeline.mutex.Unlock()
+	for _, unknown := range unknownLanguages {
+		pipeline.unknownLanguages = append(
+			pipeline.unknownLanguages,
+			fmt.Sprintf("%s.%s: %s", cfgResource.ProjectSlug,
+				cfgResource.ResourceSlug, unknown),
+		)
+	}
+}
 
-		case <-waitChannel:
-			exitfor = true
+/*
+Schedule the creation of the 'languageCodes' remote target languages for
+'project'. Languages that have already been scheduled by another resource are
+not scheduled again. Returns the tasks that must finish before translations for
+each language can be pushed.
+*/
+func (pipeline *pushPipeline) addTargetLanguages(
+	project *jsonapi.Resource, languageCodes []string, args PushCommandArguments,
+) map[string]worker_pool.TaskId {
+	pipeline.mutex.Lock()
+	defer pipeline.mutex.Unlock()
+
+	result := make(map[string]worker_pool.TaskId)
+	var missing []string
+	for _, languageCode := range languageCodes {
+		key := fmt.Sprintf("%s|%s", project.Id, languageCode)
+		taskId, exists := pipeline.languageTasks[key]
+		if exists {
+			result[languageCode] = taskId
+		} else if !stringSliceContains(missing, languageCode) {
+			missing = append(missing, languageCode)
 		}
 	}
-
-	if pool.IsAborted {
-		return errors.New("Aborted")
+	if len(missing) == 0 {
+		return result
 	}
-	if args.Silent {
-		var names []string
-		for _, cfgResource := range cfgResources {
-			names = append(names, fmt.Sprintf(
-				"%s.%s",
-				cfgResource.ProjectSlug,
-				cfgResource.ResourceSlug,
-			))
-		}
-		fmt.Printf("Got info about resources: %s\n", strings.Join(names, ", "))
+
+	sort.Strings(missing)
+	taskId := pipeline.scheduler.Add(&LanguagePushTask{project, missing, args})
+	for _, languageCode := range missing {
+		key := fmt.Sprintf("%s|%s", project.Id, languageCode)
+		pipeline.languageTasks[key] = taskId
+		result[languageCode] = taskId
 	}
+	pipeline.targetLanguages[project.Id] = append(
+		pipeline.targetLanguages[project.Id], missing...,
+	)
+	return result
+}
 
-	// Step 2: Create missing remote target languages
+func (pipeline *pushPipeline) addTranslationFileTask(
+	task *TranslationFileTask, prerequisites ...worker_pool.TaskId,
+) {
+	pipeline.mutex.Lock()
+	pipeline.translationFileTasks = append(pipeline.translationFileTasks, task)
+	pipeline.mutex.Unlock()
+	pipeline.scheduler.Add(task, prerequisites...)
+}
 
-	if len(targetLanguages) > 0 {
-		if !args.Silent {
-			fmt.Print("\n# Create missing remote target languages\n\n")
-		}
-
-		pool = worker_pool.New(args.Workers, len(targetLanguages), args.Silent)
-		for projectId, languages := range targetLanguages {
-			sort.Slice(languages, func(i, j int) bool {
-				return languages[i] < languages[j]
-			})
-			pool.Add(&LanguagePushTask{projects[projectId], languages, args})
-		}
-		pool.Start()
-		<-pool.Wait()
-		if pool.IsAborted {
-			return errors.New("Aborted")
-		}
-		if args.Silent {
-			var names []string
-			for projectId, languages := range targetLanguages {
-				parts := strings.Split(projectId, ":")
-				projectSlug := parts[1]
-
-				var languageCodes []string
-				languageCodes = append(languageCodes, languages...)
-				names = append(names, fmt.Sprintf(
-					"%s: %s",
-					projectSlug,
-					strings.Join(languageCodes, ", "),
-				))
-			}
-			fmt.Printf(
-				"Created missing remote target languages: %s\n",
-				strings.Join(names, ", "),
-			)
+/*
+Record the files that were uploaded, for 'tx check'. Source files that were
+skipped because the remote was more recent count too, since push considers
+them up to date.
+*/
+func (pipeline *pushPipeline) savePushState() error {
+	var paths []string
+	for _, task := range pipeline.sourceFileTasks {
+		if task.pushed || task.skipped {
+			paths = append(paths, task.sourceFile)
 		}
 	}
+	for _, task := range pipeline.translationFileTasks {
+		if task.pushed {
+			paths = append(paths, task.path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return updatePushState(paths)
+}
 
-	// Step 3: SourceFiles
-
-	if len(sourceFileTasks) > 0 {
-		if !args.Silent {
-			fmt.Print("\n# Pushing source files\n\n")
+func (pipeline *pushPipeline) printSummary(cfgResources []*config.Resource) {
+	var names []string
+	for _, cfgResource := range cfgResources {
+		names = append(names, fmt.Sprintf(
+			"%s.%s",
+			cfgResource.ProjectSlug,
+			cfgResource.ResourceSlug,
+		))
+	}
+	fmt.Printf("Got info about resources: %s\n", strings.Join(names, ", "))
+
+	if len(pipeline.targetLanguages) > 0 {
+		names = nil
+		for projectId, languages := range pipeline.targetLanguages {
+			parts := strings.Split(projectId, ":")
+			projectSlug := parts[3]
+			sort.Strings(languages)
+			names = append(names, fmt.Sprintf(
+				"%s: %s",
+				projectSlug,
+				strings.Join(languages, ", "),
+			))
 		}
+		sort.Strings(names)
+		fmt.Printf(
+			"Created missing remote target languages: %s\n",
+			strings.Join(names, ", "),
+		)
+	}
 
+	sourceFileTasks := pipeline.sourceFileTasks
+	if len(sourceFileTasks) > 0 {
 		sort.Slice(sourceFileTasks, func(i, j int) bool {
 			return sourceFileTasks[i].resource.Id < sourceFileTasks[j].resource.Id
 		})
-		pool = worker_pool.New(args.Workers, len(sourceFileTasks), args.Silent)
+		names = nil
 		for _, sourceFileTask := range sourceFileTasks {
-			pool.Add(sourceFileTask)
-		}
-		pool.Start()
-		<-pool.Wait()
-
-		if pool.IsAborted {
-			return errors.New("Aborted")
-		}
-		if args.Silent {
-			var names []string
-			for _, sourceFileTask := range sourceFileTasks {
-				parts := strings.Split(sourceFileTask.resource.Id, ":")
-				resourceSlug := parts[5]
-				names = append(names, resourceSlug)
-			}
-			fmt.Printf(
-				"Pushed source files for: %s\n",
-				strings.Join(names, ", "),
-			)
+			parts := strings.Split(sourceFileTask.resource.Id, ":")
+			resourceSlug := parts[5]
+			names = append(names, resourceSlug)
 		}
+		fmt.Printf(
+			"Pushed source files for: %s\n",
+			strings.Join(names, ", "),
+		)
 	}
 
-	// Step 4: Translations
-
+	translationFileTasks := pipeline.translationFileTasks
 	if len(translationFileTasks) > 0 {
 		sort.Slice(translationFileTasks, func(i, j int) bool {
 			left := translationFileTasks[i]
@@ -219,61 +320,34 @@ func PushCommand(
 				return left.languageCode < right.languageCode
 			}
 		})
-		if !args.Silent {
-			fmt.Print("\n# Pushing translations\n\n")
-		}
-
-		pool = worker_pool.New(args.Workers, len(translationFileTasks), args.Silent)
+		names = nil
 		for _, translationFileTask := range translationFileTasks {
-			pool.Add(translationFileTask)
-		}
-		pool.Start()
-		<-pool.Wait()
-
-		if pool.IsAborted {
-			return errors.New("Aborted")
-		}
-		if args.Silent {
-			var names []string
-			for _, translationFileTask := range translationFileTasks {
-				parts := strings.Split(translationFileTask.resource.Id, ":")
-				resourceSlug := parts[5]
-				names = append(names, fmt.Sprintf(
-					"%s: %s",
-					resourceSlug,
-					translationFileTask.languageCode,
-				))
-			}
-			fmt.Printf("Pushed translations: %s\n", strings.Join(names, ", "))
+			parts := strings.Split(translationFileTask.resource.Id, ":")
+			resourceSlug := parts[5]
+			names = append(names, fmt.Sprintf(
+				"%s: %s",
+				resourceSlug,
+				translationFileTask.languageCode,
+			))
 		}
+		fmt.Printf("Pushed translations: %s\n", strings.Join(names, ", "))
 	}
-
-	return nil
-}
-
-type TargetLanguageMessage struct {
-	project    *jsonapi.Resource
-	languageId string
 }
 
 type ResourcePushTask struct {
-	cfg                    *config.Config
-	cfgResource            *config.Resource
-	sourceTaskChannel      chan *SourceFilePushTask
-	translationTaskChannel chan *TranslationFileTask
-	api                    *jsonapi.Connection
-	args                   PushCommandArguments
-	targetLanguagesChannel chan TargetLanguageMessage
+	cfg         *config.Config
+	cfgResource *config.Resource
+	api         *jsonapi.Connection
+	args        PushCommandArguments
+	pipeline    *pushPipeline
 }
 
 func (task *ResourcePushTask) Run(send func(string), abort func()) {
 	cfg := task.cfg
 	cfgResource := task.cfgResource
-	sourceTaskChannel := task.sourceTaskChannel
-	translationTaskChannel := task.translationTaskChannel
 	api := task.api
 	args := task.args
-	targetLanguagesChannel := task.targetLanguagesChannel
+	pipeline := task.pipeline
 
 	sendMessage := func(body string, force bool) {
 		if args.Silent && !force {
@@ -342,32 +416,43 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 				args.Branch,
 			)
 
-			baseResourceSlug := getBaseResourceSlug(cfgResource, args.Branch, args.Base)
-
-			baseResourceId = fmt.Sprintf(
-				"o:%s:p:%s:r:%s",
-				cfgResource.OrganizationSlug,
-				cfgResource.ProjectSlug,
-				baseResourceSlug,
-			)
-
+			// Try the resource of the detected parent branch first, if any,
+			// then the main resource
+			bases := []string{args.Base}
+			if args.Base == "-1" && pipeline.detectedBase != "" {
+				bases = []string{pipeline.detectedBase, "-1"}
+			}
 			var baseResource *jsonapi.Resource
-			err = handleRetry(
-				func() error {
-					var err error
-					baseResource, err = txapi.GetResourceById(api, baseResourceId)
-					return err
-				},
-				"Getting info",
-				func(msg string) { sendMessage(msg, false) },
-			)
+			for _, base := range bases {
+				baseResourceSlug := getBaseResourceSlug(cfgResource, args.Branch, base)
+
+				baseResourceId = fmt.Sprintf(
+					"o:%s:p:%s:r:%s",
+					cfgResource.OrganizationSlug,
+					cfgResource.ProjectSlug,
+					baseResourceSlug,
+				)
 
-			if err != nil {
-				sendMessage(fmt.Sprintf("Error while fetching base resource: %s", err), true)
-				if !args.Skip {
-					abort()
+				err = handleRetry(
+					func() error {
+						var err error
+						baseResource, err = txapi.GetResourceById(api, baseResourceId)
+						return err
+					},
+					"Getting info",
+					func(msg string) { sendMessage(msg, false) },
+				)
+
+				if err != nil {
+					sendMessage(fmt.Sprintf("Error while fetching base resource: %s", err), true)
+					if !args.Skip {
+						abort()
+					}
+					return
+				}
+				if baseResource != nil {
+					break
 				}
-				return
 			}
 			if args.Base != "-1" {
 				if baseResource == nil {
@@ -480,17 +565,22 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 		}
 		return
 	}
+	var prerequisites []worker_pool.TaskId
 	if args.Source || !args.Translation {
-		sourceTaskChannel <- &SourceFilePushTask{
-			api,
-			resource,
-			cfgResource.SourceFile,
-			remoteStats[sourceLanguage.Id],
-			args,
-			resourceIsNew,
-			args.ReplaceEditedStrings || cfgResource.ReplaceEditedStrings,
-			args.KeepTranslations || cfgResource.KeepTranslations,
-		}
+		sourceTaskId := pipeline.addSourceFileTask(&SourceFilePushTask{
+			api:           api,
+			resource:      resource,
+			sourceFile:    cfgResource.SourceFile,
+			remoteStats:   remoteStats[sourceLanguage.Id],
+			args:          args,
+			resourceIsNew: resourceIsNew,
+			replaceEditedStrings: args.ReplaceEditedStrings ||
+				cfgResource.ReplaceEditedStrings,
+			keepTranslations: args.KeepTranslations ||
+				cfgResource.KeepTranslations,
+		})
+		// Translations of this resource will be pushed after its source
+		prerequisites = append(prerequisites, sourceTaskId)
 	}
 	if args.Translation { // -t flag is set
 		localToRemoteLanguageMappings := reverseMap(
@@ -523,8 +613,16 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			fileFilter = fmt.Sprintf("%s.xlf", fileFilter)
 		}
 
+		ignorer, err := newFileIgnorer(cfg, cfgResource)
+		if err != nil {
+			sendMessage(err.Error(), true)
+			if !args.Skip {
+				abort()
+			}
+			return
+		}
 		paths, newLanguageCodes, err := getFilesToPush(
-			curDir, fileFilter, localToRemoteLanguageMappings,
+			curDir, fileFilter, ignorer, localToRemoteLanguageMappings,
 			remoteStats, overrides, args, resourceIsNew,
 		)
 		if err != nil {
@@ -550,28 +648,51 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			abort()
 			return
 		}
+		unknownLanguages, err := findUnknownLanguageCodes(paths, allLanguages)
+		if err != nil {
+			sendMessage(err.Error(), true)
+			abort()
+			return
+		}
+		pipeline.addUnknownLanguages(cfgResource, unknownLanguages)
+		var targetLanguageCodes []string
 		for _, languageCode := range newLanguageCodes {
 			_, exists := allLanguages[languageCode]
 			if !exists || fmt.Sprintf("l:%s", languageCode) == sourceLanguage.Id {
 				continue
 			}
-			targetLanguagesChannel <- TargetLanguageMessage{project, languageCode}
+			targetLanguageCodes = append(targetLanguageCodes, languageCode)
 		}
+		languageTasks := pipeline.addTargetLanguages(
+			project, targetLanguageCodes, args,
+		)
 		for languageCode, path := range paths {
 			_, exists := allLanguages[languageCode]
 			if !exists || fmt.Sprintf("l:%s", languageCode) == sourceLanguage.Id {
 				continue
 			}
 
-			translationTaskChannel <- &TranslationFileTask{
-				api,
-				languageCode,
-				path,
-				resource,
-				args,
-				remoteStats,
-				resourceIsNew,
+			translationPrerequisites := append(
+				[]worker_pool.TaskId{}, prerequisites...,
+			)
+			languageTaskId, exists := languageTasks[languageCode]
+			if exists {
+				translationPrerequisites = append(
+					translationPrerequisites, languageTaskId,
+				)
 			}
+			pipeline.addTranslationFileTask(
+				&TranslationFileTask{
+					api:           api,
+					languageCode:  languageCode,
+					path:          path,
+					resource:      resource,
+					args:          args,
+					remoteStats:   remoteStats,
+					resourceIsNew: resourceIsNew,
+				},
+				translationPrerequisites...,
+			)
 		}
 	}
 	sendMessage("Done", false)
@@ -633,6 +754,10 @@ type SourceFilePushTask struct {
 	resourceIsNew        bool
 	replaceEditedStrings bool
 	keepTranslations     bool
+	// Whether the file was uploaded, for the push state (see savePushState)
+	pushed bool
+	// Whether the upload was skipped because the remote was more recent
+	skipped bool
 }
 
 func (task *SourceFilePushTask) Run(send func(string), abort func()) {
@@ -675,6 +800,7 @@ func (task *SourceFilePushTask) Run(send func(string), abort func()) {
 			sourceFile, remoteStats, args.UseGitTimestamps,
 		)
 		if skip {
+			task.skipped = true
 			sendMessage("Skipping", false)
 			return
 		}
@@ -725,6 +851,7 @@ func (task *SourceFilePushTask) Run(send func(string), abort func()) {
 		}
 		return
 	}
+	task.pushed = true
 
 	sendMessage("Done", false)
 }
@@ -737,6 +864,8 @@ type TranslationFileTask struct {
 	args          PushCommandArguments
 	remoteStats   map[string]*jsonapi.Resource
 	resourceIsNew bool
+	// See SourceFilePushTask
+	pushed bool
 }
 
 func (task *TranslationFileTask) Run(send func(string), abort func()) {
@@ -821,11 +950,55 @@ func (task *TranslationFileTask) Run(send func(string), abort func()) {
 		}
 		return
 	}
+	task.pushed = true
 	sendMessage("Done", false)
 }
 
+/*
+Keep the resources whose source file, or one of whose translation files if
+'translations' is set, is in 'changedFiles' (absolute paths, see
+getGitChangedFiles)
+*/
+func filterChangedResources(
+	cfg *config.Config,
+	cfgResources []*config.Resource,
+	changedFiles map[string]bool,
+	translations bool,
+) ([]*config.Resource, error) {
+	curDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	var result []*config.Resource
+	for _, cfgResource := range cfgResources {
+		paths := []string{filepath.Join(curDir, cfgResource.SourceFile)}
+		if translations {
+			files, err := searchResourceFiles(
+				cfg, cfgResource, curDir, cfgResource.FileFilter,
+			)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range files {
+				paths = append(paths, path)
+			}
+			for _, customPath := range cfgResource.Overrides {
+				paths = append(paths, filepath.Join(curDir, customPath))
+			}
+		}
+		for _, path := range paths {
+			if changedFiles[filepath.Clean(path)] {
+				result = append(result, cfgResource)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
 func getFilesToPush(
 	curDir, fileFilter string,
+	ignorer *fileIgnorer,
 	localToRemoteLanguageMappings map[string]string,
 	remoteStats map[string]*jsonapi.Resource,
 	overrides map[string]string,
@@ -835,7 +1008,7 @@ func getFilesToPush(
 	paths := make(map[string]string)
 	var newLanguageCodes []string
 
-	allLocalLanguages := searchFileFilter(curDir, fileFilter)
+	allLocalLanguages := ignorer.filter(searchFileFilter(curDir, fileFilter))
 
 	if len(overrides) > 0 {
 		for languageCode, customPath := range overrides {
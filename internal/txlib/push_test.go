@@ -2,25 +2,72 @@ package txlib
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapitest"
 )
 
+func TestPushCommandReplay(t *testing.T) {
+	api, replayer := getCassetteConnection(t, "push_cassette")
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.WriteFile(
+		"aaa.json", []byte(`{"hello": "Hello world", "bye": "Goodbye"}`), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		"aaa-el.json", []byte(`{"hello": "Γειά σου κόσμε", "bye": "Αντίο"}`), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+	err = PushCommand(cfg, api, PushCommandArguments{
+		Source: true, Translation: true, Force: true, Branch: "-1",
+		Workers: 1, Silent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replayer.Remaining() != 0 {
+		t.Errorf("%d recorded requests were not made", replayer.Remaining())
+	}
+	var uploads []string
+	for _, request := range replayer.Requests {
+		if request.Method == "POST" {
+			uploads = append(uploads, string(request.Body))
+		}
+	}
+	if len(uploads) != 2 || !strings.Contains(uploads[0], "Hello world") ||
+		!strings.Contains(uploads[1], "Γειά σου κόσμε") ||
+		!strings.Contains(uploads[1], "l:el") {
+		t.Errorf("Unexpected uploads: %v", uploads)
+	}
+}
+
 func TestPushCommandResourceExists(t *testing.T) {
 	afterTest := beforeTest(t, nil, nil)
 	defer afterTest()
 
 	mockData := jsonapi.MockData{
-		"/languages":                      getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:                       getResourceEndpoint(),
-		projectUrl:                        getProjectEndpoint(),
-		statsUrlSourceLanguage:            getStatsEndpointSourceLanguage(),
-		"/resource_strings_async_uploads": getSourceUploadPostEndpoint(),
+		"/languages":                               getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject:                     getResourceWithProjectEndpoint(),
+		projectUrl:                                 getProjectEndpoint(),
+		statsUrlSourceLanguage:                     getStatsEndpointSourceLanguage(),
+		"/resource_strings_async_uploads":          getSourceUploadPostEndpoint(),
 		"/resource_strings_async_uploads/upload_1": getSourceUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
@@ -32,8 +79,8 @@ func TestPushCommandResourceExists(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimpleUpload(t, mockData, "/resource_strings_async_uploads")
 	testSimpleGet(t, mockData, "/resource_strings_async_uploads/upload_1")
@@ -45,7 +92,7 @@ func TestPushSpecificResource(t *testing.T) {
 
 	mockData := jsonapi.MockData{
 		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:            getResourceEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
 		projectUrl:             getProjectEndpoint(),
 		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
 		sourceUploadsUrl:       getSourceUploadPostEndpoint(),
@@ -63,8 +110,8 @@ func TestPushSpecificResource(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimpleUpload(t, mockData, "/resource_strings_async_uploads")
 	testSimpleGet(t, mockData, "/resource_strings_async_uploads/upload_1")
@@ -76,7 +123,7 @@ func TestPushCommandResourceDoesNotExist(t *testing.T) {
 
 	mockData := jsonapi.MockData{
 		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:            getEmptyEndpoint(),
+		resourceUrlWithProject: getEmptyEndpoint(),
 		projectUrl:             getProjectEndpoint(),
 		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
 		resourcesUrl:           getResourceCreatedEndpoint(),
@@ -94,7 +141,7 @@ func TestPushCommandResourceDoesNotExist(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
 	testSimpleGet(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimplePost(
@@ -119,12 +166,14 @@ func TestPushCommandBranchResourceDoesNotExist(t *testing.T) {
 	defer afterTest()
 
 	branchResourceId := "o:orgslug:p:projslug:r:branch--resslug"
-	branchResourceUrl := fmt.Sprintf("/resources/%s", branchResourceId)
+	branchResourceUrl := fmt.Sprintf(
+		"/resources/%s?include=project", branchResourceId,
+	)
 
 	mockData := jsonapi.MockData{
 		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
 		branchResourceUrl:      getEmptyEndpoint(),
-		resourceUrl:            getEmptyEndpoint(),
+		resourceUrlWithProject: getEmptyEndpoint(),
 		projectUrl:             getProjectEndpoint(),
 		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
 		resourcesUrl:           getResourceCreatedEndpoint(),
@@ -144,7 +193,7 @@ func TestPushCommandBranchResourceDoesNotExist(t *testing.T) {
 	}
 
 	testSimpleGet(t, mockData, branchResourceUrl)
-	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
 	testSimpleGet(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlSourceLanguage)
 	testSimplePost(
@@ -169,12 +218,12 @@ func TestPushTranslation(t *testing.T) {
 	defer afterTest()
 
 	mockData := jsonapi.MockData{
-		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:           getResourceEndpoint(),
-		projectUrl:            getProjectEndpoint(),
-		statsUrlAllLanguages:  getStatsEndpointAllLanguages(),
-		translationUploadsUrl: getTranslationUploadPostEndpoint(),
-		translationUploadUrl:  getTranslationUploadGetEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
+		translationUploadsUrl:  getTranslationUploadPostEndpoint(),
+		translationUploadUrl:   getTranslationUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -188,8 +237,8 @@ func TestPushTranslation(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleUpload(t, mockData, translationUploadsUrl)
 	testSimpleGet(t, mockData, translationUploadUrl)
@@ -214,12 +263,12 @@ func TestPushXliff(t *testing.T) {
 	}
 
 	mockData := jsonapi.MockData{
-		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:           getResourceEndpoint(),
-		projectUrl:            getProjectEndpoint(),
-		statsUrlAllLanguages:  getStatsEndpointAllLanguages(),
-		translationUploadsUrl: getTranslationUploadPostEndpoint(),
-		translationUploadUrl:  getTranslationUploadGetEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
+		translationUploadsUrl:  getTranslationUploadPostEndpoint(),
+		translationUploadUrl:   getTranslationUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -234,8 +283,8 @@ func TestPushXliff(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleUpload(t, mockData, translationUploadsUrl)
 	testSimpleGet(t, mockData, translationUploadUrl)
@@ -251,12 +300,12 @@ func TestPushTranslationWithLanguageMapping(t *testing.T) {
 	}
 
 	mockData := jsonapi.MockData{
-		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:           getResourceEndpoint(),
-		projectUrl:            getProjectEndpoint(),
-		statsUrlAllLanguages:  getStatsEndpointAllLanguages(),
-		translationUploadsUrl: getTranslationUploadPostEndpoint(),
-		translationUploadUrl:  getTranslationUploadGetEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
+		translationUploadsUrl:  getTranslationUploadPostEndpoint(),
+		translationUploadUrl:   getTranslationUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -270,8 +319,8 @@ func TestPushTranslationWithLanguageMapping(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleUpload(t, mockData, translationUploadsUrl)
 	testSimpleGet(t, mockData, translationUploadUrl)
@@ -289,12 +338,12 @@ func TestPushTranslationWithOverrides(t *testing.T) {
 	}
 
 	mockData := jsonapi.MockData{
-		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:           getResourceEndpoint(),
-		projectUrl:            getProjectEndpoint(),
-		statsUrlAllLanguages:  getStatsEndpointAllLanguages(),
-		translationUploadsUrl: getTranslationUploadPostEndpoint(),
-		translationUploadUrl:  getTranslationUploadGetEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
+		translationUploadsUrl:  getTranslationUploadPostEndpoint(),
+		translationUploadUrl:   getTranslationUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -308,8 +357,8 @@ func TestPushTranslationWithOverrides(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleUpload(t, mockData, translationUploadsUrl)
 	testSimpleGet(t, mockData, translationUploadUrl)
@@ -320,10 +369,10 @@ func TestPushTranslationRemoteLanguageDoesNotExist(t *testing.T) {
 	defer afterTest()
 
 	mockData := jsonapi.MockData{
-		"/languages":         getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:          getResourceEndpoint(),
-		projectUrl:           getProjectEndpoint(),
-		statsUrlAllLanguages: getStatsEndpointAllLanguages(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -337,8 +386,8 @@ func TestPushTranslationRemoteLanguageDoesNotExist(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -349,9 +398,9 @@ func TestPushTranslationLocalFileIsOlderThanRemote(t *testing.T) {
 	now := time.Now().UTC()
 	duration, _ := time.ParseDuration("5m")
 	mockData := jsonapi.MockData{
-		"/languages": getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:  getResourceEndpoint(),
-		projectUrl:   getProjectEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
 		statsUrlAllLanguages: getResourceLanguageStatsEndpoint(
 			now.Add(duration),
 		),
@@ -367,8 +416,8 @@ func TestPushTranslationLocalFileIsOlderThanRemote(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 }
 
@@ -379,12 +428,12 @@ func TestPushTranslationLocalFileIsNewerThanRemote(t *testing.T) {
 	now := time.Now().UTC()
 	duration, _ := time.ParseDuration("-5m")
 	mockData := jsonapi.MockData{
-		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		projectUrl:            getProjectEndpoint(),
-		resourceUrl:           getResourceEndpoint(),
-		statsUrlAllLanguages:  getResourceLanguageStatsEndpoint(now.Add(duration)),
-		translationUploadsUrl: getTranslationUploadPostEndpoint(),
-		translationUploadUrl:  getTranslationUploadGetEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		projectUrl:             getProjectEndpoint(),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		statsUrlAllLanguages:   getResourceLanguageStatsEndpoint(now.Add(duration)),
+		translationUploadsUrl:  getTranslationUploadPostEndpoint(),
+		translationUploadUrl:   getTranslationUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -397,8 +446,8 @@ func TestPushTranslationLocalFileIsNewerThanRemote(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleUpload(t, mockData, translationUploadsUrl)
 	testSimpleGet(t, mockData, translationUploadUrl)
@@ -409,12 +458,12 @@ func TestPushTranslationLimitLanguages(t *testing.T) {
 	defer afterTest()
 
 	mockData := jsonapi.MockData{
-		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:           getResourceEndpoint(),
-		projectUrl:            getProjectEndpoint(),
-		statsUrlAllLanguages:  getStatsEndpointAllLanguages(),
-		translationUploadsUrl: getTranslationUploadPostEndpoint(),
-		translationUploadUrl:  getTranslationUploadGetEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlAllLanguages:   getStatsEndpointAllLanguages(),
+		translationUploadsUrl:  getTranslationUploadPostEndpoint(),
+		translationUploadUrl:   getTranslationUploadGetEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
@@ -429,8 +478,8 @@ func TestPushTranslationLimitLanguages(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimpleUpload(t, mockData, translationUploadsUrl)
 	testSimpleGet(t, mockData, translationUploadUrl)
@@ -440,8 +489,8 @@ func TestProjectNotFound(t *testing.T) {
 	afterTest := beforeTest(t, nil, nil)
 	defer afterTest()
 	mockData := jsonapi.MockData{
-		"/languages": getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:  getResourceEndpoint(),
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrlWithProject: getResourceWithProjectEndpoint(),
 	}
 	api := jsonapi.GetTestConnection(mockData)
 	err := PushCommand(
@@ -453,7 +502,7 @@ func TestProjectNotFound(t *testing.T) {
 		t.Error("Expected error")
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
 }
 
 func TestPushCommandBranch(t *testing.T) {
@@ -462,6 +511,7 @@ func TestPushCommandBranch(t *testing.T) {
 
 	resourceId := "o:orgslug:p:projslug:r:branch--resslug"
 	resourceUrl := fmt.Sprintf("/resources/%s", resourceId)
+	resourceUrlWithProject := fmt.Sprintf("%s?include=project", resourceUrl)
 	statsUrl := fmt.Sprintf(
 		"/resource_language_stats?%s=%s&%s=%s&%s=%s",
 		url.QueryEscape("filter[language]"),
@@ -474,7 +524,7 @@ func TestPushCommandBranch(t *testing.T) {
 
 	mockData := jsonapi.MockData{
 		"/languages": getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl: &jsonapi.MockEndpoint{
+		resourceUrlWithProject: &jsonapi.MockEndpoint{
 			Requests: []jsonapi.MockRequest{
 				{
 					Response: jsonapi.MockResponse{
@@ -485,12 +535,17 @@ func TestPushCommandBranch(t *testing.T) {
 								"attributes": {"slug": "branch--resslug"},
 								"relationships": {"project": {"data": {"type": "projects",
 																	   "id": "%s"}}}
-							}}`,
+							}, "included": [%s]}`,
 							resourceId,
 							projectId,
+							getIncludedProject(),
 						),
 					},
 				},
+			},
+		},
+		resourceUrl: &jsonapi.MockEndpoint{
+			Requests: []jsonapi.MockRequest{
 				{
 					Response: jsonapi.MockResponse{
 						Text: fmt.Sprintf(
@@ -524,10 +579,11 @@ func TestPushCommandBranch(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testMultipleRequests(t, mockData, resourceUrl, []string{"GET", "PATCH"}, []string{"",
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testMultipleRequests(t, mockData, resourceUrl, []string{"PATCH"}, []string{
 		`{"data":{"type":"resources","id":"o:orgslug:p:projslug:r:branch--resslug","relationships":{"base":{"data":{"type":"resources","id":"o:orgslug:p:projslug:r:resslug"}}}}}
 		`})
-	testSimpleGet(t, mockData, projectUrl)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrl)
 	testSimpleUpload(t, mockData, sourceUploadsUrl)
 	testSimpleGet(t, mockData, sourceUploadUrl)
@@ -539,6 +595,7 @@ func TestPushCommandChangeBase(t *testing.T) {
 
 	resourceId := "o:orgslug:p:projslug:r:branch--resslug"
 	resourceUrl := fmt.Sprintf("/resources/%s", resourceId)
+	resourceUrlWithProject := fmt.Sprintf("%s?include=project", resourceUrl)
 	statsUrl := fmt.Sprintf(
 		"/resource_language_stats?%s=%s&%s=%s&%s=%s",
 		url.QueryEscape("filter[language]"),
@@ -551,7 +608,7 @@ func TestPushCommandChangeBase(t *testing.T) {
 
 	mockData := jsonapi.MockData{
 		"/languages": getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl: &jsonapi.MockEndpoint{
+		resourceUrlWithProject: &jsonapi.MockEndpoint{
 			Requests: []jsonapi.MockRequest{
 				{
 					Response: jsonapi.MockResponse{
@@ -562,12 +619,17 @@ func TestPushCommandChangeBase(t *testing.T) {
 								"attributes": {"slug": "branch--resslug"},
 								"relationships": {"project": {"data": {"type": "projects",
 																	   "id": "%s"}}}
-							}}`,
+							}, "included": [%s]}`,
 							resourceId,
 							projectId,
+							getIncludedProject(),
 						),
 					},
 				},
+			},
+		},
+		resourceUrl: &jsonapi.MockEndpoint{
+			Requests: []jsonapi.MockRequest{
 				{
 					Response: jsonapi.MockResponse{
 						Text: fmt.Sprintf(
@@ -602,7 +664,8 @@ func TestPushCommandChangeBase(t *testing.T) {
 		t.Errorf("%s", err)
 	}
 
-	testMultipleRequests(t, mockData, resourceUrl, []string{"GET", "PATCH"}, []string{"",
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testMultipleRequests(t, mockData, resourceUrl, []string{"PATCH"}, []string{
 		`{
 			"data":{
 				"type":"resources",
@@ -617,7 +680,7 @@ func TestPushCommandChangeBase(t *testing.T) {
 				}
 			}
 		}`})
-	testSimpleGet(t, mockData, projectUrl)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrl)
 	testSimpleUpload(t, mockData, sourceUploadsUrl)
 	testSimpleGet(t, mockData, sourceUploadUrl)
@@ -630,7 +693,7 @@ func TestPushNewLanguage(t *testing.T) {
 	languagesRelationshipUrl := "/projects/o:orgslug:p:projslug/relationships/languages"
 	mockData := jsonapi.MockData{
 		"/languages":             getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:              getResourceEndpoint(),
+		resourceUrlWithProject:   getResourceWithProjectEndpoint(),
 		projectUrl:               getProjectEndpoint(),
 		statsUrlAllLanguages:     getStatsEndpointAllLanguages(),
 		languagesRelationshipUrl: jsonapi.GetMockTextResponse(""),
@@ -650,8 +713,8 @@ func TestPushNewLanguage(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimplePost(
 		t,
@@ -670,7 +733,7 @@ func TestPushAll(t *testing.T) {
 	languagesRelationshipUrl := "/projects/o:orgslug:p:projslug/relationships/languages"
 	mockData := jsonapi.MockData{
 		"/languages":             getLanguagesEndpoint([]string{"en", "fr", "el"}),
-		resourceUrl:              getResourceEndpoint(),
+		resourceUrlWithProject:   getResourceWithProjectEndpoint(),
 		projectUrl:               getProjectEndpoint(),
 		statsUrlAllLanguages:     jsonapi.GetMockTextResponse(`{"data": []}`),
 		languagesRelationshipUrl: jsonapi.GetMockTextResponse(""),
@@ -690,8 +753,8 @@ func TestPushAll(t *testing.T) {
 		t.Error(err)
 	}
 
-	testSimpleGet(t, mockData, resourceUrl)
-	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, resourceUrlWithProject)
+	testNotRequested(t, mockData, projectUrl)
 	testSimpleGet(t, mockData, statsUrlAllLanguages)
 	testSimplePost(
 		t,
@@ -809,3 +872,219 @@ func testSimpleUpload(t *testing.T, mockData jsonapi.MockData, path string) {
 		t.Errorf("Something was wrong with the request '%+v'", actual)
 	}
 }
+
+func TestPushCommandAgainstFakeServer(t *testing.T) {
+	afterTest := beforeTest(t, []string{"el"}, nil)
+	defer afterTest()
+	err := os.WriteFile("aaa.json", []byte(`{"hello": "world"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := txapitest.NewServer()
+	defer server.Close()
+	server.Store.AddProject("orgslug", "projslug", "en", "el")
+	api := server.Connection()
+
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+	err = PushCommand(cfg, api, PushCommandArguments{
+		Source: true, Translation: true, Force: true, Branch: "-1",
+		Workers: 1, Silent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource, exists := server.Store.Resources["o:orgslug:p:projslug:r:resslug"]
+	if !exists {
+		t.Fatal("Resource was not created")
+	}
+	if string(resource.Content) != `{"hello": "world"}` {
+		t.Errorf("Got source content %s", resource.Content)
+	}
+	if _, exists := resource.Translations["el"]; !exists {
+		t.Error("Greek translation was not uploaded")
+	}
+}
+
+func TestPushCommandDetectsParentBranch(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.WriteFile("aaa.json", []byte(`{"hello": "world"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := initTestGitRepo(t)
+
+	server := txapitest.NewServer()
+	defer server.Close()
+	server.Store.AddProject("orgslug", "projslug", "en", "el")
+	api := server.Connection()
+
+	push := func(branch string) {
+		cfg := getStandardConfig()
+		cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+		err := PushCommand(cfg, api, PushCommandArguments{
+			Source: true, Force: true, Branch: branch, Base: "-1",
+			Workers: 1, Silent: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	push("-1")
+	// 'main' has no resources of its own, so 'feat-a' falls back to the main
+	// resource
+	commitOnTestBranch(t, repo, "feat-a", "a.txt", true)
+	push("feat-a")
+	commitOnTestBranch(t, repo, "feat-b", "b.txt", true)
+	push("feat-b")
+
+	for slug, expected := range map[string]string{
+		"feat-a--resslug": "o:orgslug:p:projslug:r:resslug",
+		"feat-b--resslug": "o:orgslug:p:projslug:r:feat-a--resslug",
+	} {
+		resource, exists := server.Store.Resources["o:orgslug:p:projslug:r:"+slug]
+		if !exists {
+			t.Fatalf("Resource '%s' was not created", slug)
+		}
+		if resource.BaseId != expected {
+			t.Errorf("Got base '%s' for '%s', expected '%s'",
+				resource.BaseId, slug, expected)
+		}
+	}
+}
+
+func TestPushCommandChangedSince(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	for _, name := range []string{"aaa.json", "bbb.json", "bbb-el.json"} {
+		err := os.WriteFile(name, []byte(`{"hello": "world"}`), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := initTestGitRepo(t)
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := head.Hash().String()
+
+	server := txapitest.NewServer()
+	defer server.Close()
+	server.Store.AddProject("orgslug", "projslug", "en", "el")
+	api := server.Connection()
+
+	push := func(translation bool) {
+		cfg := getStandardConfig()
+		cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+		cfg.Local.Resources = append(cfg.Local.Resources, config.Resource{
+			OrganizationSlug: "orgslug",
+			ProjectSlug:      "projslug",
+			ResourceSlug:     "resslug2",
+			Type:             "KEYVALUEJSON",
+			SourceFile:       "bbb.json",
+			FileFilter:       "bbb-<lang>.json",
+		})
+		err := PushCommand(cfg, api, PushCommandArguments{
+			Source: true, Translation: translation, Force: true, Branch: "-1",
+			Workers: 1, Silent: true, ChangedSince: ref,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertResources := func(expected ...string) {
+		t.Helper()
+		var slugs []string
+		for _, resource := range server.Store.Resources {
+			slugs = append(slugs, resource.Slug)
+		}
+		sort.Strings(slugs)
+		if strings.Join(slugs, ",") != strings.Join(expected, ",") {
+			t.Errorf("Got resources %v, expected %v", slugs, expected)
+		}
+	}
+
+	push(true)
+	assertResources()
+
+	// Uncommitted change to a translation file
+	err = os.WriteFile("bbb-el.json", []byte(`{"hello": "kosme"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(false)
+	assertResources()
+	push(true)
+	assertResources("resslug2")
+
+	// Committed change to a source file
+	commitOnTestBranch(t, repo, "main", "aaa.json", false)
+	push(false)
+	assertResources("resslug", "resslug2")
+}
+
+func TestPushSummaryCreatedLanguages(t *testing.T) {
+	pipeline := pushPipeline{targetLanguages: map[string][]string{
+		"o:orgslug:p:projslug": {"fr", "el"},
+	}}
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	pipeline.printSummary(nil)
+
+	// Restore stdout
+	w.Close()
+	os.Stdout = oldStdout
+	out, _ := ioutil.ReadAll(r)
+	r.Close()
+
+	expected := "Created missing remote target languages: projslug: el, fr\n"
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("Got summary %q, expected it to contain %q", out, expected)
+	}
+}
+
+func TestPushReportsUnknownLanguagesWhenSilent(t *testing.T) {
+	afterTest := beforeTest(t, []string{"el", "backup"}, nil)
+	defer afterTest()
+	err := os.WriteFile("aaa.json", []byte(`{"hello": "world"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := txapitest.NewServer()
+	defer server.Close()
+	server.Store.AddProject("orgslug", "projslug", "en", "el")
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+
+	// Capture stderr
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	err = PushCommand(cfg, server.Connection(), PushCommandArguments{
+		Source: true, Translation: true, All: true, Force: true,
+		Branch: "-1", Workers: 1, Silent: true,
+	})
+
+	// Restore stderr
+	w.Close()
+	os.Stderr = oldStderr
+	out, _ := ioutil.ReadAll(r)
+	r.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "aaa-backup.json) is not a language code, skipping"
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("Got stderr %q, expected it to contain %q", out, expected)
+	}
+}
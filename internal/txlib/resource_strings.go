@@ -0,0 +1,321 @@
+package txlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+type StringsListArguments struct {
+	ResourceId string
+	Filter     txapi.ResourceStringsFilter
+	// Maximum number of strings to show, 0 for all
+	Limit int
+	Json  bool
+}
+
+/*
+StringsListCommand
+Print the source strings of a resource, as a table or as JSON
+*/
+func StringsListCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args StringsListArguments,
+	out io.Writer,
+) error {
+	resource, err := getRemoteResource(cfg, &api, args.ResourceId)
+	if err != nil {
+		return err
+	}
+	resourceStrings, err := txapi.GetResourceStrings(
+		&api, resource, args.Filter, jsonapi.ListOptions{MaxItems: args.Limit},
+	)
+	if err != nil {
+		return err
+	}
+
+	items := make([]resourceStringOutput, 0, len(resourceStrings))
+	for _, resourceString := range resourceStrings {
+		item, err := newResourceStringOutput(resourceString)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if args.Json {
+		return writeJson(out, items)
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No strings found")
+		return nil
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "KEY\tTEXT\tTAGS\tCHARACTER LIMIT")
+	for _, item := range items {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n",
+			item.Key,
+			truncateText(item.text(), 50),
+			strings.Join(item.Tags, ","),
+			item.characterLimit(),
+		)
+	}
+	return table.Flush()
+}
+
+type StringsShowArguments struct {
+	// Either the API id of the string, or a resource id and the string's key
+	StringId   string
+	ResourceId string
+	Key        string
+	Json       bool
+}
+
+/*
+StringsShowCommand
+Print everything about a single source string
+*/
+func StringsShowCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args StringsShowArguments,
+	out io.Writer,
+) error {
+	resourceString, err := findResourceString(
+		cfg, &api, args.StringId, args.ResourceId, args.Key,
+	)
+	if err != nil {
+		return err
+	}
+	item, err := newResourceStringOutput(resourceString)
+	if err != nil {
+		return err
+	}
+	if args.Json {
+		return writeJson(out, item)
+	}
+	item.print(out)
+	return nil
+}
+
+type StringsUpdateArguments struct {
+	StringId   string
+	ResourceId string
+	Key        string
+	Update     txapi.ResourceStringUpdate
+	// Applied on top of the string's current tags (or 'Update.Tags', if set)
+	AddTags    []string
+	RemoveTags []string
+	Json       bool
+}
+
+/*
+StringsUpdateCommand
+Change the metadata (tags, context, character limit, comments) of a single
+source string, without uploading the source file
+*/
+func StringsUpdateCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args StringsUpdateArguments,
+	out io.Writer,
+) error {
+	resourceString, err := findResourceString(
+		cfg, &api, args.StringId, args.ResourceId, args.Key,
+	)
+	if err != nil {
+		return err
+	}
+
+	update := args.Update
+	if len(args.AddTags) > 0 || len(args.RemoveTags) > 0 {
+		var tags []string
+		if update.Tags != nil {
+			tags = *update.Tags
+		} else {
+			var attributes txapi.ResourceStringAttributes
+			err = resourceString.MapAttributes(&attributes)
+			if err != nil {
+				return err
+			}
+			tags = attributes.Tags
+		}
+		tags = applyTagChanges(tags, args.AddTags, args.RemoveTags)
+		update.Tags = &tags
+	}
+
+	err = txapi.UpdateResourceString(resourceString, update)
+	if err != nil {
+		return err
+	}
+	item, err := newResourceStringOutput(resourceString)
+	if err != nil {
+		return err
+	}
+	if args.Json {
+		return writeJson(out, item)
+	}
+	item.print(out)
+	return nil
+}
+
+/*
+Find a source string either by its API id or by the resource it belongs to
+and its key
+*/
+func findResourceString(
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	stringId, resourceId, key string,
+) (*jsonapi.Resource, error) {
+	if stringId != "" {
+		resourceString, err := txapi.GetResourceString(api, stringId)
+		if err != nil {
+			return nil, err
+		}
+		if resourceString == nil {
+			return nil, fmt.Errorf("string '%s' does not exist", stringId)
+		}
+		return resourceString, nil
+	}
+
+	remoteResourceId, err := figureOutRemoteResourceId(resourceId, cfg)
+	if err != nil {
+		return nil, err
+	}
+	resource := &jsonapi.Resource{
+		API: api, Type: "resources", Id: remoteResourceId,
+	}
+	resourceStrings, err := txapi.GetResourceStrings(
+		api, resource, txapi.ResourceStringsFilter{Key: key},
+		jsonapi.ListOptions{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(resourceStrings) == 0 {
+		return nil, fmt.Errorf(
+			"string with key '%s' not found in resource '%s'", key, resourceId,
+		)
+	}
+	if len(resourceStrings) > 1 {
+		ids := make([]string, 0, len(resourceStrings))
+		for _, resourceString := range resourceStrings {
+			ids = append(ids, resourceString.Id)
+		}
+		return nil, fmt.Errorf(
+			"there are %d strings with key '%s' (with different contexts), "+
+				"please use one of their ids: %s",
+			len(ids), key, strings.Join(ids, ", "),
+		)
+	}
+	return resourceStrings[0], nil
+}
+
+func applyTagChanges(tags, add, remove []string) []string {
+	result := []string{}
+	for _, tag := range tags {
+		if !stringSliceContains(remove, tag) {
+			result = append(result, tag)
+		}
+	}
+	for _, tag := range add {
+		if !stringSliceContains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// How a source string is presented in JSON output
+type resourceStringOutput struct {
+	Id string `json:"id"`
+	txapi.ResourceStringAttributes
+}
+
+func newResourceStringOutput(
+	resourceString *jsonapi.Resource,
+) (resourceStringOutput, error) {
+	result := resourceStringOutput{Id: resourceString.Id}
+	err := resourceString.MapAttributes(&result.ResourceStringAttributes)
+	return result, err
+}
+
+// The text of the string; the plural forms one after the other if pluralized
+func (item resourceStringOutput) text() string {
+	if !item.Pluralized {
+		return item.Strings["other"]
+	}
+	forms := make([]string, 0, len(item.Strings))
+	for _, form := range sortedPluralForms(item.Strings) {
+		forms = append(forms, fmt.Sprintf("%s: %s", form, item.Strings[form]))
+	}
+	return strings.Join(forms, " | ")
+}
+
+func (item resourceStringOutput) characterLimit() string {
+	if item.CharacterLimit == nil {
+		return "-"
+	}
+	return fmt.Sprint(*item.CharacterLimit)
+}
+
+func (item resourceStringOutput) print(out io.Writer) {
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "Id:\t%s\n", item.Id)
+	fmt.Fprintf(table, "Key:\t%s\n", item.Key)
+	fmt.Fprintf(table, "Context:\t%s\n", item.Context)
+	if item.Pluralized {
+		for _, form := range sortedPluralForms(item.Strings) {
+			fmt.Fprintf(table, "Text (%s):\t%s\n", form, item.Strings[form])
+		}
+	} else {
+		fmt.Fprintf(table, "Text:\t%s\n", item.Strings["other"])
+	}
+	fmt.Fprintf(table, "Tags:\t%s\n", strings.Join(item.Tags, ", "))
+	fmt.Fprintf(table, "Character limit:\t%s\n", item.characterLimit())
+	fmt.Fprintf(table, "Developer comment:\t%s\n", item.DeveloperComment)
+	fmt.Fprintf(table, "Instructions:\t%s\n", item.Instructions)
+	fmt.Fprintf(table, "Occurrences:\t%s\n", item.Occurrences)
+	fmt.Fprintf(table, "Text modified:\t%s\n", item.StringsDatetimeModified)
+	fmt.Fprintf(table, "Metadata modified:\t%s\n", item.MetadataDatetimeModified)
+	_ = table.Flush()
+}
+
+// Plural forms in their natural order rather than alphabetically
+func sortedPluralForms(texts map[string]string) []string {
+	order := map[string]int{
+		"zero": 0, "one": 1, "two": 2, "few": 3, "many": 4, "other": 5,
+	}
+	forms := make([]string, 0, len(texts))
+	for form := range texts {
+		forms = append(forms, form)
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		return order[forms[i]] < order[forms[j]]
+	})
+	return forms
+}
+
+func truncateText(text string, length int) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+func writeJson(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,134 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/txapi"
+	"github.com/transifex/cli/pkg/txapitest"
+)
+
+func getStringsTestServer(t *testing.T) *txapitest.Server {
+	server := txapitest.NewServer()
+	t.Cleanup(server.Close)
+	project := server.Store.AddProject("orgslug", "projslug", "en", "el")
+	server.Store.AddResource(
+		project.Id, "resslug", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello world", "bye": "Goodbye"}`),
+	)
+	return server
+}
+
+func TestStringsListCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := StringsListCommand(cfg, server.Connection(), StringsListArguments{
+		ResourceId: "projslug.resslug",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "KEY") ||
+		!strings.Contains(lines[2], "Hello world") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = StringsListCommand(cfg, server.Connection(), StringsListArguments{
+		ResourceId: "o:orgslug:p:projslug:r:resslug",
+		Filter:     txapi.ResourceStringsFilter{Key: "bye"},
+		Json:       true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]interface{}
+	err = json.Unmarshal(out.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	if len(items) != 1 || items[0]["key"] != "bye" ||
+		!strings.HasPrefix(items[0]["id"].(string), "o:orgslug:p:projslug:r:resslug:s:") {
+		t.Errorf("Got items %v", items)
+	}
+
+	err = StringsListCommand(cfg, server.Connection(), StringsListArguments{
+		ResourceId: "projslug.missing",
+	}, &out)
+	if err == nil {
+		t.Error("Expected an error for a resource that is not in the config")
+	}
+}
+
+func TestStringsUpdateCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+
+	tags := []string{"ui", "old"}
+	limit := 20
+	comment := "Shown on the home page"
+	var out bytes.Buffer
+	err := StringsUpdateCommand(cfg, server.Connection(), StringsUpdateArguments{
+		ResourceId: "projslug.resslug",
+		Key:        "hello",
+		Update: txapi.ResourceStringUpdate{
+			Tags:             &tags,
+			CharacterLimit:   &limit,
+			DeveloperComment: &comment,
+		},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Shown on the home page") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = StringsUpdateCommand(cfg, server.Connection(), StringsUpdateArguments{
+		ResourceId: "projslug.resslug",
+		Key:        "hello",
+		AddTags:    []string{"new"},
+		RemoveTags: []string{"old"},
+		Json:       true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := server.Store.Resources["o:orgslug:p:projslug:r:resslug"].Strings["hello"]
+	if strings.Join(metadata.Tags, ",") != "ui,new" ||
+		metadata.CharacterLimit != 20 ||
+		metadata.DeveloperComment != "Shown on the home page" {
+		t.Errorf("Got metadata %+v", metadata)
+	}
+
+	var item map[string]interface{}
+	_ = json.Unmarshal(out.Bytes(), &item)
+	stringId, _ := item["id"].(string)
+	out.Reset()
+	err = StringsShowCommand(cfg, server.Connection(), StringsShowArguments{
+		StringId: stringId,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "ui, new") ||
+		!strings.Contains(out.String(), "Hello world") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	err = StringsUpdateCommand(cfg, server.Connection(), StringsUpdateArguments{
+		ResourceId: "projslug.resslug",
+		Key:        "missing",
+		AddTags:    []string{"new"},
+	}, &out)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Got error %v, expected string not found", err)
+	}
+}
@@ -0,0 +1,518 @@
+package txlib
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+type TranslationsListArguments struct {
+	ResourceId   string
+	LanguageCode string
+	Filter       txapi.ResourceTranslationsFilter
+	// Maximum number of translations to show, 0 for all
+	Limit int
+	Json  bool
+}
+
+/*
+TranslationsListCommand
+Print the translations of a resource in a language along with their review
+state, as a table or as JSON
+*/
+func TranslationsListCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args TranslationsListArguments,
+	out io.Writer,
+) error {
+	if args.LanguageCode == "" {
+		return errors.New("please provide a language")
+	}
+	resource, err := getRemoteResource(cfg, &api, args.ResourceId)
+	if err != nil {
+		return err
+	}
+	translations, err := txapi.GetResourceTranslations(
+		&api, resource, args.LanguageCode, args.Filter,
+		jsonapi.ListOptions{MaxItems: args.Limit},
+	)
+	if err != nil {
+		return err
+	}
+
+	items := make([]resourceTranslationOutput, 0, len(translations))
+	for _, translation := range translations {
+		item, err := newResourceTranslationOutput(translation)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if args.Json {
+		return writeJson(out, items)
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No translations found")
+		return nil
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "KEY\tSOURCE\tTRANSLATION\tREVIEWED\tPROOFREAD\tTRANSLATED AT")
+	for _, item := range items {
+		translatedAt := item.DatetimeTranslated
+		if translatedAt == "" {
+			translatedAt = "-"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			item.Key,
+			truncateText(joinPluralForms(item.Source), 30),
+			truncateText(joinPluralForms(item.Strings), 30),
+			yesNo(item.Reviewed),
+			yesNo(item.Proofread),
+			translatedAt,
+		)
+	}
+	return table.Flush()
+}
+
+type TranslationsUpdateArguments struct {
+	ResourceId string
+	// A CSV or JSON file with the changes, see 'translationPatch'
+	Path string
+	// Used for the entries of the file that don't specify a language
+	LanguageCode string
+	// Only report what would change
+	DryRun bool
+}
+
+/*
+A change to the translation of a single string, as read from the file passed
+to 'tx translations update'. Fields that are nil are left alone.
+
+In CSV files, the first row names the columns: 'key', 'language',
+'translation', 'reviewed' and 'proofread'. JSON files hold an array of objects
+with the same fields; they may also have a 'strings' object with the plural
+forms instead of 'translation'.
+*/
+type translationPatch struct {
+	Key         string            `json:"key"`
+	Language    string            `json:"language"`
+	Translation *string           `json:"translation"`
+	Strings     map[string]string `json:"strings"`
+	Reviewed    *bool             `json:"reviewed"`
+	Proofread   *bool             `json:"proofread"`
+	// Where the patch was found in the file, for error messages
+	position string
+}
+
+/*
+TranslationsUpdateCommand
+Apply the changes of a CSV or JSON file to the translations of a resource with
+as few bulk requests as possible. Every entry of the file is checked before
+anything is sent; if any of them is invalid, nothing is updated.
+*/
+func TranslationsUpdateCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args TranslationsUpdateArguments,
+	out io.Writer,
+) error {
+	patches, err := readTranslationPatches(args.Path, args.LanguageCode)
+	if err != nil {
+		return err
+	}
+	if len(patches) == 0 {
+		return fmt.Errorf("'%s' has no translations", args.Path)
+	}
+	resource, err := getRemoteResource(cfg, &api, args.ResourceId)
+	if err != nil {
+		return err
+	}
+
+	byLanguage := make(map[string][]translationPatch)
+	for _, patch := range patches {
+		byLanguage[patch.Language] = append(byLanguage[patch.Language], patch)
+	}
+	languageCodes := make([]string, 0, len(byLanguage))
+	for code := range byLanguage {
+		languageCodes = append(languageCodes, code)
+	}
+	sort.Strings(languageCodes)
+
+	var problems []string
+	changed := make(map[string][]*jsonapi.Resource)
+	unchanged := make(map[string]int)
+	for _, code := range languageCodes {
+		translations, err := txapi.GetResourceTranslations(
+			&api, resource, code, txapi.ResourceTranslationsFilter{},
+			jsonapi.ListOptions{},
+		)
+		if err != nil {
+			return fmt.Errorf("could not fetch the '%s' translations: %w", code, err)
+		}
+		byKey := make(map[string][]*jsonapi.Resource)
+		for _, translation := range translations {
+			item, err := newResourceTranslationOutput(translation)
+			if err != nil {
+				return err
+			}
+			byKey[item.Key] = append(byKey[item.Key], translation)
+		}
+
+		seen := make(map[string]string)
+		for _, patch := range byLanguage[code] {
+			matches := byKey[patch.Key]
+			if len(matches) != 1 {
+				if len(matches) == 0 {
+					problems = append(problems, fmt.Sprintf(
+						"%s: string with key '%s' not found",
+						patch.position, patch.Key,
+					))
+				} else {
+					problems = append(problems, fmt.Sprintf(
+						"%s: there are %d strings with key '%s' "+
+							"(with different contexts)",
+						patch.position, len(matches), patch.Key,
+					))
+				}
+				continue
+			}
+			if previous, exists := seen[patch.Key]; exists {
+				problems = append(problems, fmt.Sprintf(
+					"%s: '%s' (%s) was already changed at %s",
+					patch.position, patch.Key, code, previous,
+				))
+				continue
+			}
+			seen[patch.Key] = patch.position
+
+			translation := matches[0]
+			isChanged, err := applyTranslationPatch(translation, patch)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf(
+					"%s: %s", patch.position, err,
+				))
+			} else if isChanged {
+				changed[code] = append(changed[code], translation)
+			} else {
+				unchanged[code]++
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf(
+			"nothing was updated, '%s' has problems:\n%s",
+			args.Path, strings.Join(problems, "\n"),
+		)
+	}
+
+	// Languages are sent one after the other, so a failure can leave the
+	// ones before it updated
+	var updated []string
+	for _, code := range languageCodes {
+		if !args.DryRun && len(changed[code]) > 0 {
+			err := txapi.UpdateResourceTranslations(&api, changed[code])
+			if err != nil {
+				if len(updated) == 0 {
+					return fmt.Errorf(
+						"could not update the '%s' translations, no language "+
+							"was updated: %w",
+						code, err,
+					)
+				}
+				return fmt.Errorf(
+					"could not update the '%s' translations, these languages "+
+						"were already updated: %s: %w",
+					code, strings.Join(updated, ", "), err,
+				)
+			}
+			updated = append(updated, code)
+		}
+		verb := "updated"
+		if args.DryRun {
+			verb = "would be updated"
+		}
+		fmt.Fprintf(out, "%s: %d %s, %d unchanged\n",
+			code, len(changed[code]), verb, unchanged[code])
+	}
+	return nil
+}
+
+/*
+Apply a patch to the attributes of a translation, returning whether anything
+changed
+*/
+func applyTranslationPatch(
+	translation *jsonapi.Resource, patch translationPatch,
+) (bool, error) {
+	item, err := newResourceTranslationOutput(translation)
+	if err != nil {
+		return false, err
+	}
+	texts := item.Strings
+	if patch.Strings != nil {
+		texts = patch.Strings
+	} else if patch.Translation != nil {
+		if item.Pluralized {
+			return false, fmt.Errorf(
+				"'%s' is pluralized, please provide its plural forms "+
+					"with 'strings' in a JSON file", patch.Key,
+			)
+		}
+		texts = map[string]string{"other": *patch.Translation}
+	}
+	if _, exists := texts["other"]; !exists && texts != nil {
+		return false, fmt.Errorf(
+			"the translation of '%s' needs the 'other' plural form", patch.Key,
+		)
+	}
+	reviewed := item.Reviewed
+	if patch.Reviewed != nil {
+		reviewed = *patch.Reviewed
+	}
+	proofread := item.Proofread
+	if patch.Proofread != nil {
+		proofread = *patch.Proofread
+	}
+	if texts == nil && (reviewed || proofread) {
+		return false, fmt.Errorf(
+			"'%s' cannot be reviewed or proofread without a translation",
+			patch.Key,
+		)
+	}
+	if proofread && !reviewed {
+		return false, fmt.Errorf(
+			"'%s' cannot be proofread without being reviewed", patch.Key,
+		)
+	}
+
+	if sameTexts(texts, item.Strings) && reviewed == item.Reviewed &&
+		proofread == item.Proofread {
+		return false, nil
+	}
+	translation.Attributes["strings"] = texts
+	translation.Attributes["reviewed"] = reviewed
+	translation.Attributes["proofread"] = proofread
+	return true, nil
+}
+
+func sameTexts(a, b map[string]string) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+	for form, text := range a {
+		if b[form] != text {
+			return false
+		}
+	}
+	return true
+}
+
+func readTranslationPatches(
+	path, defaultLanguageCode string,
+) ([]translationPatch, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patches []translationPatch
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		patches, err = readCsvTranslationPatches(file)
+	case ".json":
+		patches, err = readJsonTranslationPatches(file)
+	default:
+		return nil, fmt.Errorf(
+			"don't know how to read '%s', please use a .csv or .json file", path,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read '%s': %w", path, err)
+	}
+
+	var problems []string
+	for i := range patches {
+		patch := &patches[i]
+		if patch.Language == "" {
+			patch.Language = defaultLanguageCode
+		}
+		if patch.Key == "" {
+			problems = append(problems, fmt.Sprintf(
+				"%s: the key is missing", patch.position,
+			))
+		}
+		if patch.Language == "" {
+			problems = append(problems, fmt.Sprintf(
+				"%s: the language is missing", patch.position,
+			))
+		}
+		if patch.Translation != nil && patch.Strings != nil {
+			problems = append(problems, fmt.Sprintf(
+				"%s: please use either 'translation' or 'strings', not both",
+				patch.position,
+			))
+		}
+		if patch.Translation == nil && patch.Strings == nil &&
+			patch.Reviewed == nil && patch.Proofread == nil {
+			problems = append(problems, fmt.Sprintf(
+				"%s: nothing to change", patch.position,
+			))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf(
+			"nothing was updated, '%s' has problems:\n%s",
+			path, strings.Join(problems, "\n"),
+		)
+	}
+	return patches, nil
+}
+
+func readCsvTranslationPatches(file io.Reader) ([]translationPatch, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for index, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "key", "language", "translation", "reviewed", "proofread":
+			columns[name] = index
+		default:
+			return nil, fmt.Errorf("unknown column '%s'", name)
+		}
+	}
+
+	var patches []translationPatch
+	for number, row := range rows[1:] {
+		patch := translationPatch{position: fmt.Sprintf("row %d", number+2)}
+		cell := func(name string) string {
+			index, exists := columns[name]
+			if !exists || index >= len(row) {
+				return ""
+			}
+			return row[index]
+		}
+		patch.Key = cell("key")
+		patch.Language = strings.TrimSpace(cell("language"))
+		// An empty cell leaves the translation as it is
+		if text := cell("translation"); text != "" {
+			patch.Translation = &text
+		}
+		for name, target := range map[string]**bool{
+			"reviewed": &patch.Reviewed, "proofread": &patch.Proofread,
+		} {
+			value := strings.TrimSpace(cell(name))
+			if value == "" {
+				continue
+			}
+			parsed, err := parseYesNo(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid '%s' value '%s'",
+					patch.position, name, value)
+			}
+			*target = &parsed
+		}
+		patches = append(patches, patch)
+	}
+	return patches, nil
+}
+
+func readJsonTranslationPatches(file io.Reader) ([]translationPatch, error) {
+	var patches []translationPatch
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&patches)
+	if err != nil {
+		return nil, err
+	}
+	for i := range patches {
+		patches[i].position = fmt.Sprintf("entry %d", i+1)
+	}
+	return patches, nil
+}
+
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// How a translation is presented in JSON output
+type resourceTranslationOutput struct {
+	Id string `json:"id"`
+	// The source string's
+	Key        string            `json:"key"`
+	Source     map[string]string `json:"source"`
+	Pluralized bool              `json:"pluralized"`
+	txapi.ResourceTranslationAttributes
+}
+
+func newResourceTranslationOutput(
+	translation *jsonapi.Resource,
+) (resourceTranslationOutput, error) {
+	result := resourceTranslationOutput{Id: translation.Id}
+	err := translation.MapAttributes(&result.ResourceTranslationAttributes)
+	if err != nil {
+		return result, err
+	}
+	relationship, exists := translation.Relationships["resource_string"]
+	if exists && relationship.Fetched && relationship.DataSingular != nil {
+		var source txapi.ResourceStringAttributes
+		err = relationship.DataSingular.MapAttributes(&source)
+		if err != nil {
+			return result, err
+		}
+		result.Key = source.Key
+		result.Source = source.Strings
+		result.Pluralized = source.Pluralized
+	}
+	return result, nil
+}
+
+// The plural forms one after the other, or the text if not pluralized
+func joinPluralForms(texts map[string]string) string {
+	if texts == nil {
+		return "-"
+	}
+	if len(texts) == 1 {
+		return texts["other"]
+	}
+	forms := make([]string, 0, len(texts))
+	for _, form := range sortedPluralForms(texts) {
+		forms = append(forms, fmt.Sprintf("%s: %s", form, texts[form]))
+	}
+	return strings.Join(forms, " | ")
+}
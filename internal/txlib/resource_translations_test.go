@@ -0,0 +1,192 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/txapi"
+	"github.com/transifex/cli/pkg/txapitest"
+)
+
+func TestTranslationsListCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	err := server.Store.SetTranslation(
+		"o:orgslug:p:projslug:r:resslug", "el", []byte(`{"hello": "Γεια σου κόσμε"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err = TranslationsListCommand(cfg, server.Connection(), TranslationsListArguments{
+		ResourceId:   "projslug.resslug",
+		LanguageCode: "el",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "KEY") ||
+		!strings.Contains(lines[1], "Goodbye") ||
+		!strings.Contains(lines[2], "Γεια σου κόσμε") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	untranslated := false
+	out.Reset()
+	err = TranslationsListCommand(cfg, server.Connection(), TranslationsListArguments{
+		ResourceId:   "projslug.resslug",
+		LanguageCode: "el",
+		Filter:       txapi.ResourceTranslationsFilter{Translated: &untranslated},
+		Json:         true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]interface{}
+	err = json.Unmarshal(out.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	if len(items) != 1 || items[0]["key"] != "bye" || items[0]["strings"] != nil {
+		t.Errorf("Got items %v", items)
+	}
+
+	err = TranslationsListCommand(cfg, server.Connection(), TranslationsListArguments{
+		ResourceId: "projslug.resslug",
+	}, &out)
+	if err == nil {
+		t.Error("Expected an error without a language")
+	}
+}
+
+func TestTranslationsUpdateCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "patch.csv")
+	err := os.WriteFile(csvPath, []byte(
+		"key,translation,reviewed\n"+
+			"hello,Γεια σου κόσμε,yes\n"+
+			"bye,Αντίο,\n",
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = TranslationsUpdateCommand(cfg, server.Connection(), TranslationsUpdateArguments{
+		ResourceId:   "projslug.resslug",
+		Path:         csvPath,
+		LanguageCode: "el",
+		DryRun:       true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "el: 2 would be updated, 0 unchanged\n" {
+		t.Errorf("Got output %q", out.String())
+	}
+	if len(server.Store.Resources["o:orgslug:p:projslug:r:resslug"].Translations) != 0 {
+		t.Error("Dry run changed the translations")
+	}
+
+	out.Reset()
+	err = TranslationsUpdateCommand(cfg, server.Connection(), TranslationsUpdateArguments{
+		ResourceId:   "projslug.resslug",
+		Path:         csvPath,
+		LanguageCode: "el",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translation := server.Store.Resources["o:orgslug:p:projslug:r:resslug"].Translations["el"]
+	if translation == nil || translation.Strings["hello"].Text != "Γεια σου κόσμε" ||
+		!translation.Strings["hello"].Reviewed ||
+		translation.Strings["bye"].Text != "Αντίο" {
+		t.Fatalf("Got translation %+v", translation)
+	}
+
+	jsonPath := filepath.Join(dir, "patch.json")
+	err = os.WriteFile(jsonPath, []byte(`[
+		{"key": "hello", "language": "el", "reviewed": true},
+		{"key": "bye", "language": "el", "proofread": true},
+		{"key": "missing", "language": "el", "translation": "Τίποτα"}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = TranslationsUpdateCommand(cfg, server.Connection(), TranslationsUpdateArguments{
+		ResourceId: "projslug.resslug",
+		Path:       jsonPath,
+	}, &out)
+	if err == nil ||
+		!strings.Contains(err.Error(), "entry 2: 'bye' cannot be proofread") ||
+		!strings.Contains(err.Error(), "entry 3: string with key 'missing' not found") {
+		t.Errorf("Got error %v", err)
+	}
+	if translation.Strings["bye"].Proofread {
+		t.Error("Translations were updated despite the errors")
+	}
+}
+
+func TestTranslationsUpdateCommandPartialFailure(t *testing.T) {
+	server := txapitest.NewServer()
+	t.Cleanup(server.Close)
+	project := server.Store.AddProject("orgslug", "projslug", "en", "el", "fr")
+	server.Store.AddResource(
+		project.Id, "resslug", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello world", "bye": "Goodbye"}`),
+	)
+	// Let the first language through and reject the second one
+	patches := 0
+	failing := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PATCH" {
+				patches++
+				if patches > 1 {
+					w.WriteHeader(500)
+					return
+				}
+			}
+			server.ServeHTTP(w, r)
+		},
+	))
+	t.Cleanup(failing.Close)
+	api := server.Connection()
+	api.Host = failing.URL
+
+	csvPath := filepath.Join(t.TempDir(), "patch.csv")
+	err := os.WriteFile(csvPath, []byte(
+		"key,language,translation\n"+
+			"hello,el,Γεια σου κόσμε\n"+
+			"hello,fr,Bonjour le monde\n",
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = TranslationsUpdateCommand(getStandardConfig(), api, TranslationsUpdateArguments{
+		ResourceId: "projslug.resslug",
+		Path:       csvPath,
+	}, &out)
+	if err == nil || !strings.Contains(
+		err.Error(),
+		"could not update the 'fr' translations, these languages were "+
+			"already updated: el",
+	) {
+		t.Errorf("Got error %v", err)
+	}
+	if out.String() != "el: 1 updated, 0 unchanged\n" {
+		t.Errorf("Got output %q", out.String())
+	}
+}
@@ -0,0 +1,273 @@
+package txlib
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+type ResourcesListArguments struct {
+	// See figureOutRemoteProjectId
+	Project          string
+	OrganizationSlug string
+	Json             bool
+}
+
+// How a remote resource is presented in JSON output
+type resourceOutput struct {
+	Id               string   `json:"id"`
+	Slug             string   `json:"slug"`
+	Name             string   `json:"name"`
+	I18nType         string   `json:"i18n_type"`
+	StringCount      int      `json:"string_count"`
+	WordCount        int      `json:"word_count"`
+	Categories       []string `json:"categories"`
+	Priority         string   `json:"priority"`
+	DatetimeModified string   `json:"datetime_modified"`
+	// The id of the resource in the local configuration, empty if it's not
+	// there
+	ConfigId string `json:"config_id"`
+	InConfig bool   `json:"in_config"`
+}
+
+/*
+ResourcesListCommand
+Print the resources of a project, as a table or as JSON
+*/
+func ResourcesListCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args ResourcesListArguments,
+	out io.Writer,
+) error {
+	project, err := getRemoteProject(
+		cfg, &api, args.Project, args.OrganizationSlug,
+	)
+	if err != nil {
+		return err
+	}
+	resources, err := txapi.GetResources(&api, project)
+	if err != nil {
+		return err
+	}
+
+	items := make([]resourceOutput, 0, len(resources))
+	for _, resource := range resources {
+		var attributes txapi.ResourceAttributes
+		err := resource.MapAttributes(&attributes)
+		if err != nil {
+			return err
+		}
+		item := resourceOutput{
+			Id:               resource.Id,
+			Slug:             attributes.Slug,
+			Name:             attributes.Name,
+			StringCount:      attributes.StringCount,
+			WordCount:        attributes.WordCount,
+			Categories:       attributes.Categories,
+			Priority:         attributes.Priority,
+			DatetimeModified: attributes.DatetimeModified,
+		}
+		if item.Categories == nil {
+			item.Categories = []string{}
+		}
+		relationship, exists := resource.Relationships["i18n_format"]
+		if exists && relationship.DataSingular != nil {
+			item.I18nType = relationship.DataSingular.Id
+		}
+		if cfgResource := findConfigResource(cfg, resource.Id); cfgResource != nil {
+			item.InConfig = true
+			item.ConfigId = fmt.Sprintf(
+				"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+			)
+		}
+		items = append(items, item)
+	}
+	if args.Json {
+		return writeJson(out, items)
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No resources found")
+		return nil
+	}
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "SLUG\tNAME\tI18N TYPE\tSTRINGS\tMODIFIED\tIN CONFIG")
+	for _, item := range items {
+		inConfig := "-"
+		if item.InConfig {
+			inConfig = item.ConfigId
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			item.Slug,
+			truncateText(item.Name, 40),
+			item.I18nType,
+			item.StringCount,
+			item.DatetimeModified,
+			inConfig,
+		)
+	}
+	return table.Flush()
+}
+
+// Find the resource of the local configuration with the given API id
+func findConfigResource(cfg *config.Config, resourceId string) *config.Resource {
+	if cfg == nil || cfg.Local == nil {
+		return nil
+	}
+	for i := range cfg.Local.Resources {
+		cfgResource := &cfg.Local.Resources[i]
+		if strings.EqualFold(cfgResource.GetAPv3Id(), resourceId) {
+			return cfgResource
+		}
+	}
+	return nil
+}
+
+type ResourcesSyncMetaArguments struct {
+	// Resources of the local configuration, as in 'tx push'; empty for all
+	ResourceIds []string
+	Branch      string
+	// Only print what would change
+	DryRun bool
+}
+
+// A difference between the local and the remote metadata of a resource
+type resourceMetaChange struct {
+	field    string
+	from, to string
+}
+
+/*
+ResourcesSyncMetaCommand
+Apply the metadata of the local configuration's resources (name, categories,
+priority and whether they accept translations) to the resources on Transifex.
+Only the metadata that is set in the local configuration is compared.
+*/
+func ResourcesSyncMetaCommand(
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args ResourcesSyncMetaArguments,
+	out io.Writer,
+) error {
+	args.Branch = figureOutBranch(args.Branch)
+	cfgResources, err := figureOutResources(args.ResourceIds, cfg)
+	if err != nil {
+		return err
+	}
+	applyBranchToResources(cfgResources, args.Branch)
+	sort.Slice(cfgResources, func(i, j int) bool {
+		return cfgResources[i].GetAPv3Id() < cfgResources[j].GetAPv3Id()
+	})
+
+	updated := 0
+	for _, cfgResource := range cfgResources {
+		name := fmt.Sprintf(
+			"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+		)
+		resource, err := txapi.GetResourceById(&api, cfgResource.GetAPv3Id())
+		if err != nil {
+			return err
+		}
+		if resource == nil {
+			fmt.Fprintf(out, "%s: not found on Transifex, skipping\n", name)
+			continue
+		}
+		fields, changes, err := diffResourceMeta(cfgResource, resource)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Fprintf(out, "%s: up to date\n", name)
+			continue
+		}
+		fmt.Fprintf(out, "%s:\n", name)
+		for _, change := range changes {
+			fmt.Fprintf(out, "  %s: '%s' -> '%s'\n",
+				change.field, change.from, change.to)
+		}
+		if args.DryRun {
+			continue
+		}
+		err = resource.Save(fields)
+		if err != nil {
+			return fmt.Errorf("could not update '%s': %w", name, err)
+		}
+		updated++
+	}
+
+	if args.DryRun {
+		fmt.Fprintln(out, "Dry run, nothing was updated")
+	} else {
+		fmt.Fprintf(out, "Updated %d resource(s)\n", updated)
+	}
+	return nil
+}
+
+/*
+Compare the metadata of a resource in the local configuration with the remote
+one. The remote resource's attributes are changed to match the local ones;
+the names of the fields that changed are returned, along with a description of
+each change.
+*/
+func diffResourceMeta(
+	cfgResource *config.Resource, resource *jsonapi.Resource,
+) ([]string, []resourceMetaChange, error) {
+	var attributes txapi.ResourceAttributes
+	err := resource.MapAttributes(&attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fields []string
+	var changes []resourceMetaChange
+	if cfgResource.ResourceName != "" &&
+		cfgResource.ResourceName != attributes.Name {
+		fields = append(fields, "name")
+		changes = append(changes, resourceMetaChange{
+			"name", attributes.Name, cfgResource.ResourceName,
+		})
+		resource.Attributes["name"] = cfgResource.ResourceName
+	}
+	if len(cfgResource.Categories) != 0 {
+		local := append([]string{}, cfgResource.Categories...)
+		remote := append([]string{}, attributes.Categories...)
+		sort.Strings(local)
+		sort.Strings(remote)
+		if strings.Join(local, ",") != strings.Join(remote, ",") {
+			fields = append(fields, "categories")
+			changes = append(changes, resourceMetaChange{
+				"categories",
+				strings.Join(attributes.Categories, ", "),
+				strings.Join(cfgResource.Categories, ", "),
+			})
+			resource.Attributes["categories"] = cfgResource.Categories
+		}
+	}
+	if cfgResource.Priority != "" &&
+		cfgResource.Priority != attributes.Priority {
+		fields = append(fields, "priority")
+		changes = append(changes, resourceMetaChange{
+			"priority", attributes.Priority, cfgResource.Priority,
+		})
+		resource.Attributes["priority"] = cfgResource.Priority
+	}
+	if cfgResource.AcceptTranslations != nil &&
+		*cfgResource.AcceptTranslations != attributes.AcceptTranslation {
+		fields = append(fields, "accept_translations")
+		changes = append(changes, resourceMetaChange{
+			"accept_translations",
+			fmt.Sprint(attributes.AcceptTranslation),
+			fmt.Sprint(*cfgResource.AcceptTranslations),
+		})
+		resource.Attributes["accept_translations"] =
+			*cfgResource.AcceptTranslations
+	}
+	return fields, changes, nil
+}
@@ -0,0 +1,135 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResourcesListCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	server.Store.AddResource(
+		"o:orgslug:p:projslug", "remote", "PO", []byte("msgid \"a\"\n"),
+	)
+	cfg := getStandardConfig()
+
+	var out bytes.Buffer
+	err := ResourcesListCommand(cfg, server.Connection(), ResourcesListArguments{
+		Project: "projslug",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "SLUG") ||
+		!strings.Contains(lines[2], "KEYVALUEJSON") ||
+		!strings.Contains(lines[2], "projslug.resslug") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = ResourcesListCommand(cfg, server.Connection(), ResourcesListArguments{
+		Project: "https://app.transifex.com/orgslug/projslug/dashboard/",
+		Json:    true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []resourceOutput
+	err = json.Unmarshal(out.Bytes(), &items)
+	if err != nil {
+		t.Fatalf("Invalid JSON output %s: %s", out.String(), err)
+	}
+	if len(items) != 2 || items[0].Slug != "remote" || items[0].InConfig ||
+		items[1].StringCount != 2 || items[1].ConfigId != "projslug.resslug" {
+		t.Errorf("Got resources %+v", items)
+	}
+
+	err = ResourcesListCommand(cfg, server.Connection(), ResourcesListArguments{
+		Project: "unknown",
+	}, &out)
+	if err == nil || !strings.Contains(err.Error(), "provide its organization") {
+		t.Errorf("Got error %v", err)
+	}
+	err = ResourcesListCommand(cfg, server.Connection(), ResourcesListArguments{
+		Project: "unknown", OrganizationSlug: "orgslug",
+	}, &out)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Got error %v", err)
+	}
+}
+
+func TestResourcesSyncMetaCommand(t *testing.T) {
+	server := getStringsTestServer(t)
+	cfg := getStandardConfig()
+	acceptTranslations := false
+	cfgResource := &cfg.Local.Resources[0]
+	cfgResource.ResourceName = "New name"
+	cfgResource.Categories = []string{"frontend", "checkout"}
+	cfgResource.Priority = "urgent"
+	cfgResource.AcceptTranslations = &acceptTranslations
+
+	var out bytes.Buffer
+	err := ResourcesSyncMetaCommand(cfg, server.Connection(), ResourcesSyncMetaArguments{
+		Branch: "-1", DryRun: true,
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := server.Store.Resources["o:orgslug:p:projslug:r:resslug"]
+	if resource.Name == "New name" || resource.Priority == "urgent" {
+		t.Errorf("Dry run changed the resource: %+v", resource)
+	}
+	for _, expected := range []string{
+		"projslug.resslug:",
+		"name: 'resslug' -> 'New name'",
+		"categories: '' -> 'frontend, checkout'",
+		"accept_translations: 'true' -> 'false'",
+		"Dry run",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected '%s' in output:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	err = ResourcesSyncMetaCommand(cfg, server.Connection(), ResourcesSyncMetaArguments{
+		Branch: "-1",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Name != "New name" || resource.Priority != "urgent" ||
+		strings.Join(resource.Categories, ",") != "frontend,checkout" ||
+		!resource.NotAcceptingTranslations {
+		t.Errorf("Resource was not updated: %+v", resource)
+	}
+	if !strings.Contains(out.String(), "Updated 1 resource(s)") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	// Categories in a different order are not a change
+	cfgResource.Categories = []string{"checkout", "frontend"}
+	out.Reset()
+	err = ResourcesSyncMetaCommand(cfg, server.Connection(), ResourcesSyncMetaArguments{
+		ResourceIds: []string{"projslug.resslug"}, Branch: "-1",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "projslug.resslug: up to date") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = ResourcesSyncMetaCommand(cfg, server.Connection(), ResourcesSyncMetaArguments{
+		Branch: "feature",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "feature--resslug: not found") {
+		t.Errorf("Got output:\n%s", out.String())
+	}
+}
@@ -59,7 +59,12 @@ func StatusCommand(
 			i+1,
 			cfgResourcesLen,
 		)
-		localLanguages := searchFileFilter(".", cfgResource.FileFilter)
+		localLanguages, err := searchResourceFiles(
+			cfg, &cfgResource, ".", cfgResource.FileFilter,
+		)
+		if err != nil {
+			return err
+		}
 		overrides := cfgResource.Overrides
 		if len(overrides) > 0 {
 			for langOverride := range overrides {
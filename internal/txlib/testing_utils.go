@@ -8,9 +8,15 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/cassette"
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -45,6 +51,9 @@ var (
 	translationDownloadUrl  = fmt.Sprintf("%s/download_1", translationDownloadsUrl)
 	sourceDownloadsUrl      = "/resource_strings_async_downloads"
 	sourceDownloadUrl       = fmt.Sprintf("%s/download_1", sourceDownloadsUrl)
+
+	// txapi.GetResourceById includes the project
+	resourceUrlWithProject = fmt.Sprintf("%s?include=project", resourceUrl)
 )
 
 func beforeTest(
@@ -113,6 +122,51 @@ func beforeTest(
 	}
 }
 
+/*
+Turn the current directory into a git repository with a commit of all its
+files, on branch 'main'. 'branches' are created pointing to the same commit;
+names like 'origin/feature' become remote-tracking branches.
+*/
+func initTestGitRepo(t *testing.T, branches ...string) *git.Repository {
+	repo, err := git.PlainInit(".", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.Storer.SetReference(plumbing.NewSymbolicReference(
+		plumbing.HEAD, plumbing.NewBranchReferenceName("main"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = worktree.AddGlob(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name: "Test", Email: "test@example.com", When: time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, branch := range branches {
+		name := plumbing.NewBranchReferenceName(branch)
+		if parts := strings.SplitN(branch, "/", 2); len(parts) == 2 {
+			name = plumbing.NewRemoteReferenceName(parts[0], parts[1])
+		}
+		err = repo.Storer.SetReference(plumbing.NewHashReference(name, hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
 func testSimpleGet(t *testing.T, mockData jsonapi.MockData, path string) {
 	endpoint := mockData[path]
 	if endpoint.Count != 1 {
@@ -125,6 +179,13 @@ func testSimpleGet(t *testing.T, mockData jsonapi.MockData, path string) {
 	}
 }
 
+func testNotRequested(t *testing.T, mockData jsonapi.MockData, path string) {
+	endpoint := mockData[path]
+	if endpoint.Count != 0 {
+		t.Errorf("Got %d requests to '%s', expected none", endpoint.Count, path)
+	}
+}
+
 func testMultipleRequests(t *testing.T, mockData jsonapi.MockData, path string, methods []string, payloads []string) {
 	for i, method := range methods {
 		endpoint := mockData[path]
@@ -196,6 +257,27 @@ func getStandardConfig() *config.Config {
 	}
 }
 
+/*
+Return a connection that replays the cassette in 'testdata/<name>.json'
+instead of hitting the network. Call before 'beforeTest' since that changes
+the working directory.
+*/
+func getCassetteConnection(
+	t *testing.T, name string,
+) (jsonapi.Connection, *cassette.Replayer) {
+	recorded, err := cassette.Load(fmt.Sprintf("testdata/%s.json", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := cassette.NewReplayer(recorded)
+	api := jsonapi.Connection{
+		Host:   "https://rest.api.transifex.com",
+		Token:  "token",
+		Client: http.Client{Transport: replayer},
+	}
+	return api, replayer
+}
+
 func getNewTestServer(output string) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
@@ -204,14 +286,37 @@ func getNewTestServer(output string) *httptest.Server {
 	))
 }
 
-func getResourceEndpoint() *jsonapi.MockEndpoint {
-	return jsonapi.GetMockTextResponse(
-		`{"data": {"type": "resources",
-							 "id": "o:orgslug:p:projslug:r:resslug",
-							 "attributes": {"slug": "resslug"},
-							 "relationships": {"project": {"data": {"type": "projects",
-																											"id": "o:orgslug:p:projslug"}}}}}`,
-	)
+/*
+The response to 'resourceUrlWithProject', which comes with the project so that
+it doesn't have to be fetched separately
+*/
+func getResourceWithProjectEndpoint() *jsonapi.MockEndpoint {
+	return jsonapi.GetMockTextResponse(fmt.Sprintf(`{
+		"data": {"type": "resources",
+		         "id": "o:orgslug:p:projslug:r:resslug",
+		         "attributes": {"slug": "resslug"},
+		         "relationships": {"project": {"data": {"type": "projects",
+		                                                "id": "o:orgslug:p:projslug"}}}},
+		"included": [%s]
+	}`, getIncludedProject()))
+}
+
+// The project of getProjectEndpoint, as found in the 'included' list of
+// responses
+func getIncludedProject() string {
+	return `{
+		"type": "projects",
+		"id": "o:orgslug:p:projslug",
+		"attributes": {"slug": "projslug"},
+		"relationships": {
+			"languages": {"links": {
+				"self": "/projects/o:orgslug:p:projslug/relationships/languages",
+				"related": "/projects/o:orgslug:p:projslug/languages"
+			}},
+			"source_language": {"data": {"type": "languages", "id": "l:en"},
+			                    "links": {"related": "/languages/l:en"}}
+		}
+	}`
 }
 
 func getProjectEndpoint() *jsonapi.MockEndpoint {
@@ -3,15 +3,18 @@ package txlib
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
-	"github.com/mattn/go-isatty"
+	"github.com/manifoldco/promptui"
 	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+	"github.com/transifex/cli/pkg/worker_pool"
 	"golang.org/x/term"
 )
 
@@ -69,6 +72,129 @@ func figureOutResources(
 	return result, nil
 }
 
+/*
+Return the API id of a resource given either as '<project>.<resource>', as it
+appears in the local configuration, or directly as an API id
+('o:<organization>:p:<project>:r:<resource>')
+*/
+func figureOutRemoteResourceId(resourceId string, cfg *config.Config) (string, error) {
+	if strings.HasPrefix(resourceId, "o:") {
+		return resourceId, nil
+	}
+	if cfg == nil || cfg.Local == nil {
+		return "", fmt.Errorf(
+			"could not find resource '%s' in local configuration", resourceId,
+		)
+	}
+	cfgResources, err := figureOutResources([]string{resourceId}, cfg)
+	if err != nil {
+		return "", err
+	}
+	if len(cfgResources) > 1 {
+		return "", fmt.Errorf(
+			"'%s' matches more than one resource, please be more specific",
+			resourceId,
+		)
+	}
+	return cfgResources[0].GetAPv3Id(), nil
+}
+
+/*
+Fetch a resource given either by its id in the local configuration or by its
+API id, like figureOutRemoteResourceId
+*/
+func getRemoteResource(
+	cfg *config.Config, api *jsonapi.Connection, resourceId string,
+) (*jsonapi.Resource, error) {
+	remoteResourceId, err := figureOutRemoteResourceId(resourceId, cfg)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := txapi.GetResourceById(api, remoteResourceId)
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("resource '%s' does not exist", resourceId)
+	}
+	return resource, nil
+}
+
+/*
+Return the API id of a project given as its API id ('o:<org>:p:<project>'), its
+URL on Transifex, or its slug. A slug is looked up in 'organizationSlug' if
+set, otherwise in the resources of the local configuration.
+*/
+func figureOutRemoteProjectId(
+	project, organizationSlug string, cfg *config.Config,
+) (string, error) {
+	if strings.HasPrefix(project, "o:") {
+		return project, nil
+	}
+	if strings.Contains(project, "://") {
+		parsed, err := url.Parse(project)
+		if err != nil {
+			return "", fmt.Errorf("invalid project URL '%s'", project)
+		}
+		// "/org/proj/whatever..." => ["", "org", "proj", whatever...]
+		parts := strings.Split(parsed.Path, "/")
+		if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+			return "", fmt.Errorf("invalid project URL '%s'", project)
+		}
+		return fmt.Sprintf("o:%s:p:%s", parts[1], parts[2]), nil
+	}
+	if organizationSlug != "" {
+		return fmt.Sprintf("o:%s:p:%s", organizationSlug, project), nil
+	}
+
+	organizationSlugs := []string{}
+	if cfg != nil && cfg.Local != nil {
+		for _, cfgResource := range cfg.FindResourcesByProject(project) {
+			if !stringSliceContains(organizationSlugs, cfgResource.OrganizationSlug) {
+				organizationSlugs = append(
+					organizationSlugs, cfgResource.OrganizationSlug,
+				)
+			}
+		}
+	}
+	if len(organizationSlugs) == 0 {
+		return "", fmt.Errorf(
+			"could not find project '%s' in local configuration, please "+
+				"provide its organization", project,
+		)
+	}
+	if len(organizationSlugs) > 1 {
+		return "", fmt.Errorf(
+			"project '%s' exists in more than one organization (%s), please "+
+				"provide its organization",
+			project, strings.Join(organizationSlugs, ", "),
+		)
+	}
+	return fmt.Sprintf("o:%s:p:%s", organizationSlugs[0], project), nil
+}
+
+/*
+Fetch a project given in any of the ways figureOutRemoteProjectId accepts
+*/
+func getRemoteProject(
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	project, organizationSlug string,
+) (*jsonapi.Resource, error) {
+	projectId, err := figureOutRemoteProjectId(project, organizationSlug, cfg)
+	if err != nil {
+		return nil, err
+	}
+	result, err := txapi.GetProjectById(api, projectId)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("project '%s' does not exist", projectId)
+	}
+	return result, nil
+}
+
 func applyBranchToResources(cfgResources []*config.Resource, branch string) {
 	for i := range cfgResources {
 		cfgResource := cfgResources[i]
@@ -115,6 +241,16 @@ func getBranchResourceSlug(cfgResource *config.Resource, branch string) string {
 	}
 }
 
+/*
+Ask the user a yes/no question in the terminal and return whether they
+answered yes. A variable so that tests can answer for the user.
+*/
+var askConfirmation = func(label string) bool {
+	prompt := promptui.Prompt{Label: label, IsConfirm: true}
+	_, err := prompt.Run()
+	return err == nil
+}
+
 func stringSliceContains(haystack []string, needle string) bool {
 	for _, item := range haystack {
 		if item == needle {
@@ -152,41 +288,44 @@ func reverseMap(src map[string]string) map[string]string {
 }
 
 /*
-Run 'do'. If the error returned by 'do' is a jsonapi.RetryError, sleep the number of
-seconds indicated by the error and try again. Meanwhile, inform the user of
-what's going on using 'send'.
+Run 'do' and, if it fails with an error that the retry policy considers
+transient (throttling, 5xx responses, network errors), try again after a
+backoff. Meanwhile, inform the user of what's going on using 'send'. Errors
+that the jsonapi.Connection has already retried, or has decided must not be
+retried (eg POSTs that the server may have processed), are returned as they
+are.
 */
 func handleRetry(do func() error, initialMsg string, send func(string)) error {
-	for {
+	return retryPolicy.Do(func() error {
 		if len(initialMsg) > 0 {
 			send(initialMsg)
 		}
-		err := do()
-		if err == nil {
-			return nil
-		} else {
-			var e *jsonapi.RetryError
-			if errors.As(err, &e) {
-				retryAfter := e.RetryAfter
-				if isatty.IsTerminal(os.Stdout.Fd()) {
-					for retryAfter > 0 {
-						send(fmt.Sprint(
-							err,
-						))
-						time.Sleep(time.Second)
-						retryAfter -= 1
-					}
-				} else {
-					send(fmt.Sprint(
-						err,
-					))
-					time.Sleep(time.Duration(retryAfter) * time.Second)
-				}
-			} else {
-				return err
-			}
+		return do()
+	}, func(err error, wait time.Duration) {
+		send(fmt.Sprintf("%s, retrying in %s", err, wait.Round(time.Second)))
+	})
+}
+
+var retryPolicy = jsonapi.DefaultRetryPolicy()
+
+/*
+Make the scheduler's workers scale down whenever 'api' gets a response that
+asks us to slow down. Returns a function that restores 'api' to its previous
+state.
+*/
+func adaptConcurrencyToRetries(
+	api *jsonapi.Connection, scheduler *worker_pool.Scheduler, workers int,
+) func() {
+	concurrency := worker_pool.NewConcurrency(1, workers)
+	scheduler.Concurrency = concurrency
+	previous := api.OnRetry
+	api.OnRetry = func(retryError *jsonapi.RetryError) {
+		concurrency.Decrease()
+		if previous != nil {
+			previous(retryError)
 		}
 	}
+	return func() { api.OnRetry = previous }
 }
 
 func checkFileFilter(fileFilter string) error {
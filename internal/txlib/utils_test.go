@@ -1,11 +1,15 @@
 package txlib
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
+	"sync/atomic"
 	"testing"
 
 	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/jsonapi"
 
 	"github.com/transifex/cli/internal/txlib/config"
 )
@@ -99,3 +103,41 @@ func TestTruncateMessage(t *testing.T) {
 		result,
 	)
 }
+
+func TestHandleRetryDoesNotResendBrokenPost(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			hijacker, _ := w.(http.Hijacker)
+			conn, _, _ := hijacker.Hijack()
+			conn.Close()
+		},
+	))
+	defer server.Close()
+
+	for _, policy := range []*jsonapi.RetryPolicy{
+		nil, jsonapi.DefaultRetryPolicy(),
+	} {
+		atomic.StoreInt32(&requests, 0)
+		api := jsonapi.Connection{Host: server.URL, RetryPolicy: policy}
+		resource := jsonapi.Resource{
+			API:        &api,
+			Type:       "resource_strings_async_uploads",
+			Attributes: map[string]interface{}{"content": "{}"},
+		}
+		var messages []string
+		err := handleRetry(
+			func() error { return resource.Save(nil) },
+			"Uploading",
+			func(msg string) { messages = append(messages, msg) },
+		)
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+		if atomic.LoadInt32(&requests) != 1 || len(messages) != 1 {
+			t.Errorf("Sent the POST %d times, messages %v",
+				atomic.LoadInt32(&requests), messages)
+		}
+	}
+}
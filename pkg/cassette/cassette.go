@@ -0,0 +1,161 @@
+/*
+Package cassette
+Record real HTTP traffic to a file and replay it later, offline. Meant for
+end-to-end tests of code that talks to the Transifex API through a
+jsonapi.Connection, without having to hand-write every response.
+
+Recording:
+
+	recorded := cassette.New()
+	api := jsonapi.Connection{
+		Host:   "https://rest.api.transifex.com",
+		Token:  "XXX",
+		Client: http.Client{Transport: cassette.NewRecorder(nil, recorded)},
+	}
+	// ... use 'api' ...
+	err := recorded.Save("testdata/pull.json")
+
+Replaying:
+
+	recorded, err := cassette.Load("testdata/pull.json")
+	replayer := cassette.NewReplayer(recorded)
+	api := jsonapi.Connection{
+		Host:   "https://rest.api.transifex.com",
+		Client: http.Client{Transport: replayer},
+	}
+	// ... use 'api' ...
+	if replayer.Remaining() > 0 {
+		t.Error("Not all recorded requests were made")
+	}
+
+Credentials are never saved: the 'Authorization' header is dropped and the
+signatures of presigned URLs are redacted with jsonapi.RedactUrl. Requests are
+matched by method, host, path and (redacted) query string, in the order they
+were recorded, so polling the same URL several times replays the recorded
+sequence of responses. The host is part of the match so that an API path and a
+download from another host with the same path don't get mixed up; replay with
+the same API host that was recorded.
+*/
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	mutex sync.Mutex
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method      string `json:"method"`
+	Url         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        Body   `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitempty"`
+}
+
+/*
+Body
+Raw body of a request or response. Saved as a plain string when it is valid
+UTF-8 (so that cassettes are easy to read and edit) and as base64 otherwise.
+*/
+type Body []byte
+
+type encodedBody struct {
+	Base64 string `json:"base64"`
+}
+
+func (body Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(body) {
+		return json.Marshal(string(body))
+	}
+	return json.Marshal(encodedBody{base64.StdEncoding.EncodeToString(body)})
+}
+
+func (body *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*body = Body(text)
+		return nil
+	}
+	var encoded encodedBody
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return err
+	}
+	*body = decoded
+	return nil
+}
+
+func New() *Cassette {
+	return &Cassette{Interactions: []Interaction{}}
+}
+
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := New()
+	err = json.Unmarshal(data, cassette)
+	if err != nil {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+func (cassette *Cassette) Save(path string) error {
+	cassette.mutex.Lock()
+	defer cassette.mutex.Unlock()
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func (cassette *Cassette) add(interaction Interaction) {
+	cassette.mutex.Lock()
+	defer cassette.mutex.Unlock()
+	cassette.Interactions = append(cassette.Interactions, interaction)
+}
+
+// Response headers that are not worth saving or must not be saved
+var skippedHeaders = []string{"Set-Cookie", "Date", "Content-Length"}
+
+// The key requests are matched with: the method and the URL, with the query
+// parameters sorted
+func matchKey(method, rawUrl string) string {
+	parsed, err := url.Parse(jsonapi.RedactUrl(rawUrl))
+	if err != nil {
+		return method + " " + rawUrl
+	}
+	key := method + " " + parsed.Host + parsed.EscapedPath()
+	if parsed.RawQuery != "" {
+		key += "?" + parsed.Query().Encode()
+	}
+	return key
+}
@@ -0,0 +1,183 @@
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func getTestServer() (*httptest.Server, *int) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			count++
+			switch r.URL.Path {
+			case "/students/1":
+				w.Header().Set("Content-Type", "application/vnd.api+json")
+				fmt.Fprintf(w, `{"data": {"type": "students", "id": "1", `+
+					`"attributes": {"visit": %d}}}`, count)
+			case "/students/2":
+				w.Header().Set(
+					"Location", "https://s3.com/f?X-Amz-Signature=secret",
+				)
+				w.WriteHeader(303)
+			case "/file":
+				_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+			}
+		},
+	))
+	return server, &count
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server, count := getTestServer()
+	defer server.Close()
+
+	recorded := New()
+	api := jsonapi.Connection{
+		Host:   server.URL,
+		Token:  "mytoken",
+		Client: http.Client{Transport: NewRecorder(nil, recorded)},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := api.Get("students", "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := api.Get("students", "2")
+	var redirectError *jsonapi.RedirectError
+	if !errors.As(err, &redirectError) {
+		t.Fatalf("Got error %v, expected a redirect", err)
+	}
+	response, err := (&http.Client{
+		Transport: NewRecorder(nil, recorded),
+	}).Get(server.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	err = recorded.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "mytoken") ||
+		strings.Contains(string(data), "secret") {
+		t.Errorf("Cassette contains secrets: %s", data)
+	}
+
+	// Replay, with the server gone
+	server.Close()
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := NewReplayer(loaded)
+	api = jsonapi.Connection{
+		Host:   server.URL,
+		Client: http.Client{Transport: replayer},
+	}
+	for i := 1; i <= 2; i++ {
+		student, err := api.Get("students", "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if student.Attributes["visit"] != float64(i) {
+			t.Errorf("Got visit %v, expected %d", student.Attributes["visit"], i)
+		}
+	}
+	_, err = api.Get("students", "2")
+	if !errors.As(err, &redirectError) ||
+		redirectError.Location != "https://s3.com/f?X-Amz-Signature=REDACTED" {
+		t.Errorf("Got error %v, expected a redacted redirect", err)
+	}
+	response, err = (&http.Client{Transport: replayer}).Get(server.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := make([]byte, 3)
+	_, _ = response.Body.Read(body)
+	if string(body) != string([]byte{0xff, 0xfe, 0x00}) {
+		t.Errorf("Got binary body %v", body)
+	}
+
+	if replayer.Remaining() != 0 {
+		t.Errorf("%d interactions were not replayed", replayer.Remaining())
+	}
+	if *count != 4 {
+		t.Errorf("Server got %d requests, expected 4", *count)
+	}
+
+	_, err = api.Get("students", "1")
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Got error %v, expected missing recording", err)
+	}
+}
+
+func TestReplayerMatchesQueryInAnyOrder(t *testing.T) {
+	replayer := NewReplayer(&Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: "GET", Url: "https://a.com/x?b=2&a=1"},
+		Response: Response{Status: 204},
+	}}})
+	response, err := (&http.Client{Transport: replayer}).Get(
+		"https://a.com/x?a=1&b=2",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 204 {
+		t.Errorf("Got status %d, expected 204", response.StatusCode)
+	}
+	if len(replayer.Requests) != 1 || replayer.Requests[0].Method != "GET" {
+		t.Errorf("Got requests %+v", replayer.Requests)
+	}
+}
+
+func TestReplayerMatchesHost(t *testing.T) {
+	replayer := NewReplayer(&Cassette{Interactions: []Interaction{
+		{
+			Request:  Request{Method: "GET", Url: "https://api.com/files/1"},
+			Response: Response{Status: 303},
+		},
+		{
+			Request:  Request{Method: "GET", Url: "https://s3.com/files/1"},
+			Response: Response{Status: 200},
+		},
+	}})
+	client := http.Client{
+		Transport: replayer,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	for _, testCase := range []struct {
+		url    string
+		status int
+	}{
+		{"https://s3.com/files/1", 200},
+		{"https://api.com/files/1", 303},
+	} {
+		response, err := client.Get(testCase.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.StatusCode != testCase.status {
+			t.Errorf("Got status %d for %s, expected %d",
+				response.StatusCode, testCase.url, testCase.status)
+		}
+	}
+	_, err := client.Get("https://other.com/files/1")
+	if err == nil {
+		t.Error("Expected no recorded response for another host")
+	}
+}
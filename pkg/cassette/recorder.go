@@ -0,0 +1,92 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+/*
+Recorder
+http.RoundTripper that sends requests with 'Transport' (http.DefaultTransport
+if nil) and adds every exchange to a Cassette. Requests that fail without a
+response are not recorded.
+*/
+type Recorder struct {
+	Transport http.RoundTripper
+	Cassette  *Cassette
+}
+
+func NewRecorder(transport http.RoundTripper, cassette *Cassette) *Recorder {
+	return &Recorder{Transport: transport, Cassette: cassette}
+}
+
+func (recorder *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport := recorder.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var requestBody []byte
+	if request.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	headers := response.Header.Clone()
+	for _, name := range skippedHeaders {
+		headers.Del(name)
+	}
+	if location := headers.Get("Location"); location != "" {
+		headers.Set("Location", jsonapi.RedactUrl(location))
+	}
+	if len(headers) == 0 {
+		headers = nil
+	}
+
+	recorder.Cassette.add(Interaction{
+		Request: Request{
+			Method:      request.Method,
+			Url:         jsonapi.RedactUrl(request.URL.String()),
+			ContentType: request.Header.Get("Content-Type"),
+			Body:        redactBody(requestBody),
+		},
+		Response: Response{
+			Status:  response.StatusCode,
+			Headers: headers,
+			Body:    redactBody(responseBody),
+		},
+	})
+	return response, nil
+}
+
+// Redact presigned URLs in text bodies, like the ones in the 'links' of async
+// jobs
+func redactBody(body []byte) Body {
+	if len(body) == 0 {
+		return nil
+	}
+	if !utf8.Valid(body) {
+		return body
+	}
+	return Body(jsonapi.RedactText(string(body)))
+}
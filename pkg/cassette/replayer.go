@@ -0,0 +1,96 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+/*
+Replayer
+http.RoundTripper that responds to requests with the responses recorded in a
+Cassette, without touching the network. Each recorded interaction is used at
+most once; asking for a request that was not recorded (or more times than it
+was recorded) returns an error.
+*/
+type Replayer struct {
+	// Recorded interactions per match key, in the order they were recorded
+	pending map[string][]Interaction
+	// The requests that were replayed, in order, for assertions
+	Requests []Request
+
+	mutex sync.Mutex
+}
+
+func NewReplayer(cassette *Cassette) *Replayer {
+	replayer := Replayer{pending: make(map[string][]Interaction)}
+	for _, interaction := range cassette.Interactions {
+		key := matchKey(interaction.Request.Method, interaction.Request.Url)
+		replayer.pending[key] = append(replayer.pending[key], interaction)
+	}
+	return &replayer
+}
+
+func (replayer *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	key := matchKey(request.Method, request.URL.String())
+	interactions := replayer.pending[key]
+	if len(interactions) == 0 {
+		return nil, fmt.Errorf("cassette: no recorded response for %s", key)
+	}
+	interaction := interactions[0]
+	replayer.pending[key] = interactions[1:]
+	replayer.Requests = append(replayer.Requests, Request{
+		Method:      request.Method,
+		Url:         request.URL.String(),
+		ContentType: request.Header.Get("Content-Type"),
+		Body:        requestBody,
+	})
+
+	recorded := interaction.Response
+	headers := recorded.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	return &http.Response{
+		Status: fmt.Sprintf(
+			"%d %s", recorded.Status, http.StatusText(recorded.Status),
+		),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       request,
+	}, nil
+}
+
+/*
+Remaining
+Return how many recorded interactions were not replayed
+*/
+func (replayer *Replayer) Remaining() int {
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+	remaining := 0
+	for _, interactions := range replayer.pending {
+		remaining += len(interactions)
+	}
+	return remaining
+}
@@ -0,0 +1,71 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Content type of requests that act on many resources at once
+const BulkContentType = `application/vnd.api+json;profile="bulk"`
+
+/*
+BulkUpdate
+Save the given attributes of many resources of the same type with a single
+PATCH request to the collection, using the "bulk" profile of {json:api}. The
+resources are updated in place with the server's response.
+
+Servers limit how many resources they accept in one request; it's up to the
+caller to split large updates.
+*/
+func (c *Connection) BulkUpdate(
+	Type string, resources []*Resource, fields []string,
+) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	payload := PayloadPluralWrite{Data: make([]PayloadResource, 0, len(resources))}
+	byId := make(map[string]*Resource)
+	for _, resource := range resources {
+		if resource.Id == "" {
+			return fmt.Errorf("cannot bulk update %s without an id", Type)
+		}
+		item := PayloadResource{
+			Type:       Type,
+			Id:         resource.Id,
+			Attributes: make(map[string]interface{}),
+		}
+		for _, field := range fields {
+			value, exists := resource.Attributes[field]
+			if !exists {
+				return fmt.Errorf(
+					"field %s of %s '%s' is not set", field, Type, resource.Id,
+				)
+			}
+			item.Attributes[field] = value
+		}
+		payload.Data = append(payload.Data, item)
+		byId[resource.Id] = resource
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	body, err = c.request("PATCH", fmt.Sprintf("/%s", Type), body, BulkContentType)
+	if err != nil {
+		return err
+	}
+
+	var response PayloadPluralRead
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return err
+	}
+	for _, item := range response.Data {
+		resource, exists := byId[item.Id]
+		if exists && item.Attributes != nil {
+			resource.Attributes = item.Attributes
+		}
+	}
+	return nil
+}
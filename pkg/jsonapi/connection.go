@@ -2,11 +2,14 @@ package jsonapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type Connection struct {
@@ -15,6 +18,15 @@ type Connection struct {
 	Client  http.Client
 	Headers map[string]string
 
+	// Optional, shared between all the workers that use this connection
+	RateLimiter *RateLimiter
+	// Optional, called whenever the server asks us to slow down
+	OnRetry func(retryError *RetryError)
+	// Optional, if not set failed requests are not retried
+	RetryPolicy *RetryPolicy
+	// Optional, logs requests and responses for debugging
+	Tracer *HttpTracer
+
 	// Used for testing
 	RequestMethod func(method, path string,
 		payload []byte, contentType string) ([]byte, error)
@@ -26,6 +38,58 @@ func (c *Connection) request(
 	payload []byte,
 	contentType string,
 ) ([]byte, error) {
+	return c.requestWithContext(
+		context.Background(), method, path, payload, contentType,
+	)
+}
+
+func (c *Connection) requestWithContext(
+	ctx context.Context,
+	method,
+	path string,
+	payload []byte,
+	contentType string,
+) ([]byte, error) {
+	var body []byte
+	attempt := func() error {
+		var err error
+		body, err = c.requestOnce(ctx, method, path, payload, contentType)
+		if err != nil && ctx.Err() != nil {
+			// Cancelled, no point in trying again
+			return &finalError{err}
+		}
+		if err != nil && method == "POST" && mayHaveBeenProcessed(err) {
+			// Retrying could create things twice
+			return &finalError{err}
+		}
+		return err
+	}
+	if c.RetryPolicy == nil {
+		return body, attempt()
+	}
+
+	err := c.RetryPolicy.Do(attempt, nil)
+	var exhausted *RetriesExhaustedError
+	if ClassifyError(err) != 0 && !errors.As(err, &exhausted) {
+		// Our policy chose not to retry this, make sure that the caller
+		// doesn't either. The finalError stays in the chain so that retry
+		// loops further up (see ClassifyError) respect it.
+		err = &finalError{err}
+	}
+	return body, err
+}
+
+func (c *Connection) requestOnce(
+	ctx context.Context,
+	method,
+	path string,
+	payload []byte,
+	contentType string,
+) ([]byte, error) {
+	if c.RateLimiter != nil {
+		c.RateLimiter.Wait()
+	}
+
 	if c.RequestMethod != nil {
 		return c.RequestMethod(method, path, payload, contentType)
 	}
@@ -34,15 +98,15 @@ func (c *Connection) request(
 		path = c.Host + path
 	}
 
-	if c.Client.CheckRedirect == nil {
-		c.Client.CheckRedirect = func(
-			req *http.Request, via []*http.Request,
-		) error {
-			return &RedirectError{Location: req.URL.String()}
-		}
+	// The connection may be shared between goroutines, so don't modify it
+	client := c.Client
+	if client.CheckRedirect == nil {
+		client.CheckRedirect = RefuseRedirects
 	}
 
-	requestObj, err := http.NewRequest(method, path, bytes.NewReader(payload))
+	requestObj, err := http.NewRequestWithContext(
+		ctx, method, path, bytes.NewReader(payload),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -55,18 +119,38 @@ func (c *Connection) request(
 	for header, value := range c.Headers {
 		requestObj.Header.Add(header, value)
 	}
-	response, err := c.Client.Do(requestObj)
+	started := time.Now()
+	response, err := client.Do(requestObj)
 	if err != nil {
+		if c.Tracer != nil {
+			c.Tracer.trace(requestObj, payload, nil, nil, started, err)
+		}
 		return nil, err
 	}
+	defer response.Body.Close()
 	body, err := io.ReadAll(response.Body)
+	if c.Tracer != nil {
+		if err != nil {
+			c.Tracer.trace(requestObj, payload, nil, nil, started, err)
+		} else {
+			c.Tracer.trace(requestObj, payload, response, body, started, nil)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
 
 	retryErrorResponse := parseRetryResponse(response)
 	if retryErrorResponse != nil {
+		if c.OnRetry != nil {
+			c.OnRetry(retryErrorResponse)
+		}
+		if c.RateLimiter != nil {
+			c.RateLimiter.Pause(
+				time.Duration(retryErrorResponse.RetryAfter) * time.Second,
+			)
+			return nil, &pausedError{retryErrorResponse}
+		}
 		return nil, retryErrorResponse
 	}
 
@@ -83,7 +167,20 @@ Get
 Returns a Resource instance from the server based on its 'type' and 'id'
 */
 func (c *Connection) Get(Type, Id string) (Resource, error) {
+	return c.GetWithQuery(Type, Id, "")
+}
+
+/*
+GetWithQuery
+Like Get, but with a URL encoded set of GET variables, eg for including related
+resources or sparse fieldsets. Included resources are available through the
+relationships without fetching them.
+*/
+func (c *Connection) GetWithQuery(Type, Id, Query string) (Resource, error) {
 	url := fmt.Sprintf("/%s/%s", Type, Id)
+	if Query != "" {
+		url = url + "?" + Query
+	}
 	return c.getFromPath(url)
 }
 
@@ -99,7 +196,11 @@ func (c *Connection) getFromPath(path string) (Resource, error) {
 	if err != nil {
 		return result, err
 	}
-	return payloadToResource(response.Data, nil, c)
+	included, err := makeIncludedMap(response.Included, c)
+	if err != nil {
+		return result, err
+	}
+	return payloadToResource(response.Data, &included, c)
 }
 
 /*
@@ -117,8 +218,14 @@ func (c *Connection) List(Type, Query string) (Collection, error) {
 }
 
 func (c *Connection) listFromPath(Url string) (Collection, error) {
+	return c.listFromPathWithContext(context.Background(), Url)
+}
+
+func (c *Connection) listFromPathWithContext(
+	ctx context.Context, Url string,
+) (Collection, error) {
 	var result Collection
-	body, err := c.request("GET", Url, nil, "")
+	body, err := c.requestWithContext(ctx, "GET", Url, nil, "")
 	if err != nil {
 		return result, err
 	}
@@ -134,3 +134,85 @@ func TestList(t *testing.T) {
 		}
 	}
 }
+
+func TestGetWithIncluded(t *testing.T) {
+	var capturedPath string
+	api := Connection{
+		RequestMethod: func(
+			method, path string, payload []byte, contentType string,
+		) ([]byte, error) {
+			capturedPath = path
+			return []byte(`{
+				"data": {"type": "students", "id": "1",
+				         "relationships": {"teacher": {
+				           "data": {"type": "teachers", "id": "2"}
+				         }}},
+				"included": [{"type": "teachers", "id": "2",
+				              "attributes": {"name": "Jane Doe"}}]
+			}`), nil
+		},
+	}
+
+	student, err := api.GetWithQuery(
+		"students", "1", Query{Includes: []string{"teacher"}}.Encode(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capturedPath != "/students/1?include=teacher" {
+		t.Errorf("Got path %s", capturedPath)
+	}
+	relationship := student.Relationships["teacher"]
+	if !relationship.Fetched ||
+		relationship.DataSingular.Attributes["name"] != "Jane Doe" {
+		t.Errorf("Included teacher was not used: %+v", relationship.DataSingular)
+	}
+}
+
+func TestBulkUpdate(t *testing.T) {
+	var capturedMethod, capturedPath, capturedContentType string
+	var capturedPayload []byte
+	api := Connection{
+		RequestMethod: func(
+			method, path string, payload []byte, contentType string,
+		) ([]byte, error) {
+			capturedMethod = method
+			capturedPath = path
+			capturedPayload = payload
+			capturedContentType = contentType
+			return []byte(`{"data": [
+				{"type": "students", "id": "1", "attributes": {"name": "A", "age": 10}},
+				{"type": "students", "id": "2", "attributes": {"name": "B", "age": 11}}
+			]}`), nil
+		},
+	}
+	students := []*Resource{
+		{Type: "students", Id: "1", Attributes: map[string]interface{}{"name": "A"}},
+		{Type: "students", Id: "2", Attributes: map[string]interface{}{"name": "B"}},
+	}
+
+	err := api.BulkUpdate("students", students, []string{"name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capturedMethod != "PATCH" || capturedPath != "/students" ||
+		capturedContentType != BulkContentType {
+		t.Errorf("Got request %s %s (%s)",
+			capturedMethod, capturedPath, capturedContentType)
+	}
+	equal, _ := jsonEqual(capturedPayload, []byte(`{"data": [
+		{"type": "students", "id": "1", "attributes": {"name": "A"}},
+		{"type": "students", "id": "2", "attributes": {"name": "B"}}
+	]}`))
+	if !equal {
+		t.Errorf("Got payload %s", capturedPayload)
+	}
+	if students[1].Attributes["age"] != float64(11) {
+		t.Errorf("Students were not updated: %+v", students[1].Attributes)
+	}
+
+	err = api.BulkUpdate("students", students, []string{"age", "grade"})
+	if err == nil {
+		t.Error("Expected an error for a missing field")
+	}
+}
@@ -3,9 +3,11 @@ package jsonapi
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 /*
@@ -77,6 +79,16 @@ func (m *RedirectError) Error() string {
 		"`var e *jsonapi.RedirectError; errors.As(err, &e); e.Location`"
 }
 
+/*
+RefuseRedirects
+The CheckRedirect of the HTTP client of a Connection; redirects are returned
+as RedirectErrors instead of being followed. Connections whose client has no
+CheckRedirect use it too.
+*/
+func RefuseRedirects(req *http.Request, via []*http.Request) error {
+	return &RedirectError{Location: req.URL.String()}
+}
+
 type RetryError struct {
 	StatusCode int
 	RetryAfter int
@@ -95,14 +107,36 @@ func parseRetryResponse(response *http.Response) *RetryError {
 		response.StatusCode != 504 {
 		return nil
 	}
-	if response.StatusCode == 502 ||
-		response.StatusCode == 503 ||
-		response.StatusCode == 504 {
-		return &RetryError{response.StatusCode, 10}
+	defaultRetryAfter := 1
+	if response.StatusCode != 429 {
+		defaultRetryAfter = 10
 	}
-	retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
-	if err != nil {
-		return &RetryError{response.StatusCode, 1}
+	retryAfter, ok := parseRetryAfterHeader(response.Header.Get("Retry-After"))
+	if !ok {
+		return &RetryError{response.StatusCode, defaultRetryAfter}
 	}
 	return &RetryError{response.StatusCode, retryAfter}
 }
+
+// 'Retry-After' is either a number of seconds or an HTTP date
+func parseRetryAfterHeader(value string) (int, bool) {
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return seconds, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	seconds = int(math.Ceil(time.Until(date).Seconds()))
+	if seconds < 0 {
+		seconds = 0
+	}
+	return seconds, true
+}
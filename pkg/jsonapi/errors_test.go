@@ -1,7 +1,9 @@
 package jsonapi
 
 import (
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestHandleSingleErrorResponse(t *testing.T) {
@@ -78,3 +80,49 @@ func TestHandleDoubleErrorResponse(t *testing.T) {
 			errorResponse.Error(), expectedError)
 	}
 }
+
+func TestParseRetryResponse(t *testing.T) {
+	testCases := []struct {
+		statusCode int
+		retryAfter string
+		expected   *RetryError
+	}{
+		{200, "", nil},
+		{404, "5", nil},
+		{429, "", &RetryError{429, 1}},
+		{429, "5", &RetryError{429, 5}},
+		{429, "soon", &RetryError{429, 1}},
+		{503, "", &RetryError{503, 10}},
+		{503, "30", &RetryError{503, 30}},
+		{502, "", &RetryError{502, 10}},
+		{504, "-3", &RetryError{504, 10}},
+	}
+	for _, testCase := range testCases {
+		response := &http.Response{
+			StatusCode: testCase.statusCode,
+			Header:     make(http.Header),
+		}
+		if testCase.retryAfter != "" {
+			response.Header.Set("Retry-After", testCase.retryAfter)
+		}
+		actual := parseRetryResponse(response)
+		if testCase.expected == nil {
+			if actual != nil {
+				t.Errorf("Got %+v for %d, expected nil", actual, testCase.statusCode)
+			}
+			continue
+		}
+		if actual == nil || *actual != *testCase.expected {
+			t.Errorf("Got %+v for %d/'%s', expected %+v",
+				actual, testCase.statusCode, testCase.retryAfter, testCase.expected)
+		}
+	}
+}
+
+func TestParseRetryAfterHeaderDate(t *testing.T) {
+	date := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+	seconds, ok := parseRetryAfterHeader(date)
+	if !ok || seconds < 18 || seconds > 21 {
+		t.Errorf("Got %d (%t), expected ~20", seconds, ok)
+	}
+}
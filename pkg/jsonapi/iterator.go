@@ -0,0 +1,160 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+/*
+ListOptions
+Optional limits for iterating over collections
+*/
+type ListOptions struct {
+	// Ask the server for pages of this size ('page[size]'); 0 leaves it to
+	// the server
+	PageSize int
+	// Stop after this many items; 0 means no limit
+	MaxItems int
+}
+
+/*
+Iterator
+Goes over the items of a paginated collection, fetching the pages lazily, as
+pointed to by the `.links.next` field of each {json:api} response:
+
+	iterator := api.Iterate(ctx, "resources", query, jsonapi.ListOptions{})
+	for iterator.Next() {
+		resource := iterator.Resource()
+		...
+	}
+	if err := iterator.Err(); err != nil {
+		...
+	}
+
+Stopping early means the remaining pages are never fetched.
+*/
+type Iterator struct {
+	api      *Connection
+	ctx      context.Context
+	options  ListOptions
+	nextUrl  string
+	page     []Resource
+	position int
+	count    int
+	current  *Resource
+	err      error
+}
+
+/*
+Iterate
+Return an iterator over all the items of a collection. Query is a URL encoded
+set of GET variables, like the ones Query.Encode returns. No request is made
+until the first call to Next.
+*/
+func (c *Connection) Iterate(
+	ctx context.Context, Type, Query string, options ListOptions,
+) *Iterator {
+	iterator := &Iterator{api: c, ctx: ctx, options: options}
+	values, err := url.ParseQuery(Query)
+	if err != nil {
+		iterator.err = err
+		return iterator
+	}
+	if options.PageSize > 0 {
+		values.Set("page[size]", strconv.Itoa(options.PageSize))
+	}
+	iterator.nextUrl = fmt.Sprintf("/%s", Type)
+	if len(values) > 0 {
+		iterator.nextUrl += "?" + values.Encode()
+	}
+	return iterator
+}
+
+/*
+Iterate
+Return an iterator over the items of this page and the ones that follow it
+*/
+func (c *Collection) Iterate(
+	ctx context.Context, options ListOptions,
+) *Iterator {
+	return &Iterator{
+		api:     c.API,
+		ctx:     ctx,
+		options: options,
+		nextUrl: c.Next,
+		page:    c.Data,
+	}
+}
+
+/*
+ListAll
+Return all the items of a collection, following pagination
+*/
+func (c *Connection) ListAll(
+	ctx context.Context, Type, Query string, options ListOptions,
+) ([]*Resource, error) {
+	return c.Iterate(ctx, Type, Query, options).All()
+}
+
+/*
+Next
+Advance to the next item, fetching the next page if needed. Returns false
+when there are no more items, the limit has been reached or an error occurred;
+check Err to tell them apart.
+*/
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.options.MaxItems > 0 && it.count >= it.options.MaxItems {
+		return false
+	}
+	for it.position >= len(it.page) {
+		if it.nextUrl == "" {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		page, err := it.api.listFromPathWithContext(it.ctx, it.nextUrl)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page.Data
+		it.position = 0
+		it.nextUrl = page.Next
+	}
+	resource := it.page[it.position]
+	it.current = &resource
+	it.position++
+	it.count++
+	return true
+}
+
+/*
+Resource
+Return the current item. Each item is a separate copy, safe to keep around.
+*/
+func (it *Iterator) Resource() *Resource {
+	return it.current
+}
+
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+/*
+All
+Consume the iterator and return all the remaining items
+*/
+func (it *Iterator) All() ([]*Resource, error) {
+	var result []*Resource
+	for it.Next() {
+		result = append(result, it.Resource())
+	}
+	return result, it.Err()
+}
@@ -0,0 +1,149 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Three pages of two students each
+func getPaginatedConnection(requestedPaths *[]string) *Connection {
+	pages := map[string]string{
+		"/students?page%5Bsize%5D=2": `{"data": [
+			{"type": "students", "id": "1"}, {"type": "students", "id": "2"}
+		], "links": {"next": "/students?page=2"}}`,
+		"/students?page=2": `{"data": [
+			{"type": "students", "id": "3"}, {"type": "students", "id": "4"}
+		], "links": {"next": "/students?page=3"}}`,
+		"/students?page=3": `{"data": [
+			{"type": "students", "id": "5"}, {"type": "students", "id": "6"}
+		], "links": {"next": null}}`,
+	}
+	return &Connection{RequestMethod: func(
+		method, path string, payload []byte, contentType string,
+	) ([]byte, error) {
+		*requestedPaths = append(*requestedPaths, path)
+		page, exists := pages[path]
+		if !exists {
+			return nil, fmt.Errorf("unexpected path %s", path)
+		}
+		return []byte(page), nil
+	}}
+}
+
+func getIds(resources []*Resource) string {
+	result := ""
+	for _, resource := range resources {
+		result += resource.Id
+	}
+	return result
+}
+
+func TestListAll(t *testing.T) {
+	var paths []string
+	api := getPaginatedConnection(&paths)
+
+	students, err := api.ListAll(
+		context.Background(), "students", "", ListOptions{PageSize: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getIds(students) != "123456" {
+		t.Errorf("Got students %s, expected 123456", getIds(students))
+	}
+	if len(paths) != 3 {
+		t.Errorf("Got requests %v, expected 3", paths)
+	}
+}
+
+func TestIteratorMaxItems(t *testing.T) {
+	var paths []string
+	api := getPaginatedConnection(&paths)
+
+	students, err := api.ListAll(
+		context.Background(), "students", "",
+		ListOptions{PageSize: 2, MaxItems: 3},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getIds(students) != "123" {
+		t.Errorf("Got students %s, expected 123", getIds(students))
+	}
+	if len(paths) != 2 {
+		t.Errorf("Got requests %v, expected 2", paths)
+	}
+}
+
+func TestIteratorIsLazy(t *testing.T) {
+	var paths []string
+	api := getPaginatedConnection(&paths)
+
+	iterator := api.Iterate(
+		context.Background(), "students", "", ListOptions{PageSize: 2},
+	)
+	if len(paths) != 0 {
+		t.Errorf("Got requests %v before iterating", paths)
+	}
+	for iterator.Next() {
+		if iterator.Resource().Id == "2" {
+			break
+		}
+	}
+	if iterator.Err() != nil {
+		t.Error(iterator.Err())
+	}
+	if len(paths) != 1 {
+		t.Errorf("Got requests %v, expected 1", paths)
+	}
+}
+
+func TestIteratorCancelled(t *testing.T) {
+	var paths []string
+	api := getPaginatedConnection(&paths)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	iterator := api.Iterate(ctx, "students", "", ListOptions{PageSize: 2})
+	count := 0
+	for iterator.Next() {
+		count++
+		cancel()
+	}
+	if !errors.Is(iterator.Err(), context.Canceled) {
+		t.Errorf("Got error %v, expected cancellation", iterator.Err())
+	}
+	if count != 2 || len(paths) != 1 {
+		t.Errorf("Got %d students with requests %v", count, paths)
+	}
+}
+
+func TestCollectionIterate(t *testing.T) {
+	var paths []string
+	api := getPaginatedConnection(&paths)
+
+	page := Collection{
+		API:  api,
+		Data: []Resource{{Type: "students", Id: "0"}},
+		Next: "/students?page=3",
+	}
+	students, err := page.Iterate(context.Background(), ListOptions{}).All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getIds(students) != "056" {
+		t.Errorf("Got students %s, expected 056", getIds(students))
+	}
+}
+
+func TestIteratorError(t *testing.T) {
+	var paths []string
+	api := getPaginatedConnection(&paths)
+
+	_, err := api.ListAll(context.Background(), "teachers", "", ListOptions{})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -3,13 +3,33 @@ package jsonapi
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 type Query struct {
 	Filters  map[string]string
 	Includes []string
-	Extras   map[string]string
+	// Sparse fieldsets: resource type -> the only attributes and
+	// relationships the server should return for it
+	Fields map[string][]string
+	Page   PageOptions
+	Extras map[string]string
+}
+
+type PageOptions struct {
+	// Number of items per page, 0 leaves it to the server
+	Size int
+	// Where the page starts, as found in the 'next' and 'previous' links
+	Cursor string
+}
+
+/*
+String
+Prints the same as '%v' would; makes Queries printable with '%s' as well
+*/
+func (page PageOptions) String() string {
+	return fmt.Sprintf("{%d %s}", page.Size, page.Cursor)
 }
 
 /*
@@ -31,6 +51,15 @@ func (q Query) Encode() string {
 	if q.Includes != nil {
 		result.Add("include", strings.Join(q.Includes, ","))
 	}
+	for Type, fields := range q.Fields {
+		result.Add(fmt.Sprintf("fields[%s]", Type), strings.Join(fields, ","))
+	}
+	if q.Page.Size > 0 {
+		result.Add("page[size]", strconv.Itoa(q.Page.Size))
+	}
+	if q.Page.Cursor != "" {
+		result.Add("page[cursor]", q.Page.Cursor)
+	}
 	if q.Extras != nil {
 		for key, value := range q.Extras {
 			result.Add(key, value)
@@ -18,6 +18,10 @@ func TestEncode(t *testing.T) {
 		{Query{Includes: []string{"aaa", "bbb"}},
 			"include=aaa,bbb"},
 		{Query{Extras: map[string]string{"limit": "15"}}, "limit=15"},
+		{Query{Fields: map[string][]string{"projects": {"slug", "name"}}},
+			"fields[projects]=slug,name"},
+		{Query{Page: PageOptions{Size: 20, Cursor: "abc"}},
+			"page[cursor]=abc&page[size]=20"},
 	}
 
 	for _, testCase := range testCases {
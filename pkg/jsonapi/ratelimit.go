@@ -0,0 +1,113 @@
+package jsonapi
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+RateLimiter
+Token bucket that limits the rate of requests a Connection sends. It is meant
+to be shared (by pointer) between all the copies of a Connection that talk to
+the same host, so that parallel workers respect a common budget.
+
+	api := jsonapi.Connection{
+		Host: "https://foo.com",
+		Token: "XXX",
+		RateLimiter: jsonapi.NewRateLimiter(10, 10),
+	}
+
+A 'requestsPerSecond' value of 0 or less disables the cap; the limiter will
+then only hold requests back while it is paused. The Connection pauses the
+limiter whenever the server responds with a 'Retry-After' (or a 5xx) so that
+*all* workers back off, not only the one that got the response. Retry loops
+then only apply their own backoff, the pause takes care of the 'Retry-After'.
+*/
+type RateLimiter struct {
+	requestsPerSecond float64
+	burst             float64
+	tokens            float64
+	last              time.Time
+	pausedUntil       time.Time
+	mutex             sync.Mutex
+
+	// Used for testing
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		requestsPerSecond: requestsPerSecond,
+		burst:             float64(burst),
+		tokens:            float64(burst),
+		now:               time.Now,
+		sleep:             time.Sleep,
+	}
+}
+
+/*
+Wait
+Block until a request is allowed to be sent.
+*/
+func (limiter *RateLimiter) Wait() {
+	for {
+		delay := limiter.reserve()
+		if delay <= 0 {
+			return
+		}
+		limiter.sleep(delay)
+	}
+}
+
+// Try to take a token. Returns how long the caller needs to wait before
+// trying again, or 0 if the token was taken.
+func (limiter *RateLimiter) reserve() time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	if now.Before(limiter.pausedUntil) {
+		return limiter.pausedUntil.Sub(now)
+	}
+	if limiter.requestsPerSecond <= 0 {
+		return 0
+	}
+
+	if !limiter.last.IsZero() {
+		elapsed := now.Sub(limiter.last).Seconds()
+		limiter.tokens += elapsed * limiter.requestsPerSecond
+		if limiter.tokens > limiter.burst {
+			limiter.tokens = limiter.burst
+		}
+	}
+	limiter.last = now
+
+	if limiter.tokens >= 1 {
+		limiter.tokens -= 1
+		return 0
+	}
+	missing := 1 - limiter.tokens
+	return time.Duration(missing / limiter.requestsPerSecond * float64(time.Second))
+}
+
+/*
+Pause
+Hold back all requests for 'duration'. Overlapping pauses are merged, the
+latest end wins.
+*/
+func (limiter *RateLimiter) Pause(duration time.Duration) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	until := limiter.now().Add(duration)
+	if until.After(limiter.pausedUntil) {
+		limiter.pausedUntil = until
+	}
+	// Don't let the bucket refill with the time spent paused
+	limiter.tokens = 0
+	limiter.last = limiter.pausedUntil
+}
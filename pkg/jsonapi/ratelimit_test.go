@@ -0,0 +1,148 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func getTestRateLimiter(
+	requestsPerSecond float64, burst int,
+) (*RateLimiter, *time.Time, *[]time.Duration) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	limiter := NewRateLimiter(requestsPerSecond, burst)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(duration time.Duration) {
+		sleeps = append(sleeps, duration)
+		now = now.Add(duration)
+	}
+	return limiter, &now, &sleeps
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	limiter, _, sleeps := getTestRateLimiter(2, 2)
+
+	limiter.Wait()
+	limiter.Wait()
+	if len(*sleeps) != 0 {
+		t.Errorf("Slept %v within the burst", *sleeps)
+	}
+
+	limiter.Wait()
+	if len(*sleeps) != 1 || (*sleeps)[0] != 500*time.Millisecond {
+		t.Errorf("Got sleeps %v, expected [500ms]", *sleeps)
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter, now, sleeps := getTestRateLimiter(1, 1)
+
+	limiter.Wait()
+	*now = now.Add(3 * time.Second)
+	limiter.Wait()
+	if len(*sleeps) != 0 {
+		t.Errorf("Slept %v after the bucket refilled", *sleeps)
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	limiter, _, sleeps := getTestRateLimiter(0, 1)
+
+	for i := 0; i < 100; i++ {
+		limiter.Wait()
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("Unlimited rate limiter slept %v", *sleeps)
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	limiter, _, sleeps := getTestRateLimiter(0, 1)
+
+	limiter.Pause(3 * time.Second)
+	limiter.Pause(time.Second)
+	limiter.Wait()
+	if len(*sleeps) != 1 || (*sleeps)[0] != 3*time.Second {
+		t.Errorf("Got sleeps %v, expected [3s]", *sleeps)
+	}
+}
+
+func TestConnectionPausesOnRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(429)
+		},
+	))
+	defer server.Close()
+
+	limiter, _, sleeps := getTestRateLimiter(0, 1)
+	var retryErrors []*RetryError
+	api := Connection{
+		Host:        server.URL,
+		RateLimiter: limiter,
+		OnRetry: func(retryError *RetryError) {
+			retryErrors = append(retryErrors, retryError)
+		},
+	}
+	_, err := api.Get("students", "1")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if len(retryErrors) != 1 || retryErrors[0].RetryAfter != 7 {
+		t.Errorf("Got retry errors %+v, expected one with RetryAfter 7",
+			retryErrors)
+	}
+
+	limiter.Wait()
+	if len(*sleeps) != 1 || (*sleeps)[0] < 6*time.Second {
+		t.Errorf("Got sleeps %v, expected ~7s", *sleeps)
+	}
+}
+
+func TestConnectionWaitsOnceOnRetryAfter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(429)
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			_, _ = w.Write([]byte(
+				`{"data": {"type": "students", "id": "1"}}`,
+			))
+		},
+	))
+	defer server.Close()
+
+	limiter, now, limiterSleeps := getTestRateLimiter(0, 1)
+	policy, _ := getTestRetryPolicy(3, 0)
+	var retrySleeps []time.Duration
+	policy.now = func() time.Time { return *now }
+	policy.sleep = func(duration time.Duration) {
+		retrySleeps = append(retrySleeps, duration)
+		*now = now.Add(duration)
+	}
+	api := Connection{
+		Host:        server.URL,
+		RateLimiter: limiter,
+		RetryPolicy: policy,
+	}
+	_, err := api.Get("students", "1")
+	if err != nil {
+		t.Fatalf("Got error %s", err)
+	}
+
+	// The retry loop only backs off, the paused limiter covers the rest
+	if len(retrySleeps) != 1 || retrySleeps[0] != time.Second {
+		t.Errorf("Got retry sleeps %v, expected [1s]", retrySleeps)
+	}
+	if len(*limiterSleeps) != 1 || (*limiterSleeps)[0] != 6*time.Second {
+		t.Errorf("Got limiter sleeps %v, expected [6s]", *limiterSleeps)
+	}
+}
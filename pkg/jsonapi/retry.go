@@ -0,0 +1,373 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+/*
+ErrorClass
+Bit flags for the kinds of errors a RetryPolicy may retry. Use ClassifyError to
+find out the class of an error.
+*/
+type ErrorClass int
+
+const (
+	// 429 responses
+	RetryThrottled ErrorClass = 1 << iota
+	// 502, 503 and 504 responses
+	RetryServerErrors
+	// Connection refused/reset, unexpected EOF, etc
+	RetryConnectionErrors
+	// Dial, TLS handshake and response header timeouts
+	RetryTimeouts
+	// Failed DNS lookups
+	RetryDNSErrors
+
+	RetryAll = RetryThrottled | RetryServerErrors | RetryConnectionErrors |
+		RetryTimeouts | RetryDNSErrors
+)
+
+// Names of the error classes, in the order they are printed
+var errorClassNames = []struct {
+	name  string
+	class ErrorClass
+}{
+	{"throttled", RetryThrottled},
+	{"server_errors", RetryServerErrors},
+	{"connection_errors", RetryConnectionErrors},
+	{"timeouts", RetryTimeouts},
+	{"dns_errors", RetryDNSErrors},
+}
+
+/*
+ParseErrorClasses
+Parse a comma separated list of error class names, eg
+"throttled,server_errors", into an ErrorClass. "all" stands for all the classes
+and "none" for none of them.
+*/
+func ParseErrorClasses(value string) (ErrorClass, error) {
+	var result ErrorClass
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "all":
+			result |= RetryAll
+			continue
+		case "none":
+			continue
+		}
+		found := false
+		for _, item := range errorClassNames {
+			if item.name == name {
+				result |= item.class
+				found = true
+				break
+			}
+		}
+		if !found {
+			var names []string
+			for _, item := range errorClassNames {
+				names = append(names, item.name)
+			}
+			return 0, fmt.Errorf(
+				"unknown error class '%s', use 'all', 'none' or any of: %s",
+				name, strings.Join(names, ", "),
+			)
+		}
+	}
+	return result, nil
+}
+
+/*
+String
+The names of the classes in 'class', in the format that ParseErrorClasses
+accepts
+*/
+func (class ErrorClass) String() string {
+	if class&RetryAll == 0 {
+		return "none"
+	}
+	if class&RetryAll == RetryAll {
+		return "all"
+	}
+	var names []string
+	for _, item := range errorClassNames {
+		if class&item.class != 0 {
+			names = append(names, item.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+/*
+RetryPolicy
+Decides whether and when a failed operation should be retried.
+
+MaxAttempts is the total number of attempts, including the first one, and
+MaxElapsedTime is how long to keep trying since the first attempt; 0 means no
+limit for either. The wait before retry 'n' (starting from 1) is
+InitialInterval * Multiplier^(n-1), capped to MaxInterval and randomised by
++/- Jitter (a fraction between 0 and 1). If the server responded with a
+'Retry-After', we wait at least that long, unless the Connection has already
+paused its RateLimiter for it. RetryOn is the set of error classes that will be
+retried.
+
+Usage:
+
+	api := jsonapi.Connection{
+		Host:        "https://foo.com",
+		Token:       "XXX",
+		RetryPolicy: jsonapi.DefaultRetryPolicy(),
+	}
+*/
+type RetryPolicy struct {
+	MaxAttempts     int
+	MaxElapsedTime  time.Duration
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64
+	RetryOn         ErrorClass
+
+	// Used for testing
+	sleep  func(time.Duration)
+	now    func() time.Time
+	random func() float64
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     6,
+		MaxElapsedTime:  5 * time.Minute,
+		InitialInterval: time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryOn:         RetryAll,
+	}
+}
+
+/*
+RetriesExhaustedError
+Returned by RetryPolicy.Do when an error was retryable but the policy ran out
+of attempts or time. Wraps the last error, so 'errors.As' still works for it.
+*/
+type RetriesExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (err *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("giving up after %d attempts: %s", err.Attempts, err.Err)
+}
+
+func (err *RetriesExhaustedError) Unwrap() error {
+	return err.Err
+}
+
+/*
+Backoff
+Return how long to wait before retry number 'attempt' (1 for the first retry)
+*/
+func (policy *RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	interval := float64(policy.InitialInterval) *
+		math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxInterval > 0 && interval > float64(policy.MaxInterval) {
+		interval = float64(policy.MaxInterval)
+	}
+	if policy.Jitter > 0 {
+		random := rand.Float64
+		if policy.random != nil {
+			random = policy.random
+		}
+		// Between (1 - Jitter) and (1 + Jitter) times the interval
+		interval = interval * (1 + policy.Jitter*(2*random()-1))
+	}
+	return time.Duration(interval)
+}
+
+/*
+Do
+Run 'operation' until it succeeds, fails with an error the policy does not
+retry, or the policy runs out of attempts/time. Before every retry, 'notify'
+(if not nil) is called with the error and the time we are about to wait.
+*/
+func (policy *RetryPolicy) Do(
+	operation func() error, notify func(err error, wait time.Duration),
+) error {
+	sleep, now := time.Sleep, time.Now
+	if policy.sleep != nil {
+		sleep = policy.sleep
+	}
+	if policy.now != nil {
+		now = policy.now
+	}
+
+	start := now()
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+		if ClassifyError(err)&policy.RetryOn == 0 {
+			return err
+		}
+		var exhausted *RetriesExhaustedError
+		if errors.As(err, &exhausted) {
+			// Someone further down has already retried this
+			return err
+		}
+
+		wait := policy.Backoff(attempt)
+		var retryError *RetryError
+		var paused *pausedError
+		if errors.As(err, &retryError) && !errors.As(err, &paused) {
+			retryAfter := time.Duration(retryError.RetryAfter) * time.Second
+			if retryAfter > wait {
+				wait = retryAfter
+			}
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return &RetriesExhaustedError{attempt, err}
+		}
+		if policy.MaxElapsedTime > 0 &&
+			now().Add(wait).Sub(start) > policy.MaxElapsedTime {
+			return &RetriesExhaustedError{attempt, err}
+		}
+
+		if notify != nil {
+			notify(err, wait)
+		}
+		sleep(wait)
+	}
+}
+
+/*
+ClassifyError
+Return the class of 'err' or 0 if it shouldn't be retried
+*/
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return 0
+	}
+
+	var final *finalError
+	if errors.As(err, &final) {
+		return 0
+	}
+
+	var retryError *RetryError
+	if errors.As(err, &retryError) {
+		if retryError.StatusCode == 429 {
+			return RetryThrottled
+		}
+		return RetryServerErrors
+	}
+
+	// Errors the server responded with are final
+	var apiError *Error
+	var redirectError *RedirectError
+	if errors.As(err, &apiError) || errors.As(err, &redirectError) {
+		return 0
+	}
+	if errors.Is(err, context.Canceled) {
+		return 0
+	}
+
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		if dnsError.IsTimeout {
+			return RetryTimeouts
+		}
+		return RetryDNSErrors
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return RetryTimeouts
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return RetryTimeouts
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) {
+		return RetryConnectionErrors
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) {
+		return RetryConnectionErrors
+	}
+
+	return 0
+}
+
+// Wraps errors that must not be retried even if their class says otherwise
+type finalError struct {
+	Err error
+}
+
+func (err *finalError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *finalError) Unwrap() error {
+	return err.Err
+}
+
+// Wraps RetryErrors whose 'Retry-After' the RateLimiter is already enforcing, so
+// that retry loops don't wait for it a second time
+type pausedError struct {
+	Err error
+}
+
+func (err *pausedError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *pausedError) Unwrap() error {
+	return err.Err
+}
+
+/*
+Whether the server may have received and acted on a request that failed with
+'err'. Failures during DNS resolution or while connecting are safe to retry for
+any request; connections that broke afterwards are only safe for idempotent
+ones.
+*/
+func mayHaveBeenProcessed(err error) bool {
+	class := ClassifyError(err)
+	if class&(RetryConnectionErrors|RetryTimeouts) == 0 {
+		return false
+	}
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return false
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) && opError.Op == "dial" {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,324 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func getTestRetryPolicy(
+	maxAttempts int, maxElapsedTime time.Duration,
+) (*RetryPolicy, *[]time.Duration) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	policy := &RetryPolicy{
+		MaxAttempts:     maxAttempts,
+		MaxElapsedTime:  maxElapsedTime,
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+		RetryOn:         RetryAll,
+		now:             func() time.Time { return now },
+		sleep: func(duration time.Duration) {
+			sleeps = append(sleeps, duration)
+			now = now.Add(duration)
+		},
+	}
+	return policy, &sleeps
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy, _ := getTestRetryPolicy(0, 0)
+	expected := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second,
+		5 * time.Second,
+	}
+	for i, duration := range expected {
+		result := policy.Backoff(i + 1)
+		if result != duration {
+			t.Errorf("Got backoff %s for attempt %d, expected %s",
+				result, i+1, duration)
+		}
+	}
+
+	policy.Jitter = 0.5
+	policy.random = func() float64 { return 0 }
+	if result := policy.Backoff(1); result != 500*time.Millisecond {
+		t.Errorf("Got backoff %s with low jitter, expected 500ms", result)
+	}
+	policy.random = func() float64 { return 1 }
+	if result := policy.Backoff(1); result != 1500*time.Millisecond {
+		t.Errorf("Got backoff %s with high jitter, expected 1.5s", result)
+	}
+}
+
+func TestRetryPolicyDoSucceeds(t *testing.T) {
+	policy, sleeps := getTestRetryPolicy(5, 0)
+	attempts := 0
+	err := policy.Do(func() error {
+		attempts++
+		if attempts < 3 {
+			return syscall.ECONNRESET
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Errorf("Got error %s", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Got %d attempts, expected 3", attempts)
+	}
+	if len(*sleeps) != 2 ||
+		(*sleeps)[0] != time.Second || (*sleeps)[1] != 2*time.Second {
+		t.Errorf("Got sleeps %v, expected [1s 2s]", *sleeps)
+	}
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	policy, _ := getTestRetryPolicy(3, 0)
+	attempts := 0
+	var notified []time.Duration
+	err := policy.Do(func() error {
+		attempts++
+		return io.ErrUnexpectedEOF
+	}, func(err error, wait time.Duration) {
+		notified = append(notified, wait)
+	})
+	var exhausted *RetriesExhaustedError
+	if !errors.As(err, &exhausted) || exhausted.Attempts != 3 {
+		t.Fatalf("Got error %v, expected exhausted after 3 attempts", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Exhausted error does not wrap the last error: %v", err)
+	}
+	if attempts != 3 || len(notified) != 2 {
+		t.Errorf("Got %d attempts and %d notifications, expected 3 and 2",
+			attempts, len(notified))
+	}
+}
+
+func TestRetryPolicyMaxElapsedTime(t *testing.T) {
+	policy, sleeps := getTestRetryPolicy(0, 4*time.Second)
+	err := policy.Do(func() error { return syscall.ECONNREFUSED }, nil)
+	var exhausted *RetriesExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("Got error %v, expected exhausted", err)
+	}
+	// 1s + 2s fit in 4s, the next 4s wait doesn't
+	if len(*sleeps) != 2 {
+		t.Errorf("Got sleeps %v, expected [1s 2s]", *sleeps)
+	}
+}
+
+func TestRetryPolicyHonoursRetryAfter(t *testing.T) {
+	policy, sleeps := getTestRetryPolicy(2, 0)
+	policy.Do(func() error {
+		return &RetryError{StatusCode: 429, RetryAfter: 7}
+	}, nil)
+	if len(*sleeps) != 1 || (*sleeps)[0] != 7*time.Second {
+		t.Errorf("Got sleeps %v, expected [7s]", *sleeps)
+	}
+}
+
+func TestRetryPolicyDoesNotRetry(t *testing.T) {
+	policy, _ := getTestRetryPolicy(5, 0)
+	policy.RetryOn = RetryThrottled
+
+	for _, returned := range []error{
+		errors.New("something else"),
+		&Error{StatusCode: 404},
+		syscall.ECONNRESET,
+		&RetriesExhaustedError{3, &RetryError{StatusCode: 429}},
+	} {
+		attempts := 0
+		err := policy.Do(func() error {
+			attempts++
+			return returned
+		}, nil)
+		if attempts != 1 {
+			t.Errorf("Retried %v %d times", returned, attempts-1)
+		}
+		if err != returned {
+			t.Errorf("Got error %v, expected %v", err, returned)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	for _, testCase := range []struct {
+		err      error
+		expected ErrorClass
+	}{
+		{&RetryError{StatusCode: 429}, RetryThrottled},
+		{&RetryError{StatusCode: 503}, RetryServerErrors},
+		{&Error{StatusCode: 400}, 0},
+		{&net.DNSError{Err: "no such host", Name: "foo"}, RetryDNSErrors},
+		{&net.DNSError{IsTimeout: true}, RetryTimeouts},
+		{fmt.Errorf("wrapped: %w", syscall.ECONNRESET), RetryConnectionErrors},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, RetryConnectionErrors},
+		{io.EOF, RetryConnectionErrors},
+		{errors.New("unknown"), 0},
+	} {
+		result := ClassifyError(testCase.err)
+		if result != testCase.expected {
+			t.Errorf("Got class %d for %v, expected %d",
+				result, testCase.err, testCase.expected)
+		}
+	}
+}
+
+func TestParseErrorClasses(t *testing.T) {
+	for _, testCase := range []struct {
+		value    string
+		expected ErrorClass
+	}{
+		{"all", RetryAll},
+		{"none", 0},
+		{"throttled", RetryThrottled},
+		{"throttled, server_errors", RetryThrottled | RetryServerErrors},
+		{"timeouts,dns_errors", RetryTimeouts | RetryDNSErrors},
+	} {
+		result, err := ParseErrorClasses(testCase.value)
+		if err != nil {
+			t.Errorf("Got error %s for '%s'", err, testCase.value)
+		}
+		if result != testCase.expected {
+			t.Errorf("Got class %s for '%s', expected %s",
+				result, testCase.value, testCase.expected)
+		}
+		parsed, _ := ParseErrorClasses(result.String())
+		if parsed != result {
+			t.Errorf("'%s' doesn't parse back to %d", result, result)
+		}
+	}
+
+	_, err := ParseErrorClasses("throttled,everything")
+	if err == nil {
+		t.Error("Expected error for unknown class 'everything'")
+	}
+}
+
+func TestConnectionRetriesServerErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(503)
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			_, _ = w.Write([]byte(
+				`{"data": {"type": "students", "id": "1"}}`,
+			))
+		},
+	))
+	defer server.Close()
+
+	policy, sleeps := getTestRetryPolicy(5, 0)
+	api := Connection{Host: server.URL, RetryPolicy: policy}
+	student, err := api.Get("students", "1")
+	if err != nil {
+		t.Fatalf("Got error %s", err)
+	}
+	if student.Id != "1" {
+		t.Errorf("Got student %+v", student)
+	}
+	if requests != 3 || len(*sleeps) != 2 {
+		t.Errorf("Got %d requests and sleeps %v, expected 3 and 2 sleeps",
+			requests, *sleeps)
+	}
+}
+
+func TestConnectionDoesNotRetryBrokenPost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			hijacker, _ := w.(http.Hijacker)
+			conn, _, _ := hijacker.Hijack()
+			conn.Close()
+		},
+	))
+	defer server.Close()
+
+	policy, sleeps := getTestRetryPolicy(5, 0)
+	api := Connection{Host: server.URL, RetryPolicy: policy}
+	_, err := api.request("POST", "/students", []byte("{}"), "")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("Retried a POST that may have been processed: %v", *sleeps)
+	}
+	if ClassifyError(err) != 0 {
+		t.Errorf("Callers could retry the POST, got class %d", ClassifyError(err))
+	}
+
+	// Without a policy too
+	_, err = (&Connection{Host: server.URL}).request(
+		"POST", "/students", []byte("{}"), "",
+	)
+	if err == nil || ClassifyError(err) != 0 {
+		t.Errorf("Got error %v with class %d", err, ClassifyError(err))
+	}
+
+	_, err = api.request("GET", "/students", nil, "")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if len(*sleeps) != 4 {
+		t.Errorf("Got sleeps %v, expected 4", *sleeps)
+	}
+}
+
+// Responds with a body that breaks while being read
+type brokenBodyTransport struct {
+	closed int
+}
+
+func (transport *brokenBodyTransport) RoundTrip(
+	request *http.Request,
+) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+		Body:       &brokenBody{transport},
+		Request:    request,
+	}, nil
+}
+
+type brokenBody struct {
+	transport *brokenBodyTransport
+}
+
+func (body *brokenBody) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func (body *brokenBody) Close() error {
+	body.transport.closed++
+	return nil
+}
+
+func TestConnectionClosesBrokenBodies(t *testing.T) {
+	transport := &brokenBodyTransport{}
+	policy, _ := getTestRetryPolicy(3, 0)
+	api := Connection{
+		Host:        "https://foo.com",
+		Client:      http.Client{Transport: transport},
+		RetryPolicy: policy,
+	}
+	_, err := api.Get("students", "1")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if transport.closed != 3 {
+		t.Errorf("Closed %d response bodies, expected 3", transport.closed)
+	}
+}
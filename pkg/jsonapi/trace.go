@@ -0,0 +1,393 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+HttpTracer
+Logs every request a Connection sends and the response it gets back, meant for
+debugging failed API calls. Credentials are never written out: the
+'Authorization' header is redacted and so are the signatures of presigned URLs
+(for example the ones async downloads redirect to). All the exchanges are also
+kept in memory so that they can be saved as a HAR file with 'WriteHar'.
+
+	tracer := jsonapi.NewHttpTracer(os.Stderr)
+	api := jsonapi.Connection{
+		Host:   "https://foo.com",
+		Token:  "XXX",
+		Tracer: tracer,
+	}
+	...
+	file, _ := os.Create("tx.har")
+	tracer.WriteHar(file, "tx", "1.0.0")
+
+Set 'Writer' to nil to only record the HAR entries.
+*/
+type HttpTracer struct {
+	Writer io.Writer
+	// Bodies longer than this are truncated, both in the log and in the HAR
+	MaxBodyLength int
+
+	entries []harEntry
+	mutex   sync.Mutex
+}
+
+func NewHttpTracer(writer io.Writer) *HttpTracer {
+	return &HttpTracer{Writer: writer, MaxBodyLength: 4096}
+}
+
+// Headers that are interesting enough to be shown in the log; the HAR file
+// gets all of them
+var tracedHeaders = []string{
+	"Content-Type", "Authorization", "Location", "Retry-After",
+	"X-Request-Id",
+}
+
+// Query parameters of presigned URLs that act as credentials, matched
+// case-insensitively as substrings of the parameter name
+var sensitiveQueryParameters = []string{
+	"signature", "credential", "token", "key-pair-id", "policy",
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s"'<>\\]+`)
+
+const redacted = "REDACTED"
+
+/*
+Record an exchange. 'response' and 'responseBody' are nil if the request
+failed, in which case 'err' says why.
+*/
+func (tracer *HttpTracer) trace(
+	request *http.Request,
+	requestBody []byte,
+	response *http.Response,
+	responseBody []byte,
+	started time.Time,
+	err error,
+) {
+	elapsed := time.Since(started)
+	entry := tracer.makeHarEntry(
+		request, requestBody, response, responseBody, started, elapsed, err,
+	)
+
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	tracer.entries = append(tracer.entries, entry)
+	if tracer.Writer == nil {
+		return
+	}
+
+	// Build the whole block first so that exchanges from parallel workers
+	// don't get mixed up
+	var log strings.Builder
+	fmt.Fprintf(&log, "--> %s %s\n", request.Method, entry.Request.Url)
+	writeTracedHeaders(&log, request.Header)
+	if entry.Request.PostData != nil {
+		writeTracedBody(&log, request.Header.Get("Content-Type"),
+			entry.Request.PostData.Text, len(requestBody))
+	}
+	if response == nil {
+		fmt.Fprintf(&log, "<-- error (%s) %s %s: %s\n",
+			elapsed.Round(time.Millisecond), request.Method,
+			entry.Request.Url, RedactText(err.Error()))
+	} else {
+		fmt.Fprintf(&log, "<-- %s (%s) %s %s\n",
+			response.Status, elapsed.Round(time.Millisecond), request.Method,
+			entry.Request.Url)
+		writeTracedHeaders(&log, response.Header)
+		writeTracedBody(&log, response.Header.Get("Content-Type"),
+			entry.Response.Content.Text, len(responseBody))
+	}
+	fmt.Fprintln(tracer.Writer, log.String())
+}
+
+func writeTracedHeaders(log *strings.Builder, headers http.Header) {
+	for _, name := range tracedHeaders {
+		value := headers.Get(name)
+		if value != "" {
+			fmt.Fprintf(log, "    %s: %s\n", name, redactHeader(name, value))
+		}
+	}
+}
+
+func writeTracedBody(
+	log *strings.Builder, contentType, text string, length int,
+) {
+	if length == 0 {
+		return
+	}
+	if !isTextContent(contentType) {
+		fmt.Fprintf(log, "    (%d bytes of %s)\n", length, contentType)
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(log, "    %s\n", line)
+	}
+}
+
+func isTextContent(contentType string) bool {
+	return strings.Contains(contentType, "json") ||
+		strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "x-www-form-urlencoded")
+}
+
+/*
+WriteHar
+Write everything recorded so far to 'writer' as a HAR 1.2 document. 'creator'
+and 'version' identify the application that made the requests.
+*/
+func (tracer *HttpTracer) WriteHar(
+	writer io.Writer, creator, version string,
+) error {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+
+	entries := tracer.entries
+	if entries == nil {
+		entries = []harEntry{}
+	}
+	document := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: creator, Version: version},
+		Entries: entries,
+	}}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func (tracer *HttpTracer) truncate(body []byte) string {
+	if tracer.MaxBodyLength > 0 && len(body) > tracer.MaxBodyLength {
+		return fmt.Sprintf("%s... (%d more bytes)",
+			body[:tracer.MaxBodyLength], len(body)-tracer.MaxBodyLength)
+	}
+	return string(body)
+}
+
+func redactHeader(name, value string) string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization", "Cookie", "Set-Cookie":
+		parts := strings.SplitN(value, " ", 2)
+		if len(parts) == 2 {
+			return parts[0] + " " + redacted
+		}
+		return redacted
+	case "Location":
+		return RedactUrl(value)
+	}
+	return value
+}
+
+/*
+RedactUrl
+Replace the values of query parameters that look like credentials (as found in
+presigned S3/CloudFront URLs) and any password in the URL with "REDACTED"
+*/
+func RedactUrl(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	changed := false
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+		changed = true
+	}
+	query := parsed.Query()
+	for name := range query {
+		lowerName := strings.ToLower(name)
+		for _, sensitive := range sensitiveQueryParameters {
+			if strings.Contains(lowerName, sensitive) || lowerName == "sig" {
+				query.Set(name, redacted)
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return rawUrl
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+/*
+RedactText
+Redact all the URLs that appear in 'text' with RedactUrl
+*/
+func RedactText(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, RedactUrl)
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Custom field (allowed by the spec) for requests that got no response
+	Error string `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func (tracer *HttpTracer) makeHarEntry(
+	request *http.Request,
+	requestBody []byte,
+	response *http.Response,
+	responseBody []byte,
+	started time.Time,
+	elapsed time.Duration,
+	err error,
+) harEntry {
+	milliseconds := float64(elapsed) / float64(time.Millisecond)
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      request.Method,
+			Url:         RedactUrl(request.URL.String()),
+			HttpVersion: request.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(request.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{Wait: milliseconds},
+	}
+	parsed, parseErr := url.Parse(entry.Request.Url)
+	if parseErr == nil {
+		for name, values := range parsed.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(
+					entry.Request.QueryString, harNameValue{name, value},
+				)
+			}
+		}
+	}
+	if len(requestBody) > 0 {
+		mimeType := request.Header.Get("Content-Type")
+		text := ""
+		if isTextContent(mimeType) {
+			text = RedactText(tracer.truncate(requestBody))
+		}
+		entry.Request.PostData = &harPostData{MimeType: mimeType, Text: text}
+	}
+
+	if response == nil {
+		if err != nil {
+			entry.Error = RedactText(err.Error())
+		}
+		return entry
+	}
+	mimeType := response.Header.Get("Content-Type")
+	entry.Response.Status = response.StatusCode
+	entry.Response.StatusText = strings.TrimSpace(
+		strings.TrimPrefix(response.Status, fmt.Sprint(response.StatusCode)),
+	)
+	entry.Response.HttpVersion = response.Proto
+	entry.Response.Headers = harHeaders(response.Header)
+	entry.Response.RedirectURL = RedactUrl(response.Header.Get("Location"))
+	entry.Response.BodySize = len(responseBody)
+	entry.Response.Content = harContent{
+		Size:     len(responseBody),
+		MimeType: mimeType,
+	}
+	if isTextContent(mimeType) {
+		entry.Response.Content.Text = RedactText(tracer.truncate(responseBody))
+	}
+	return entry
+}
+
+func harHeaders(headers http.Header) []harNameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []harNameValue{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			result = append(result, harNameValue{name, redactHeader(name, value)})
+		}
+	}
+	return result
+}
@@ -1,6 +1,8 @@
 package txapi
 
 import (
+	"context"
+
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -17,16 +19,17 @@ func GetI18nFormats(
 	query := jsonapi.Query{Filters: map[string]string{
 		"organization": organization.Id,
 	}}.Encode()
-	i18nFormats, err := api.List("i18n_formats", query)
+	i18nFormats, err := api.ListAll(
+		context.Background(), "i18n_formats", query, jsonapi.ListOptions{},
+	)
 	if err != nil {
 		return nil, err
 	}
 
 	result := make(map[string]*jsonapi.Resource)
 
-	for i := range i18nFormats.Data {
+	for _, i18nFormat := range i18nFormats {
 		var i18nFormatsAttributes I18nFormatsAttributes
-		var i18nFormat = &i18nFormats.Data[i]
 		err := i18nFormat.MapAttributes(&i18nFormatsAttributes)
 		if err != nil {
 			return nil, err
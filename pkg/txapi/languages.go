@@ -1,6 +1,7 @@
 package txapi
 
 import (
+	"context"
 	"sync"
 
 	"github.com/transifex/cli/pkg/jsonapi"
@@ -30,14 +31,15 @@ var GetLanguages = func() func(api *jsonapi.Connection) (map[string]*jsonapi.Res
 
 	return func(api *jsonapi.Connection) (map[string]*jsonapi.Resource, error) {
 		once.Do(func() {
-			collection, err := api.List("languages", "")
+			languages, err := api.ListAll(
+				context.Background(), "languages", "", jsonapi.ListOptions{},
+			)
 			if err != nil {
 				result = nil
 				resultErr = err
 				return
 			}
-			for i := range collection.Data {
-				language := collection.Data[i]
+			for _, language := range languages {
 				var languageAttributes LanguageAttributes
 				err = language.MapAttributes(&languageAttributes)
 				if err != nil {
@@ -45,7 +47,7 @@ var GetLanguages = func() func(api *jsonapi.Connection) (map[string]*jsonapi.Res
 					resultErr = err
 					return
 				}
-				result[languageAttributes.Code] = &language
+				result[languageAttributes.Code] = language
 			}
 		})
 		return result, resultErr
@@ -55,19 +57,13 @@ var GetLanguages = func() func(api *jsonapi.Connection) (map[string]*jsonapi.Res
 func GetLanguage(
 	api *jsonapi.Connection, code string,
 ) (*jsonapi.Resource, error) {
-	languages, err := api.List("languages", "")
-	if err != nil {
-		return nil, err
-	}
-
-	var language *jsonapi.Resource
-
-	for _, l := range languages.Data {
-		if l.Attributes["code"] == code {
-			language = &l
-			break
+	languages := api.Iterate(
+		context.Background(), "languages", "", jsonapi.ListOptions{},
+	)
+	for languages.Next() {
+		if languages.Resource().Attributes["code"] == code {
+			return languages.Resource(), nil
 		}
 	}
-
-	return language, nil
+	return nil, languages.Err()
 }
@@ -1,6 +1,8 @@
 package txapi
 
 import (
+	"context"
+
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -14,63 +16,38 @@ type OrganizationAttributes struct {
 func GetOrganization(
 	api *jsonapi.Connection, organizationSlug string,
 ) (*jsonapi.Resource, error) {
-	page, err := api.List("organizations", "")
-	if err != nil {
-		return nil, err
-	}
-
-	for { // pagination
-		for i := range page.Data {
-			organization := page.Data[i]
-			var organizationAttributes OrganizationAttributes
-			err := organization.MapAttributes(&organizationAttributes)
-			if err != nil {
-				return nil, err
-			}
-			if organizationAttributes.Slug == organizationSlug {
-				return &organization, nil
-			}
+	organizations := api.Iterate(
+		context.Background(), "organizations", "", jsonapi.ListOptions{},
+	)
+	for organizations.Next() {
+		organization := organizations.Resource()
+		var organizationAttributes OrganizationAttributes
+		err := organization.MapAttributes(&organizationAttributes)
+		if err != nil {
+			return nil, err
 		}
-		if page.Next != "" {
-			page, err = page.GetNext()
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, nil
+		if organizationAttributes.Slug == organizationSlug {
+			return organization, nil
 		}
 	}
+	return nil, organizations.Err()
 }
 
 func GetOrganizations(api *jsonapi.Connection) (
 	[]*jsonapi.Resource, error,
 ) {
-
-	organizations, err := api.List("organizations", "")
+	result, err := api.ListAll(
+		context.Background(), "organizations", "", jsonapi.ListOptions{},
+	)
 	if err != nil {
 		return nil, err
 	}
-	var result []*jsonapi.Resource
-
-	for {
-		for i := range organizations.Data {
-			var organizationAttributes OrganizationAttributes
-			var organization = organizations.Data[i]
-			err := organization.MapAttributes(&organizationAttributes)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, &organization)
-		}
-		if organizations.Next == "" {
-			break
-		} else {
-			organizations, err = organizations.GetNext()
-			if err != nil {
-				return nil, err
-			}
+	for _, organization := range result {
+		var organizationAttributes OrganizationAttributes
+		err := organization.MapAttributes(&organizationAttributes)
+		if err != nil {
+			return nil, err
 		}
 	}
-
 	return result, nil
 }
@@ -1,8 +1,10 @@
 package txapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/transifex/cli/pkg/jsonapi"
 )
@@ -31,34 +33,20 @@ func GetProjects(
 	query := jsonapi.Query{Filters: map[string]string{
 		"organization": organization.Id,
 	}}.Encode()
-	projects, err := api.List("projects", query)
+	result, err := api.ListAll(
+		context.Background(), "projects", query, jsonapi.ListOptions{},
+	)
 	if err != nil {
 		return nil, err
 	}
-
-	var result []*jsonapi.Resource
-
-	for {
-		for i := range projects.Data {
-			var projectAttributes ProjectAttributes
-			var project = projects.Data[i]
-			err := project.MapAttributes(&projectAttributes)
-			if err != nil {
-				return nil, err
-			}
-			project.SetRelated("organization", organization)
-			result = append(result, &project)
-		}
-		if projects.Next == "" {
-			break
-		} else {
-			projects, err = projects.GetNext()
-			if err != nil {
-				return nil, err
-			}
+	for _, project := range result {
+		var projectAttributes ProjectAttributes
+		err := project.MapAttributes(&projectAttributes)
+		if err != nil {
+			return nil, err
 		}
+		project.SetRelated("organization", organization)
 	}
-
 	return result, nil
 }
 
@@ -99,27 +87,19 @@ func GetProjectLanguages(
 	}
 
 	result := make(map[string]*jsonapi.Resource)
-	page := languagesRelationship.DataPlural
-	for {
-		for i := range page.Data {
-			language := page.Data[i]
-			var languageAttributes LanguageAttributes
-			err := language.MapAttributes(&languageAttributes)
-			if err != nil {
-				return nil, err
-			}
-			result[languageAttributes.Code] = &language
-		}
-		if page.Next == "" {
-			break
-		} else {
-			page, err = page.GetNext()
-			if err != nil {
-				return nil, err
-			}
+	languages := languagesRelationship.DataPlural.Iterate(
+		context.Background(), jsonapi.ListOptions{},
+	)
+	for languages.Next() {
+		language := languages.Resource()
+		var languageAttributes LanguageAttributes
+		err := language.MapAttributes(&languageAttributes)
+		if err != nil {
+			return nil, err
 		}
+		result[languageAttributes.Code] = language
 	}
-	return result, nil
+	return result, languages.Err()
 }
 
 func GetProjectById(api *jsonapi.Connection, id string) (*jsonapi.Resource, error) {
@@ -135,3 +115,141 @@ func GetProjectById(api *jsonapi.Connection, id string) (*jsonapi.Resource, erro
 	}
 	return &project, nil
 }
+
+/*
+ProjectUpdate
+The settings of a project to set with CreateProject or change with
+UpdateProject. Nil fields are left alone; use a pointer to the zero value to
+clear a field.
+*/
+type ProjectUpdate struct {
+	Name            *string
+	Description     *string
+	LongDescription *string
+	Private         *bool
+	Archived        *bool
+	Tags            *[]string
+	TMFillup        *bool
+	InstructionsURL *string
+	HomepageURL     *string
+	RepositoryURL   *string
+	License         *string
+}
+
+// Set the fields of 'update' on 'project' and return their names
+func (update ProjectUpdate) apply(project *jsonapi.Resource) []string {
+	if project.Attributes == nil {
+		project.Attributes = make(map[string]interface{})
+	}
+	var fields []string
+	set := func(field string, value interface{}) {
+		project.Attributes[field] = value
+		fields = append(fields, field)
+	}
+	for field, value := range map[string]*string{
+		"name":             update.Name,
+		"description":      update.Description,
+		"long_description": update.LongDescription,
+		"instructions_url": update.InstructionsURL,
+		"homepage_url":     update.HomepageURL,
+		"repository_url":   update.RepositoryURL,
+		"license":          update.License,
+	} {
+		if value != nil {
+			set(field, *value)
+		}
+	}
+	for field, value := range map[string]*bool{
+		"private":                   update.Private,
+		"archived":                  update.Archived,
+		"translation_memory_fillup": update.TMFillup,
+	} {
+		if value != nil {
+			set(field, *value)
+		}
+	}
+	if update.Tags != nil {
+		tags := *update.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		set("tags", tags)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+/*
+CreateProject
+Create a project in an organization. The project is private unless 'settings'
+says otherwise; public projects need a repository URL.
+*/
+func CreateProject(
+	api *jsonapi.Connection,
+	organization *jsonapi.Resource,
+	slug, sourceLanguageCode string,
+	settings ProjectUpdate,
+) (*jsonapi.Resource, error) {
+	project := &jsonapi.Resource{
+		API:        api,
+		Type:       "projects",
+		Attributes: map[string]interface{}{"slug": slug},
+	}
+	if settings.Name == nil {
+		settings.Name = &slug
+	}
+	if settings.Private == nil {
+		private := true
+		settings.Private = &private
+	}
+	fields := append(settings.apply(project), "slug")
+	project.SetRelated("organization", organization)
+	project.SetRelated("source_language", &jsonapi.Resource{
+		Type: "languages", Id: fmt.Sprintf("l:%s", sourceLanguageCode),
+	})
+	err := project.Save(append(fields, "organization", "source_language"))
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+/*
+UpdateProject
+Change the settings of a project. Only the fields set in 'update' are sent to
+the server.
+*/
+func UpdateProject(project *jsonapi.Resource, update ProjectUpdate) error {
+	fields := update.apply(project)
+	if len(fields) == 0 {
+		return errors.New("nothing to update")
+	}
+	return project.Save(fields)
+}
+
+func languageResources(codes []string) []*jsonapi.Resource {
+	result := make([]*jsonapi.Resource, 0, len(codes))
+	for _, code := range codes {
+		result = append(result, &jsonapi.Resource{
+			Type: "languages", Id: fmt.Sprintf("l:%s", code),
+		})
+	}
+	return result
+}
+
+/*
+AddProjectLanguages
+Add target languages to a project, by code
+*/
+func AddProjectLanguages(project *jsonapi.Resource, codes []string) error {
+	return project.Add("languages", languageResources(codes))
+}
+
+/*
+RemoveProjectLanguages
+Remove target languages from a project, by code. This deletes their
+translations.
+*/
+func RemoveProjectLanguages(project *jsonapi.Resource, codes []string) error {
+	return project.Remove("languages", languageResources(codes))
+}
@@ -1,6 +1,7 @@
 package txapi
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/transifex/cli/pkg/jsonapi"
@@ -259,3 +260,62 @@ func TestGetProjects(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateAndUpdateProject(t *testing.T) {
+	var payloads []map[string]interface{}
+	api := jsonapi.Connection{
+		RequestMethod: func(
+			method, path string, payload []byte, contentType string,
+		) ([]byte, error) {
+			var parsed struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			err := json.Unmarshal(payload, &parsed)
+			if err != nil {
+				return nil, err
+			}
+			parsed.Data["method"] = method
+			parsed.Data["path"] = path
+			payloads = append(payloads, parsed.Data)
+			return []byte(`{"data": {
+				"type": "projects",
+				"id": "o:orgslug:p:projslug",
+				"attributes": {"slug": "projslug", "private": true}
+			}}`), nil
+		},
+	}
+	organization := &jsonapi.Resource{Type: "organizations", Id: "o:orgslug"}
+	description := "The web app"
+	project, err := CreateProject(
+		&api, organization, "projslug", "en",
+		ProjectUpdate{Description: &description},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := payloads[0]["attributes"].(map[string]interface{})
+	relationships := payloads[0]["relationships"].(map[string]interface{})
+	if payloads[0]["method"] != "POST" || payloads[0]["path"] != "/projects" ||
+		attributes["name"] != "projslug" || attributes["private"] != true ||
+		attributes["description"] != "The web app" ||
+		relationships["source_language"] == nil {
+		t.Errorf("Got payload %+v", payloads[0])
+	}
+
+	tags := []string{}
+	tmFillup := false
+	err = UpdateProject(project, ProjectUpdate{Tags: &tags, TMFillup: &tmFillup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes = payloads[1]["attributes"].(map[string]interface{})
+	if payloads[1]["method"] != "PATCH" || len(attributes) != 2 ||
+		attributes["translation_memory_fillup"] != false {
+		t.Errorf("Got payload %+v", payloads[1])
+	}
+
+	err = UpdateProject(project, ProjectUpdate{})
+	if err == nil {
+		t.Error("Expected an error for an empty update")
+	}
+}
@@ -1,6 +1,8 @@
 package txapi
 
 import (
+	"context"
+
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -31,25 +33,31 @@ func GetResourceStats(
 	if language != nil {
 		query.Filters["language"] = language.Id
 	}
-	page, err := api.List("resource_language_stats", query.Encode())
+	stats, err := api.ListAll(
+		context.Background(), "resource_language_stats", query.Encode(),
+		jsonapi.ListOptions{},
+	)
 	if err != nil {
 		return nil, err
 	}
 	result := make(map[string]*jsonapi.Resource)
-	for {
-		for i := range page.Data {
-			stats := page.Data[i]
-			stats.SetRelated("resource", resource)
-			result[stats.Relationships["language"].DataSingular.Id] = &stats
-		}
-		if page.Next == "" {
-			break
-		} else {
-			page, err = page.GetNext()
-			if err != nil {
-				return nil, err
-			}
-		}
+	for _, item := range stats {
+		item.SetRelated("resource", resource)
+		result[item.Relationships["language"].DataSingular.Id] = item
 	}
 	return result, nil
 }
+
+/*
+GetProjectStats
+Return the stats of all the resources of a project, in all of its languages
+*/
+func GetProjectStats(
+	api *jsonapi.Connection, project *jsonapi.Resource,
+) ([]*jsonapi.Resource, error) {
+	query := jsonapi.Query{Filters: map[string]string{"project": project.Id}}
+	return api.ListAll(
+		context.Background(), "resource_language_stats", query.Encode(),
+		jsonapi.ListOptions{},
+	)
+}
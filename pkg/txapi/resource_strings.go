@@ -0,0 +1,169 @@
+package txapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+type ResourceStringAttributes struct {
+	AppearanceOrder          int               `json:"appearance_order"`
+	CharacterLimit           *int              `json:"character_limit"`
+	Context                  string            `json:"context"`
+	DatetimeCreated          string            `json:"datetime_created"`
+	DeveloperComment         string            `json:"developer_comment"`
+	Instructions             string            `json:"instructions"`
+	Key                      string            `json:"key"`
+	MetadataDatetimeModified string            `json:"metadata_datetime_modified"`
+	Occurrences              string            `json:"occurrences"`
+	Pluralized               bool              `json:"pluralized"`
+	StringHash               string            `json:"string_hash"`
+	Strings                  map[string]string `json:"strings"`
+	StringsDatetimeModified  string            `json:"strings_datetime_modified"`
+	Tags                     []string          `json:"tags"`
+}
+
+/*
+ResourceStringsFilter
+Narrows down the strings returned by GetResourceStrings. Empty fields are
+ignored.
+*/
+type ResourceStringsFilter struct {
+	Key string
+	// Only strings that have all of these tags
+	Tags []string
+	// Only strings whose text was modified after/before these ISO 8601
+	// timestamps
+	ModifiedAfter  string
+	ModifiedBefore string
+}
+
+/*
+ResourceStringUpdate
+The metadata of a string to change with UpdateResourceString. Nil fields are
+left alone; use a pointer to the zero value to clear a field.
+*/
+type ResourceStringUpdate struct {
+	Tags             *[]string
+	Context          *string
+	CharacterLimit   *int
+	DeveloperComment *string
+	Instructions     *string
+}
+
+func (filter ResourceStringsFilter) filters(
+	resource *jsonapi.Resource,
+) map[string]string {
+	result := map[string]string{"resource": resource.Id}
+	if filter.Key != "" {
+		result["key"] = filter.Key
+	}
+	if len(filter.Tags) > 0 {
+		result["tags__all"] = strings.Join(filter.Tags, ",")
+	}
+	if filter.ModifiedAfter != "" {
+		result["strings_date_modified__gte"] = filter.ModifiedAfter
+	}
+	if filter.ModifiedBefore != "" {
+		result["strings_date_modified__lte"] = filter.ModifiedBefore
+	}
+	return result
+}
+
+/*
+GetResourceStrings
+Return the source strings of a resource that match the filter, in the order
+they appear in the source file
+*/
+func GetResourceStrings(
+	api *jsonapi.Connection,
+	resource *jsonapi.Resource,
+	filter ResourceStringsFilter,
+	options jsonapi.ListOptions,
+) ([]*jsonapi.Resource, error) {
+	query := jsonapi.Query{Filters: filter.filters(resource)}.Encode()
+	result, err := api.ListAll(
+		context.Background(), "resource_strings", query, options,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, resourceString := range result {
+		var attributes ResourceStringAttributes
+		err := resourceString.MapAttributes(&attributes)
+		if err != nil {
+			return nil, err
+		}
+		resourceString.SetRelated("resource", resource)
+	}
+	return result, nil
+}
+
+/*
+GetResourceString
+Return the source string with the given id, or nil if it doesn't exist
+*/
+func GetResourceString(
+	api *jsonapi.Connection, id string,
+) (*jsonapi.Resource, error) {
+	resourceString, err := api.Get("resource_strings", id)
+	if err != nil {
+		var e *jsonapi.Error
+		if errors.As(err, &e) && e.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &resourceString, nil
+}
+
+/*
+UpdateResourceString
+Change the metadata of a source string. Only the fields set in 'update' are
+sent to the server.
+*/
+func UpdateResourceString(
+	resourceString *jsonapi.Resource, update ResourceStringUpdate,
+) error {
+	var fields []string
+	set := func(field string, value interface{}) {
+		resourceString.Attributes[field] = value
+		fields = append(fields, field)
+	}
+	if resourceString.Attributes == nil {
+		resourceString.Attributes = make(map[string]interface{})
+	}
+	if update.Tags != nil {
+		tags := *update.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		set("tags", tags)
+	}
+	if update.Context != nil {
+		set("context", *update.Context)
+	}
+	if update.CharacterLimit != nil {
+		if *update.CharacterLimit > 0 {
+			set("character_limit", *update.CharacterLimit)
+		} else {
+			set("character_limit", nil)
+		}
+	}
+	if update.DeveloperComment != nil {
+		set("developer_comment", *update.DeveloperComment)
+	}
+	if update.Instructions != nil {
+		set("instructions", *update.Instructions)
+	}
+	if len(fields) == 0 {
+		return errors.New("nothing to update")
+	}
+	return resourceString.Save(fields)
+}
+
+func DeleteResourceString(resourceString *jsonapi.Resource) error {
+	return resourceString.Delete()
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -34,16 +33,16 @@ func CreateResourceStringsAsyncDownload(
 }
 
 func PollResourceStringsDownload(download *jsonapi.Resource, filePath string) error {
-	backoff := getBackoff(nil)
+	pollInterval := getPollInterval()
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
+		time.Sleep(pollInterval())
 		err := download.Reload()
 		if err != nil {
 			return err
 		}
 
 		if download.Redirect != "" {
-			resp, err := http.Get(download.Redirect)
+			resp, err := getRedirect(download)
 			if err != nil {
 				return err
 			}
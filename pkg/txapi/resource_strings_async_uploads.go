@@ -68,9 +68,9 @@ func UploadSource(
 }
 
 func PollSourceUpload(upload *jsonapi.Resource) error {
-	backoff := getBackoff(nil)
+	pollInterval := getPollInterval()
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
+		time.Sleep(pollInterval())
 		err := upload.Reload()
 		if err != nil {
 			return err
@@ -0,0 +1,119 @@
+package txapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+// Maximum number of translations the API accepts in a single bulk update
+const ResourceTranslationsBulkLimit = 150
+
+type ResourceTranslationAttributes struct {
+	// Plural form -> text; nil if the string is not translated
+	Strings            map[string]string `json:"strings"`
+	Reviewed           bool              `json:"reviewed"`
+	Proofread          bool              `json:"proofread"`
+	Finalized          bool              `json:"finalized"`
+	Origin             string            `json:"origin"`
+	DatetimeCreated    string            `json:"datetime_created"`
+	DatetimeTranslated string            `json:"datetime_translated"`
+	DatetimeReviewed   string            `json:"datetime_reviewed"`
+	DatetimeProofread  string            `json:"datetime_proofread"`
+}
+
+/*
+ResourceTranslationsFilter
+Narrows down the translations returned by GetResourceTranslations. Nil and
+empty fields are ignored.
+*/
+type ResourceTranslationsFilter struct {
+	// The key of the source string
+	Key        string
+	Translated *bool
+	Reviewed   *bool
+	// Only translations made after this ISO 8601 timestamp
+	ModifiedSince string
+}
+
+func (filter ResourceTranslationsFilter) filters(
+	resource *jsonapi.Resource, languageCode string,
+) map[string]string {
+	result := map[string]string{
+		"resource": resource.Id,
+		"language": fmt.Sprintf("l:%s", languageCode),
+	}
+	if filter.Key != "" {
+		result["resource_string__key"] = filter.Key
+	}
+	if filter.Translated != nil {
+		result["translated"] = fmt.Sprint(*filter.Translated)
+	}
+	if filter.Reviewed != nil {
+		result["reviewed"] = fmt.Sprint(*filter.Reviewed)
+	}
+	if filter.ModifiedSince != "" {
+		result["date_translated__gte"] = filter.ModifiedSince
+	}
+	return result
+}
+
+/*
+GetResourceTranslations
+Return the translations of a resource's strings in a language, in the order
+the strings appear in the source file. The source strings are fetched along
+and can be found in the "resource_string" relationship of each translation.
+*/
+func GetResourceTranslations(
+	api *jsonapi.Connection,
+	resource *jsonapi.Resource,
+	languageCode string,
+	filter ResourceTranslationsFilter,
+	options jsonapi.ListOptions,
+) ([]*jsonapi.Resource, error) {
+	query := jsonapi.Query{
+		Filters:  filter.filters(resource, languageCode),
+		Includes: []string{"resource_string"},
+	}.Encode()
+	result, err := api.ListAll(
+		context.Background(), "resource_translations", query, options,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, translation := range result {
+		var attributes ResourceTranslationAttributes
+		err := translation.MapAttributes(&attributes)
+		if err != nil {
+			return nil, err
+		}
+		translation.SetRelated("resource", resource)
+	}
+	return result, nil
+}
+
+/*
+UpdateResourceTranslations
+Save the "strings", "reviewed" and "proofread" attributes of many translations,
+in as few requests as the API allows. Setting "strings" to nil removes a
+translation.
+*/
+func UpdateResourceTranslations(
+	api *jsonapi.Connection, translations []*jsonapi.Resource,
+) error {
+	fields := []string{"strings", "reviewed", "proofread"}
+	for start := 0; start < len(translations); start += ResourceTranslationsBulkLimit {
+		end := start + ResourceTranslationsBulkLimit
+		if end > len(translations) {
+			end = len(translations)
+		}
+		err := api.BulkUpdate(
+			"resource_translations", translations[start:end], fields,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
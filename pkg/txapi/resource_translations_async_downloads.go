@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -40,9 +39,9 @@ func CreateTranslationsAsyncDownload(
 }
 
 func PollTranslationDownload(download *jsonapi.Resource, filePath string) error {
-	backoff := getBackoff(nil)
+	pollInterval := getPollInterval()
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
+		time.Sleep(pollInterval())
 		err := download.Reload()
 		if err != nil {
 			return err
@@ -56,7 +55,7 @@ func PollTranslationDownload(download *jsonapi.Resource, filePath string) error
 			)
 		}
 	}
-	resp, err := http.Get(download.Redirect)
+	resp, err := getRedirect(download)
 	if err != nil {
 		return err
 	}
@@ -73,9 +73,9 @@ func (err *ResourceTranslationsAsyncUploadAttributes) Error() string {
 }
 
 func PollTranslationUpload(upload *jsonapi.Resource) error {
-	backoff := getBackoff(nil)
+	pollInterval := getPollInterval()
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
+		time.Sleep(pollInterval())
 		err := upload.Reload()
 		if err != nil {
 			return err
@@ -1,7 +1,10 @@
 package txapi
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/transifex/cli/pkg/jsonapi"
@@ -33,36 +36,20 @@ func GetResources(
 	query := jsonapi.Query{Filters: map[string]string{
 		"project": project.Id,
 	}}.Encode()
-
-	resources, err := api.List("resources", query)
-
+	result, err := api.ListAll(
+		context.Background(), "resources", query, jsonapi.ListOptions{},
+	)
 	if err != nil {
 		return nil, err
 	}
-
-	var result []*jsonapi.Resource
-
-	for {
-		for i := range resources.Data {
-			var resourceAttributes ResourceAttributes
-			var resource = resources.Data[i]
-			err := resource.MapAttributes(&resourceAttributes)
-			if err != nil {
-				return nil, err
-			}
-			resource.SetRelated("project", project)
-			result = append(result, &resource)
-		}
-		if resources.Next == "" {
-			break
-		} else {
-			resources, err = resources.GetNext()
-			if err != nil {
-				return nil, err
-			}
+	for _, resource := range result {
+		var resourceAttributes ResourceAttributes
+		err := resource.MapAttributes(&resourceAttributes)
+		if err != nil {
+			return nil, err
 		}
+		resource.SetRelated("project", project)
 	}
-
 	return result, nil
 }
 
@@ -72,33 +59,22 @@ func GetResource(
 	query := jsonapi.Query{Filters: map[string]string{
 		"project": project.Id,
 	}}.Encode()
-	resources, err := api.List("resources", query)
-	if err != nil {
-		return nil, err
-	}
-
-	for { // pagination
-		for i := range resources.Data {
-			resource := resources.Data[i]
-			var resourceAttributes ResourceAttributes
-			err := resource.MapAttributes(&resourceAttributes)
-			if err != nil {
-				return nil, err
-			}
-			if resourceAttributes.Slug == resourceSlug {
-				resource.SetRelated("project", project)
-				return &resource, nil
-			}
+	resources := api.Iterate(
+		context.Background(), "resources", query, jsonapi.ListOptions{},
+	)
+	for resources.Next() {
+		resource := resources.Resource()
+		var resourceAttributes ResourceAttributes
+		err := resource.MapAttributes(&resourceAttributes)
+		if err != nil {
+			return nil, err
 		}
-		if resources.Next != "" {
-			resources, err = resources.GetNext()
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, nil
+		if resourceAttributes.Slug == resourceSlug {
+			resource.SetRelated("project", project)
+			return resource, nil
 		}
 	}
+	return nil, resources.Err()
 }
 
 func CreateResource(
@@ -166,8 +142,14 @@ func DeleteResource(
 	return nil
 }
 
+/*
+GetResourceById
+Return the resource with the given id, or nil if it doesn't exist. Its project
+comes in the same response, so fetching the 'project' relationship is free.
+*/
 func GetResourceById(api *jsonapi.Connection, id string) (*jsonapi.Resource, error) {
-	resource, err := api.Get("resources", id)
+	query := jsonapi.Query{Includes: []string{"project"}}.Encode()
+	resource, err := api.GetWithQuery("resources", id, query)
 	if err != nil {
 		var e *jsonapi.Error
 		if errors.As(err, &e) {
@@ -180,6 +162,34 @@ func GetResourceById(api *jsonapi.Connection, id string) (*jsonapi.Resource, err
 	return &resource, nil
 }
 
+type ResourceAsyncMergeAttributes struct {
+	Status             string `json:"status"`
+	ConflictResolution string `json:"conflict_resolution"`
+	Force              bool   `json:"force"`
+	Errors             []struct {
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+func (err *ResourceAsyncMergeAttributes) Error() string {
+	parts := make([]string, 0, len(err.Errors))
+	for _, item := range err.Errors {
+		parts = append(parts,
+			fmt.Sprintf("%s: %s", item.Code, item.Detail))
+	}
+	if len(parts) == 0 {
+		return "merge failed"
+	}
+	return strings.Join(parts, ", ")
+}
+
+/*
+PollResourceMerge
+Wait until a merge is completed. If it fails, the returned error wraps the
+merge's attributes (*ResourceAsyncMergeAttributes) so that callers can inspect
+its error codes.
+*/
 func PollResourceMerge(
 	merge *jsonapi.Resource,
 	duration time.Duration,
@@ -190,8 +200,15 @@ func PollResourceMerge(
 			return err
 		}
 
-		if merge.Attributes["status"] == "COMPLETED" {
+		var attributes ResourceAsyncMergeAttributes
+		err = merge.MapAttributes(&attributes)
+		if err != nil {
+			return err
+		}
+		if attributes.Status == "COMPLETED" {
 			return nil
+		} else if attributes.Status == "FAILED" {
+			return fmt.Errorf("merge failed - %w", &attributes)
 		}
 		time.Sleep(duration)
 	}
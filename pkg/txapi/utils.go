@@ -1,33 +1,47 @@
 package txapi
 
+import (
+	"net/http"
+	"time"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
 /*
-Return a function that returns the next item from 'pool' every time. When 'pool' runs
-out, keep returning the last item forever.
+Return a function that returns how long to wait before each poll of an async
+job. Starts from about a second and grows exponentially (with some jitter so
+that parallel workers don't poll in lockstep) up to about 13 seconds.
 
-    backoff := getBackoff([]int{1, 2, 3})
-    fmt.Println(backoff())
-    // <<< 1
-    fmt.Println(backoff())
-    // <<< 2
-    fmt.Println(backoff())
-    // <<< 3
-    fmt.Println(backoff())
-    // <<< 3
-    fmt.Println(backoff())
-    // <<< 3
-    // ...
+	pollInterval := getPollInterval()
+	for {
+		time.Sleep(pollInterval())
+		// Reload the job...
+	}
 */
-func getBackoff(pool []int) func() int {
-	if pool == nil {
-		pool = []int{1, 1, 1, 2, 3, 5, 8, 13}
+func getPollInterval() func() time.Duration {
+	policy := jsonapi.RetryPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     13 * time.Second,
+		Multiplier:      1.5,
+		Jitter:          0.2,
+	}
+	attempt := 0
+	return func() time.Duration {
+		attempt++
+		return policy.Backoff(attempt)
 	}
-	i := -1
-	return func() int {
-		i++
-		if i < len(pool) {
-			return pool[i]
-		} else {
-			return pool[len(pool)-1]
-		}
+}
+
+/*
+Download the file an async download job redirected us to. Uses the HTTP client
+of the job's connection (so that custom CA certificates and transports apply)
+but, unlike the connection, follows redirects.
+*/
+func getRedirect(download *jsonapi.Resource) (*http.Response, error) {
+	client := http.Client{}
+	if download.API != nil {
+		client = download.API.Client
+		client.CheckRedirect = nil
 	}
+	return client.Get(download.Redirect)
 }
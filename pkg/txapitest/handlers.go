@@ -0,0 +1,808 @@
+package txapitest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A {json:api} resource object, ready to be encoded
+type object map[string]interface{}
+
+func identifier(Type, id string) map[string]string {
+	return map[string]string{"type": Type, "id": id}
+}
+
+func (ex *exchange) url(format string, args ...interface{}) string {
+	return ex.baseUrl + fmt.Sprintf(format, args...)
+}
+
+// Organizations
+
+func (ex *exchange) organizationObject(organization *Organization) object {
+	return object{
+		"type": "organizations",
+		"id":   organization.Id,
+		"attributes": map[string]interface{}{
+			"slug":     organization.Slug,
+			"name":     organization.Name,
+			"private":  organization.Private,
+			"logo_url": "",
+		},
+		"links": map[string]string{
+			"self": ex.url("/organizations/%s", organization.Id),
+		},
+	}
+}
+
+func (ex *exchange) listOrganizations() {
+	store := ex.server.Store
+	items := []object{}
+	for _, id := range sortedKeys(store.Organizations) {
+		items = append(items, ex.organizationObject(store.Organizations[id]))
+	}
+	ex.respondPage(items)
+}
+
+func (ex *exchange) getOrganization(id string) {
+	organization, exists := ex.server.Store.Organizations[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.organizationObject(organization))
+}
+
+// Projects
+
+func (ex *exchange) projectObject(project *Project) object {
+	tags := project.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return object{
+		"type": "projects",
+		"id":   project.Id,
+		"attributes": map[string]interface{}{
+			"slug":                      project.Slug,
+			"name":                      project.Name,
+			"private":                   project.Private,
+			"archived":                  project.Archived,
+			"type":                      "file",
+			"description":               project.Description,
+			"long_description":          project.LongDescription,
+			"tags":                      tags,
+			"translation_memory_fillup": project.TMFillup,
+			"instructions_url":          project.InstructionsURL,
+			"homepage_url":              project.HomepageURL,
+			"repository_url":            project.RepositoryURL,
+			"license":                   project.License,
+			"datetime_created":          project.Created,
+			"datetime_modified":         project.Modified,
+		},
+		"relationships": map[string]interface{}{
+			"organization": map[string]interface{}{
+				"data": identifier("organizations", project.OrganizationId),
+				"links": map[string]string{
+					"related": ex.url("/organizations/%s", project.OrganizationId),
+				},
+			},
+			"source_language": map[string]interface{}{
+				"data": identifier("languages", languageId(project.SourceLanguage)),
+				"links": map[string]string{
+					"related": ex.url("/languages/%s",
+						languageId(project.SourceLanguage)),
+				},
+			},
+			"languages": map[string]interface{}{
+				"links": map[string]string{
+					"self": ex.url("/projects/%s/relationships/languages",
+						project.Id),
+					"related": ex.url("/projects/%s/languages", project.Id),
+				},
+			},
+		},
+		"links": map[string]string{
+			"self": ex.url("/projects/%s", project.Id),
+		},
+	}
+}
+
+func (ex *exchange) listProjects() {
+	organizationId, ok := ex.filter("organization", true)
+	if !ok {
+		return
+	}
+	slug, _ := ex.filter("slug", false)
+	store := ex.server.Store
+	items := []object{}
+	for _, id := range sortedKeys(store.Projects) {
+		project := store.Projects[id]
+		if project.OrganizationId != organizationId ||
+			(slug != "" && project.Slug != slug) {
+			continue
+		}
+		items = append(items, ex.projectObject(project))
+	}
+	ex.respondPage(items)
+}
+
+func (ex *exchange) getProject(id string) {
+	project, exists := ex.server.Store.Projects[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.projectObject(project))
+}
+
+func (ex *exchange) createProject() {
+	attributes, relationships, ok := ex.parseBody()
+	if !ok {
+		return
+	}
+	store := ex.server.Store
+	slug, _ := attributes["slug"].(string)
+	name, _ := attributes["name"].(string)
+	organization, exists := store.Organizations[relationships["organization"]]
+	if !exists {
+		ex.error(400, "invalid", "organization does not exist")
+		return
+	}
+	sourceLanguage, exists := store.Languages[relationships["source_language"]]
+	if !exists {
+		ex.error(400, "invalid", "source language does not exist")
+		return
+	}
+	if slug == "" || name == "" {
+		ex.error(400, "invalid", "a project needs a name and a slug")
+		return
+	}
+	if _, exists := store.Projects[fmt.Sprintf("%s:p:%s", organization.Id, slug)]; exists {
+		ex.error(409, "conflict", fmt.Sprintf(
+			"project with slug '%s' already exists", slug,
+		))
+		return
+	}
+
+	project := store.AddProject(organization.Slug, slug, sourceLanguage.Code)
+	settings := *project
+	settings.Private = true
+	if !ex.applyProjectAttributes(&settings, attributes) {
+		delete(store.Projects, project.Id)
+		return
+	}
+	*project = settings
+	ex.respondSingle(201, ex.projectObject(project))
+}
+
+func (ex *exchange) updateProject(id string) {
+	project, exists := ex.server.Store.Projects[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	attributes, _, ok := ex.parseBody()
+	if !ok {
+		return
+	}
+	// Validate on a copy so that a bad request changes nothing
+	updated := *project
+	if !ex.applyProjectAttributes(&updated, attributes) {
+		return
+	}
+	*project = updated
+	project.Modified = timestamp()
+	ex.respondSingle(200, ex.projectObject(project))
+}
+
+/*
+Apply the attributes of a create or update request to a project. Like the real
+API, public projects need a repository URL. Responds with an error and returns
+false if the attributes are invalid.
+*/
+func (ex *exchange) applyProjectAttributes(
+	project *Project, attributes map[string]interface{},
+) bool {
+	texts := map[string]*string{
+		"name":             &project.Name,
+		"description":      &project.Description,
+		"long_description": &project.LongDescription,
+		"instructions_url": &project.InstructionsURL,
+		"homepage_url":     &project.HomepageURL,
+		"repository_url":   &project.RepositoryURL,
+		"license":          &project.License,
+	}
+	booleans := map[string]*bool{
+		"private":                   &project.Private,
+		"archived":                  &project.Archived,
+		"translation_memory_fillup": &project.TMFillup,
+	}
+	for name, value := range attributes {
+		var ok bool
+		if target, exists := texts[name]; exists {
+			*target, ok = value.(string)
+		} else if target, exists := booleans[name]; exists {
+			*target, ok = value.(bool)
+		} else if name == "tags" {
+			var values []interface{}
+			values, ok = value.([]interface{})
+			project.Tags = nil
+			for _, tag := range values {
+				if text, isText := tag.(string); isText {
+					project.Tags = append(project.Tags, text)
+				}
+			}
+		} else if name == "slug" {
+			ok = true
+		} else {
+			ex.error(400, "invalid", fmt.Sprintf("'%s' cannot be changed", name))
+			return false
+		}
+		if !ok {
+			ex.error(400, "invalid", fmt.Sprintf("invalid value for '%s'", name))
+			return false
+		}
+	}
+	if !project.Private && project.RepositoryURL == "" {
+		ex.error(400, "invalid",
+			"public projects need a repository URL (repository_url)")
+		return false
+	}
+	return true
+}
+
+func (ex *exchange) listProjectLanguages(id string) {
+	project, exists := ex.server.Store.Projects[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	items := []object{}
+	codes := append([]string{}, project.Languages...)
+	sort.Strings(codes)
+	for _, code := range codes {
+		language, exists := ex.server.Store.Languages[languageId(code)]
+		if exists {
+			items = append(items, ex.languageObject(language))
+		}
+	}
+	ex.respondPage(items)
+}
+
+/*
+Add (POST), remove (DELETE) or replace (PATCH) the target languages of a
+project
+*/
+func (ex *exchange) modifyProjectLanguages(id string) {
+	project, exists := ex.server.Store.Projects[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	var payload struct {
+		Data []struct {
+			Type string `json:"type"`
+			Id   string `json:"id"`
+		} `json:"data"`
+	}
+	if !ex.decode(&payload) {
+		return
+	}
+	var codes []string
+	for _, item := range payload.Data {
+		language, exists := ex.server.Store.Languages[item.Id]
+		if !exists {
+			ex.error(400, "invalid", fmt.Sprintf(
+				"language '%s' does not exist", item.Id,
+			))
+			return
+		}
+		if language.Code == project.SourceLanguage {
+			ex.error(400, "invalid", fmt.Sprintf(
+				"'%s' is the source language of the project", language.Code,
+			))
+			return
+		}
+		codes = append(codes, language.Code)
+	}
+
+	switch ex.request.Method {
+	case "POST":
+		for _, code := range codes {
+			if indexOf(project.Languages, code) == -1 {
+				project.Languages = append(project.Languages, code)
+			}
+		}
+	case "DELETE":
+		var remaining []string
+		for _, code := range project.Languages {
+			if indexOf(codes, code) == -1 {
+				remaining = append(remaining, code)
+			}
+		}
+		project.Languages = remaining
+	case "PATCH":
+		project.Languages = codes
+	default:
+		ex.notFound()
+		return
+	}
+	project.Modified = timestamp()
+	ex.respond(204, nil)
+}
+
+// Languages
+
+func (ex *exchange) languageObject(language *Language) object {
+	return object{
+		"type": "languages",
+		"id":   languageId(language.Code),
+		"attributes": map[string]interface{}{
+			"code":            language.Code,
+			"name":            language.Name,
+			"rtl":             language.Rtl,
+			"plural_equation": "(n != 1)",
+			"plural_rules": map[string]string{
+				"one":   "n is 1",
+				"other": "everything else",
+			},
+		},
+		"links": map[string]string{
+			"self": ex.url("/languages/%s", languageId(language.Code)),
+		},
+	}
+}
+
+func (ex *exchange) listLanguages() {
+	store := ex.server.Store
+	items := []object{}
+	for _, id := range sortedKeys(store.Languages) {
+		items = append(items, ex.languageObject(store.Languages[id]))
+	}
+	ex.respondPage(items)
+}
+
+func (ex *exchange) getLanguage(id string) {
+	language, exists := ex.server.Store.Languages[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.languageObject(language))
+}
+
+// I18n formats
+
+func (ex *exchange) listI18nFormats() {
+	organizationId, ok := ex.filter("organization", true)
+	if !ok {
+		return
+	}
+	store := ex.server.Store
+	items := []object{}
+	for _, name := range sortedKeys(store.I18nFormats) {
+		format := store.I18nFormats[name]
+		items = append(items, object{
+			"type": "i18n_formats",
+			"id":   format.Name,
+			"attributes": map[string]interface{}{
+				"name":            format.Name,
+				"description":     format.Description,
+				"file_extensions": format.FileExtensions,
+				"media_type":      format.MediaType,
+			},
+			"relationships": map[string]interface{}{
+				"organization": map[string]interface{}{
+					"data": identifier("organizations", organizationId),
+				},
+			},
+		})
+	}
+	// Formats are not paginated in the real API either
+	ex.respond(200, map[string]interface{}{"data": items})
+}
+
+// Resources
+
+func (ex *exchange) resourceObject(resource *Resource) object {
+	categories := resource.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	priority := resource.Priority
+	if priority == "" {
+		priority = "normal"
+	}
+	relationships := map[string]interface{}{
+		"project": map[string]interface{}{
+			"data": identifier("projects", resource.ProjectId),
+			"links": map[string]string{
+				"related": ex.url("/projects/%s", resource.ProjectId),
+			},
+		},
+		"i18n_format": map[string]interface{}{
+			"data": identifier("i18n_formats", resource.I18nFormat),
+		},
+	}
+	if resource.BaseId != "" {
+		relationships["base"] = map[string]interface{}{
+			"data": identifier("resources", resource.BaseId),
+			"links": map[string]string{
+				"related": ex.url("/resources/%s", resource.BaseId),
+			},
+		}
+	}
+	stringCount := resource.StringCount()
+	return object{
+		"type": "resources",
+		"id":   resource.Id,
+		"attributes": map[string]interface{}{
+			"slug":                resource.Slug,
+			"name":                resource.Name,
+			"categories":          categories,
+			"priority":            priority,
+			"accept_translations": !resource.NotAcceptingTranslations,
+			"string_count":        stringCount,
+			"word_count":          stringCount,
+			"i18n_version":        2,
+			"datetime_created":    resource.Created,
+			"datetime_modified":   resource.Modified,
+		},
+		"relationships": relationships,
+		"links": map[string]string{
+			"self": ex.url("/resources/%s", resource.Id),
+		},
+	}
+}
+
+func (ex *exchange) listResources() {
+	projectId, ok := ex.filter("project", true)
+	if !ok {
+		return
+	}
+	slug, _ := ex.filter("slug", false)
+	store := ex.server.Store
+	items := []object{}
+	for _, id := range sortedKeys(store.Resources) {
+		resource := store.Resources[id]
+		if resource.ProjectId != projectId ||
+			(slug != "" && resource.Slug != slug) {
+			continue
+		}
+		items = append(items, ex.resourceObject(resource))
+	}
+	ex.respondPage(items)
+}
+
+func (ex *exchange) getResource(id string) {
+	resource, exists := ex.server.Store.Resources[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.resourceObject(resource))
+}
+
+func (ex *exchange) createResource() {
+	attributes, relationships, ok := ex.parseBody()
+	if !ok {
+		return
+	}
+	store := ex.server.Store
+	slug, _ := attributes["slug"].(string)
+	name, _ := attributes["name"].(string)
+	project, exists := store.Projects[relationships["project"]]
+	if !exists {
+		ex.error(400, "invalid", "project does not exist")
+		return
+	}
+	if _, exists := store.I18nFormats[relationships["i18n_format"]]; !exists {
+		ex.error(400, "invalid", fmt.Sprintf(
+			"i18n format '%s' does not exist", relationships["i18n_format"],
+		))
+		return
+	}
+	if slug == "" {
+		slug = strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	}
+	if slug == "" {
+		ex.error(400, "invalid", "a resource needs a name or a slug")
+		return
+	}
+	if _, exists := store.Resources[fmt.Sprintf("%s:r:%s", project.Id, slug)]; exists {
+		ex.error(409, "conflict", fmt.Sprintf(
+			"resource with slug '%s' already exists", slug,
+		))
+		return
+	}
+	baseId := relationships["base"]
+	if baseId != "" {
+		if _, exists := store.Resources[baseId]; !exists {
+			ex.error(400, "invalid", "base resource does not exist")
+			return
+		}
+	}
+
+	resource := store.AddResource(
+		project.Id, slug, relationships["i18n_format"], nil,
+	)
+	if name != "" {
+		resource.Name = name
+	}
+	resource.BaseId = baseId
+	if baseId != "" {
+		resource.BaseContent = store.Resources[baseId].Content
+	}
+	applyResourceAttributes(resource, attributes)
+	ex.respondSingle(201, ex.resourceObject(resource))
+}
+
+func (ex *exchange) updateResource(id string) {
+	resource, exists := ex.server.Store.Resources[id]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	attributes, _, ok := ex.parseBody()
+	if !ok {
+		return
+	}
+	if name, ok := attributes["name"].(string); ok {
+		resource.Name = name
+	}
+	applyResourceAttributes(resource, attributes)
+	resource.Modified = timestamp()
+	ex.respondSingle(200, ex.resourceObject(resource))
+}
+
+func applyResourceAttributes(
+	resource *Resource, attributes map[string]interface{},
+) {
+	if categories, ok := attributes["categories"].([]interface{}); ok {
+		resource.Categories = nil
+		for _, category := range categories {
+			if text, ok := category.(string); ok {
+				resource.Categories = append(resource.Categories, text)
+			}
+		}
+	}
+	if priority, ok := attributes["priority"].(string); ok {
+		resource.Priority = priority
+	}
+	if accept, ok := attributes["accept_translations"].(bool); ok {
+		resource.NotAcceptingTranslations = !accept
+	}
+}
+
+func (ex *exchange) deleteResource(id string) {
+	if _, exists := ex.server.Store.Resources[id]; !exists {
+		ex.notFound()
+		return
+	}
+	delete(ex.server.Store.Resources, id)
+	ex.respond(204, nil)
+}
+
+// Resource language stats
+
+func (ex *exchange) statsObject(resource *Resource, code string) object {
+	total := resource.StringCount()
+	translated := total
+	reviewed, proofread := 0, 0
+	lastUpdate := resource.Modified
+	if code != ex.server.Store.Projects[resource.ProjectId].SourceLanguage {
+		translated = 0
+		translation, exists := resource.Translations[code]
+		if exists {
+			translated = len(translation.texts())
+			if translated > total {
+				translated = total
+			}
+			for _, edited := range translation.Strings {
+				if edited.Text != "" && edited.Reviewed {
+					reviewed++
+				}
+				if edited.Text != "" && edited.Proofread {
+					proofread++
+				}
+			}
+			lastUpdate = translation.Modified
+		}
+	}
+	id := fmt.Sprintf("%s:%s", resource.Id, languageId(code))
+	return object{
+		"type": "resource_language_stats",
+		"id":   id,
+		"attributes": map[string]interface{}{
+			"total_strings":           total,
+			"total_words":             total,
+			"translated_strings":      translated,
+			"translated_words":        translated,
+			"untranslated_strings":    total - translated,
+			"untranslated_words":      total - translated,
+			"reviewed_strings":        reviewed,
+			"reviewed_words":          reviewed,
+			"proofread_strings":       proofread,
+			"proofread_words":         proofread,
+			"last_update":             lastUpdate,
+			"last_translation_update": lastUpdate,
+			"last_review_update":      lastUpdate,
+			"last_proofread_update":   lastUpdate,
+		},
+		"relationships": map[string]interface{}{
+			"resource": map[string]interface{}{
+				"data": identifier("resources", resource.Id),
+			},
+			"language": map[string]interface{}{
+				"data": identifier("languages", languageId(code)),
+			},
+		},
+		"links": map[string]string{
+			"self": ex.url("/resource_language_stats/%s", id),
+		},
+	}
+}
+
+func (ex *exchange) listStats() {
+	projectId, ok := ex.filter("project", true)
+	if !ok {
+		return
+	}
+	resourceId, _ := ex.filter("resource", false)
+	language, _ := ex.filter("language", false)
+	store := ex.server.Store
+	project, exists := store.Projects[projectId]
+	if !exists {
+		ex.error(400, "invalid", "project does not exist")
+		return
+	}
+	codes := append([]string{project.SourceLanguage}, project.Languages...)
+
+	items := []object{}
+	for _, id := range sortedKeys(store.Resources) {
+		resource := store.Resources[id]
+		if resource.ProjectId != projectId ||
+			(resourceId != "" && resource.Id != resourceId) {
+			continue
+		}
+		for _, code := range codes {
+			if language != "" && languageId(code) != language {
+				continue
+			}
+			items = append(items, ex.statsObject(resource, code))
+		}
+	}
+	ex.respondPage(items)
+}
+
+func (ex *exchange) getStats(id string) {
+	// <resource id>:l:<code>
+	index := strings.LastIndex(id, ":l:")
+	if index == -1 {
+		ex.notFound()
+		return
+	}
+	resource, exists := ex.server.Store.Resources[id[:index]]
+	if !exists {
+		ex.notFound()
+		return
+	}
+	code := id[index+len(":l:"):]
+	project := ex.server.Store.Projects[resource.ProjectId]
+	if code != project.SourceLanguage && indexOf(project.Languages, code) == -1 {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.statsObject(resource, code))
+}
+
+// Helpers
+
+// Sorted keys of one of the Store's maps, so that lists come out in a stable
+// order
+func sortedKeys(items interface{}) []string {
+	keys := reflect.ValueOf(items).MapKeys()
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key.String())
+	}
+	sort.Strings(result)
+	return result
+}
+
+func indexOf(haystack []string, needle string) int {
+	for i, item := range haystack {
+		if item == needle {
+			return i
+		}
+	}
+	return -1
+}
+
+// Compound documents and sparse fieldsets
+
+func (ex *exchange) findObject(Type, id string) (object, bool) {
+	store := ex.server.Store
+	switch Type {
+	case "organizations":
+		if organization, exists := store.Organizations[id]; exists {
+			return ex.organizationObject(organization), true
+		}
+	case "projects":
+		if project, exists := store.Projects[id]; exists {
+			return ex.projectObject(project), true
+		}
+	case "resources":
+		if resource, exists := store.Resources[id]; exists {
+			return ex.resourceObject(resource), true
+		}
+	case "languages":
+		if language, exists := store.Languages[id]; exists {
+			return ex.languageObject(language), true
+		}
+	case "resource_strings":
+		if resource, item, exists := ex.findString(id); exists {
+			return ex.stringObject(resource, item), true
+		}
+	}
+	return nil, false
+}
+
+/*
+Return the objects the singular relationships of 'items' point to, for the
+relationships requested with the 'include' query parameter
+*/
+func (ex *exchange) included(items []object) []object {
+	include := ex.request.URL.Query().Get("include")
+	if include == "" {
+		return nil
+	}
+	result := []object{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		relationships, _ := item["relationships"].(map[string]interface{})
+		for _, name := range strings.Split(include, ",") {
+			relationship, _ := relationships[name].(map[string]interface{})
+			data, _ := relationship["data"].(map[string]string)
+			key := data["type"] + ":" + data["id"]
+			if data == nil || seen[key] {
+				continue
+			}
+			if related, exists := ex.findObject(data["type"], data["id"]); exists {
+				seen[key] = true
+				result = append(result, ex.sparse(related))
+			}
+		}
+	}
+	return result
+}
+
+// Keep only the fields requested with 'fields[<type>]', if any
+func (ex *exchange) sparse(item object) object {
+	Type, _ := item["type"].(string)
+	fields := ex.request.URL.Query().Get(fmt.Sprintf("fields[%s]", Type))
+	if fields == "" {
+		return item
+	}
+	wanted := strings.Split(fields, ",")
+	result := make(object)
+	for key, value := range item {
+		result[key] = value
+	}
+	for _, key := range []string{"attributes", "relationships"} {
+		original, _ := item[key].(map[string]interface{})
+		if original == nil {
+			continue
+		}
+		filtered := make(map[string]interface{})
+		for name, value := range original {
+			if indexOf(wanted, name) != -1 {
+				filtered[name] = value
+			}
+		}
+		result[key] = filtered
+	}
+	return result
+}
@@ -0,0 +1,351 @@
+package txapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var jobTypes = map[string]bool{
+	"resource_strings_async_uploads":        true,
+	"resource_translations_async_uploads":   true,
+	"resource_strings_async_downloads":      true,
+	"resource_translations_async_downloads": true,
+	"resource_async_merges":                 true,
+}
+
+// An async upload, download or merge
+type job struct {
+	id           string
+	Type         string
+	resourceId   string
+	languageCode string
+	attributes   map[string]interface{}
+	content      []byte
+	fetches      int
+	status       string
+	errors       []map[string]string
+	details      map[string]int
+}
+
+func (j *job) isDownload() bool {
+	return strings.HasSuffix(j.Type, "_downloads")
+}
+
+func (j *job) isMerge() bool {
+	return j.Type == "resource_async_merges"
+}
+
+func (ex *exchange) jobObject(j *job) object {
+	attributes := make(map[string]interface{})
+	for key, value := range j.attributes {
+		attributes[key] = value
+	}
+	attributes["status"] = j.status
+	if !j.isDownload() {
+		errors := j.errors
+		if errors == nil {
+			errors = []map[string]string{}
+		}
+		attributes["errors"] = errors
+		if j.details != nil {
+			attributes["details"] = j.details
+		}
+	}
+
+	relationships := map[string]interface{}{
+		"resource": map[string]interface{}{
+			"data": identifier("resources", j.resourceId),
+		},
+	}
+	if j.languageCode != "" {
+		relationships["language"] = map[string]interface{}{
+			"data": identifier("languages", languageId(j.languageCode)),
+		}
+	}
+	if j.isMerge() {
+		if resource, exists := ex.server.Store.Resources[j.resourceId]; exists {
+			relationships["base"] = map[string]interface{}{
+				"data": identifier("resources", resource.BaseId),
+			}
+		}
+	}
+	return object{
+		"type":          j.Type,
+		"id":            j.id,
+		"attributes":    attributes,
+		"relationships": relationships,
+		"links": map[string]string{
+			"self": ex.url("/%s/%s", j.Type, j.id),
+		},
+	}
+}
+
+func (ex *exchange) createJob(Type string) {
+	j := &job{Type: Type, attributes: make(map[string]interface{})}
+	var ok bool
+	switch Type {
+	case "resource_strings_async_uploads",
+		"resource_translations_async_uploads":
+		ok = ex.parseUpload(j)
+		j.status = "pending"
+	case "resource_async_merges":
+		ok = ex.parseJsonJob(j)
+		j.status = "PENDING"
+	default:
+		ok = ex.parseJsonJob(j)
+		j.status = "pending"
+	}
+	if !ok {
+		return
+	}
+
+	store := ex.server.Store
+	resource, exists := store.Resources[j.resourceId]
+	if !exists {
+		ex.error(400, "invalid", "resource does not exist")
+		return
+	}
+	if j.languageCode != "" {
+		project := store.Projects[resource.ProjectId]
+		if indexOf(project.Languages, j.languageCode) == -1 &&
+			j.languageCode != project.SourceLanguage {
+			ex.error(400, "invalid", fmt.Sprintf(
+				"language '%s' is not a language of the project",
+				j.languageCode,
+			))
+			return
+		}
+	}
+	if j.isMerge() && resource.BaseId == "" {
+		ex.error(400, "invalid", "resource is not a branch of another resource")
+		return
+	}
+
+	ex.server.nextJobId++
+	j.id = fmt.Sprintf("%s_%d", strings.TrimSuffix(Type, "s"), ex.server.nextJobId)
+	ex.server.jobs[j.id] = j
+	ex.respondSingle(202, ex.jobObject(j))
+}
+
+// Uploads are multipart forms with the file in 'content' and relationships as
+// plain ids
+func (ex *exchange) parseUpload(j *job) bool {
+	err := ex.request.ParseMultipartForm(32 << 20)
+	if err != nil {
+		ex.error(400, "parse_error", err.Error())
+		return false
+	}
+	file, _, err := ex.request.FormFile("content")
+	if err != nil {
+		ex.error(400, "invalid", "'content' is required")
+		return false
+	}
+	defer file.Close()
+	j.content, err = io.ReadAll(file)
+	if err != nil {
+		ex.error(400, "parse_error", err.Error())
+		return false
+	}
+	j.resourceId = ex.request.FormValue("resource")
+	language := ex.request.FormValue("language")
+	if language != "" {
+		j.languageCode = strings.TrimPrefix(language, "l:")
+	} else if j.Type == "resource_translations_async_uploads" {
+		ex.error(400, "invalid", "'language' is required")
+		return false
+	}
+	for key, values := range ex.request.MultipartForm.Value {
+		if key != "resource" && key != "language" {
+			j.attributes[key] = values[0]
+		}
+	}
+	return true
+}
+
+func (ex *exchange) parseJsonJob(j *job) bool {
+	attributes, relationships, ok := ex.parseBody()
+	if !ok {
+		return false
+	}
+	j.attributes = attributes
+	j.resourceId = relationships["resource"]
+	if language, exists := relationships["language"]; exists {
+		j.languageCode = strings.TrimPrefix(language, "l:")
+	} else if j.Type == "resource_translations_async_downloads" {
+		ex.error(400, "invalid", "'language' is required")
+		return false
+	}
+	return true
+}
+
+/*
+Every fetch moves the job one step forward. Once it has been fetched
+'JobSteps' times it does its work; downloads then redirect to the file.
+*/
+func (ex *exchange) getJob(Type, id string) {
+	j, exists := ex.server.jobs[id]
+	if !exists || j.Type != Type {
+		ex.notFound()
+		return
+	}
+	if !isFinal(j.status) {
+		j.fetches++
+		if j.fetches >= ex.server.JobSteps {
+			ex.completeJob(j)
+		} else if j.isMerge() {
+			j.status = "PROCESSING"
+		} else {
+			j.status = "processing"
+		}
+	}
+
+	if j.isDownload() && j.status == "succeeded" {
+		ex.writer.Header().Set("Location", ex.url(
+			"/_files/%s?X-Amz-Expires=60&X-Amz-Signature=fake", j.id,
+		))
+		ex.writer.WriteHeader(303)
+		return
+	}
+	ex.respondSingle(200, ex.jobObject(j))
+}
+
+func isFinal(status string) bool {
+	return status == "succeeded" || status == "failed" ||
+		status == "COMPLETED" || status == "FAILED"
+}
+
+func (ex *exchange) completeJob(j *job) {
+	store := ex.server.Store
+	resource, exists := store.Resources[j.resourceId]
+	if !exists {
+		j.fail("not_found", "resource was deleted")
+		return
+	}
+
+	switch j.Type {
+	case "resource_strings_async_uploads":
+		if !ex.validContent(j, resource) {
+			return
+		}
+		old := stringKeys(resource.Content)
+		updated := stringKeys(j.content)
+		details := map[string]int{
+			"strings_created": 0, "strings_updated": 0,
+			"strings_deleted": 0, "strings_skipped": 0,
+		}
+		for key, value := range updated {
+			oldValue, existed := old[key]
+			if !existed {
+				details["strings_created"]++
+			} else if oldValue != value {
+				details["strings_updated"]++
+			} else {
+				details["strings_skipped"]++
+			}
+		}
+		for key := range old {
+			if _, exists := updated[key]; !exists {
+				details["strings_deleted"]++
+			}
+		}
+		resource.setContent(j.content)
+		j.details = details
+		j.status = "succeeded"
+
+	case "resource_translations_async_uploads":
+		if !ex.validContent(j, resource) {
+			return
+		}
+		var old map[string]string
+		if translation, exists := resource.Translations[j.languageCode]; exists {
+			old = stringKeys(translation.Content)
+		}
+		details := map[string]int{
+			"translations_created": 0, "translations_updated": 0,
+		}
+		for key, value := range stringKeys(j.content) {
+			oldValue, existed := old[key]
+			if !existed {
+				details["translations_created"]++
+			} else if oldValue != value {
+				details["translations_updated"]++
+			}
+		}
+		_ = store.SetTranslation(resource.Id, j.languageCode, j.content)
+		j.details = details
+		j.status = "succeeded"
+
+	case "resource_strings_async_downloads":
+		j.content = resource.Content
+		j.status = "succeeded"
+
+	case "resource_translations_async_downloads":
+		project := store.Projects[resource.ProjectId]
+		translation, exists := resource.Translations[j.languageCode]
+		if exists && j.languageCode != project.SourceLanguage {
+			j.content = translation.Content
+		} else {
+			// Untranslated strings come back in the source language
+			j.content = resource.Content
+		}
+		j.status = "succeeded"
+
+	case "resource_async_merges":
+		base, exists := store.Resources[resource.BaseId]
+		if !exists {
+			j.fail("not_found", "base resource was deleted")
+			j.status = "FAILED"
+			return
+		}
+		force, _ := j.attributes["force"].(bool)
+		if !force && resource.BaseContent != nil &&
+			!bytes.Equal(base.Content, resource.BaseContent) {
+			j.fail("diverged", "the source strings of the base resource "+
+				"changed since the branch was created, use force to merge anyway")
+			j.status = "FAILED"
+			return
+		}
+		base.setContent(resource.Content)
+		for code, translation := range resource.Translations {
+			_ = store.SetTranslation(base.Id, code, translation.Content)
+			base.Translations[code].Strings = translation.Strings
+		}
+		j.status = "COMPLETED"
+	}
+}
+
+// Files of JSON-based formats must be valid JSON, otherwise the upload fails
+// like it would on the real API
+func (ex *exchange) validContent(j *job, resource *Resource) bool {
+	if !strings.Contains(resource.I18nFormat, "JSON") {
+		return true
+	}
+	var parsed interface{}
+	err := json.Unmarshal(j.content, &parsed)
+	if err != nil {
+		j.fail("parse_error", fmt.Sprintf("invalid JSON file: %s", err))
+		return false
+	}
+	return true
+}
+
+func (j *job) fail(code, detail string) {
+	j.status = "failed"
+	j.errors = append(j.errors, map[string]string{
+		"code": code, "detail": detail,
+	})
+}
+
+func (ex *exchange) serveFile(id string) {
+	j, exists := ex.server.jobs[id]
+	if !exists || !j.isDownload() || j.status != "succeeded" {
+		ex.writer.WriteHeader(404)
+		return
+	}
+	ex.writer.Header().Set("Content-Length", strconv.Itoa(len(j.content)))
+	_, _ = ex.writer.Write(j.content)
+}
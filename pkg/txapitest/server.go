@@ -0,0 +1,434 @@
+/*
+Package txapitest
+In-memory fake of the Transifex API, for testing code built on 'pkg/jsonapi'
+and 'pkg/txapi' offline. It speaks the same {json:api} dialect as the real API
+for organizations, projects, resources, languages, i18n formats,
+resource_language_stats, resource_strings, resource_translations and the async
+upload/download/merge jobs, including pagination, redirects to file content and
+throttling.
+
+Usage:
+
+	server := txapitest.NewServer()
+	defer server.Close()
+	project := server.Store.AddProject("org", "proj", "en", "el", "fr")
+	server.Store.AddResource(
+		project.Id, "res", "KEYVALUEJSON", []byte(`{"hello": "world"}`),
+	)
+
+	api := server.Connection()
+	organization, err := txapi.GetOrganization(&api, "org")
+	...
+
+Async jobs go through realistic status transitions: every time a job is
+fetched it moves one step closer to completion and it completes after
+'JobSteps' fetches. Downloads then redirect to the file's content.
+*/
+package txapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+type Server struct {
+	// Set to require this token from clients; empty accepts any token
+	Token string
+	// Number of items per page of collections
+	PageSize int
+	// How many times an async job needs to be fetched before it completes
+	JobSteps int
+	Store    *Store
+	// Called, with the server locked, after every request that may have
+	// modified the Store
+	OnChange func(store *Store)
+
+	// Address of the server when started with NewServer
+	URL string
+
+	httpServer *httptest.Server
+	jobs       map[string]*job
+	nextJobId  int
+	throttled  int
+	retryAfter int
+	requests   []Request
+	mutex      sync.Mutex
+}
+
+/*
+Request
+A request the server has received, for assertions
+*/
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+/*
+NewHandler
+Return a server that is not listening anywhere, to be used as an http.Handler
+*/
+func NewHandler(store *Store) *Server {
+	if store == nil {
+		store = NewStore()
+	}
+	return &Server{
+		PageSize: 150,
+		JobSteps: 1,
+		Store:    store,
+		jobs:     make(map[string]*job),
+	}
+}
+
+/*
+NewServer
+Start a fake server with an empty store on a random local port
+*/
+func NewServer() *Server {
+	server := NewHandler(nil)
+	server.httpServer = httptest.NewServer(server)
+	server.URL = server.httpServer.URL
+	return server
+}
+
+func (server *Server) Close() {
+	if server.httpServer != nil {
+		server.httpServer.Close()
+	}
+}
+
+/*
+Connection
+Return a connection that talks to the server
+*/
+func (server *Server) Connection() jsonapi.Connection {
+	token := server.Token
+	if token == "" {
+		token = "token"
+	}
+	return jsonapi.Connection{Host: server.URL, Token: token}
+}
+
+/*
+Throttle
+Respond to the next 'count' requests with '429 Too Many Requests' and the given
+'Retry-After' (in seconds)
+*/
+func (server *Server) Throttle(count, retryAfter int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.throttled = count
+	server.retryAfter = retryAfter
+}
+
+/*
+Requests
+Return the requests the server has received so far, in order
+*/
+func (server *Server) Requests() []Request {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]Request{}, server.requests...)
+}
+
+/*
+Lock
+Hold back all requests while the test modifies the Store of a running server
+*/
+func (server *Server) Lock() {
+	server.mutex.Lock()
+}
+
+func (server *Server) Unlock() {
+	server.mutex.Unlock()
+}
+
+// Everything the handlers need to respond to a request
+type exchange struct {
+	server   *Server
+	writer   http.ResponseWriter
+	request  *http.Request
+	baseUrl  string
+	segments []string
+}
+
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.requests = append(server.requests, Request{
+		Method: request.Method,
+		Path:   request.URL.Path,
+		Query:  request.URL.Query(),
+	})
+
+	scheme := "http"
+	if request.TLS != nil {
+		scheme = "https"
+	}
+	ex := &exchange{
+		server:   server,
+		writer:   writer,
+		request:  request,
+		baseUrl:  fmt.Sprintf("%s://%s", scheme, request.Host),
+		segments: strings.Split(strings.Trim(request.URL.Path, "/"), "/"),
+	}
+
+	if server.throttled > 0 {
+		server.throttled--
+		writer.Header().Set("Retry-After", strconv.Itoa(server.retryAfter))
+		ex.error(429, "throttled", "Request was throttled")
+		return
+	}
+
+	// File contents are served without authentication, like presigned URLs
+	if ex.segments[0] == "_files" && len(ex.segments) == 2 {
+		ex.serveFile(ex.segments[1])
+		return
+	}
+
+	if server.Token != "" &&
+		request.Header.Get("Authorization") != "Bearer "+server.Token {
+		ex.error(401, "unauthorized", "Authentication credentials are invalid")
+		return
+	}
+
+	ex.route()
+
+	// Fetching a job may complete it, which modifies the store
+	if server.OnChange != nil &&
+		(request.Method != "GET" || jobTypes[ex.segments[0]]) {
+		server.OnChange(server.Store)
+	}
+}
+
+func (ex *exchange) route() {
+	method := ex.request.Method
+	segments := ex.segments
+	collection := segments[0]
+
+	if len(segments) == 1 {
+		switch {
+		case method == "GET" && collection == "organizations":
+			ex.listOrganizations()
+		case method == "GET" && collection == "projects":
+			ex.listProjects()
+		case method == "POST" && collection == "projects":
+			ex.createProject()
+		case method == "GET" && collection == "languages":
+			ex.listLanguages()
+		case method == "GET" && collection == "i18n_formats":
+			ex.listI18nFormats()
+		case method == "GET" && collection == "resources":
+			ex.listResources()
+		case method == "POST" && collection == "resources":
+			ex.createResource()
+		case method == "GET" && collection == "resource_language_stats":
+			ex.listStats()
+		case method == "GET" && collection == "resource_strings":
+			ex.listStrings()
+		case method == "GET" && collection == "resource_translations":
+			ex.listTranslations()
+		case method == "PATCH" && collection == "resource_translations":
+			ex.bulkUpdateTranslations()
+		case method == "POST" && jobTypes[collection]:
+			ex.createJob(collection)
+		default:
+			ex.notFound()
+		}
+		return
+	}
+
+	id := segments[1]
+	if len(segments) == 2 {
+		switch {
+		case method == "GET" && collection == "organizations":
+			ex.getOrganization(id)
+		case method == "GET" && collection == "projects":
+			ex.getProject(id)
+		case method == "PATCH" && collection == "projects":
+			ex.updateProject(id)
+		case method == "GET" && collection == "languages":
+			ex.getLanguage(id)
+		case method == "GET" && collection == "resources":
+			ex.getResource(id)
+		case method == "PATCH" && collection == "resources":
+			ex.updateResource(id)
+		case method == "DELETE" && collection == "resources":
+			ex.deleteResource(id)
+		case method == "GET" && collection == "resource_language_stats":
+			ex.getStats(id)
+		case method == "GET" && collection == "resource_strings":
+			ex.getString(id)
+		case method == "PATCH" && collection == "resource_strings":
+			ex.updateString(id)
+		case method == "DELETE" && collection == "resource_strings":
+			ex.deleteString(id)
+		case method == "GET" && collection == "resource_translations":
+			ex.getTranslation(id)
+		case method == "GET" && jobTypes[collection]:
+			ex.getJob(collection, id)
+		default:
+			ex.notFound()
+		}
+		return
+	}
+
+	if collection == "projects" && len(segments) == 3 &&
+		segments[2] == "languages" && method == "GET" {
+		ex.listProjectLanguages(id)
+	} else if collection == "projects" && len(segments) == 4 &&
+		segments[2] == "relationships" && segments[3] == "languages" {
+		ex.modifyProjectLanguages(id)
+	} else {
+		ex.notFound()
+	}
+}
+
+// Responses
+
+func (ex *exchange) respond(status int, payload interface{}) {
+	ex.writer.Header().Set("Content-Type", "application/vnd.api+json")
+	ex.writer.WriteHeader(status)
+	if payload != nil {
+		_ = json.NewEncoder(ex.writer).Encode(payload)
+	}
+}
+
+func (ex *exchange) error(status int, code, detail string) {
+	ex.respond(status, map[string]interface{}{
+		"errors": []map[string]string{{
+			"status": strconv.Itoa(status),
+			"code":   code,
+			"title":  http.StatusText(status),
+			"detail": detail,
+		}},
+	})
+}
+
+func (ex *exchange) notFound() {
+	ex.error(404, "not_found", "Not found")
+}
+
+func (ex *exchange) respondSingle(status int, resource object) {
+	payload := map[string]interface{}{"data": ex.sparse(resource)}
+	if included := ex.included([]object{resource}); included != nil {
+		payload["included"] = included
+	}
+	ex.respond(status, payload)
+}
+
+/*
+Respond with a page of 'items' and links to the previous and next pages. The
+page is selected with the 'page[cursor]' query parameter (an offset) and its
+size with 'page[size]', up to the server's PageSize.
+*/
+func (ex *exchange) respondPage(items []object) {
+	query := ex.request.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("page[cursor]"))
+	size := ex.server.PageSize
+	if requested, err := strconv.Atoi(query.Get("page[size]")); err == nil &&
+		requested > 0 && requested < size {
+		size = requested
+	}
+	if size <= 0 {
+		size = len(items)
+	}
+	if offset < 0 || offset > len(items) {
+		offset = len(items)
+	}
+	end := offset + size
+	if end > len(items) {
+		end = len(items)
+	}
+
+	pageUrl := func(cursor int) string {
+		pageQuery := ex.request.URL.Query()
+		pageQuery.Set("page[cursor]", strconv.Itoa(cursor))
+		return fmt.Sprintf("%s%s?%s",
+			ex.baseUrl, ex.request.URL.Path, pageQuery.Encode())
+	}
+	links := map[string]interface{}{
+		"self":     ex.baseUrl + ex.request.URL.RequestURI(),
+		"next":     nil,
+		"previous": nil,
+	}
+	if end < len(items) {
+		links["next"] = pageUrl(end)
+	}
+	if offset > 0 {
+		previous := offset - size
+		if previous < 0 {
+			previous = 0
+		}
+		links["previous"] = pageUrl(previous)
+	}
+	page := make([]object, 0, end-offset)
+	for _, item := range items[offset:end] {
+		page = append(page, ex.sparse(item))
+	}
+	payload := map[string]interface{}{"data": page, "links": links}
+	if included := ex.included(items[offset:end]); included != nil {
+		payload["included"] = included
+	}
+	ex.respond(200, payload)
+}
+
+/*
+Return the value of the 'filter[name]' query parameter. If 'required' and
+missing, respond with an error and return false.
+*/
+func (ex *exchange) filter(name string, required bool) (string, bool) {
+	value := ex.request.URL.Query().Get(fmt.Sprintf("filter[%s]", name))
+	if value == "" && required {
+		ex.error(400, "invalid", fmt.Sprintf("'filter[%s]' is required", name))
+		return "", false
+	}
+	return value, true
+}
+
+/*
+Parse a {json:api} request body. Relationships are flattened to the ids they
+point to.
+*/
+func (ex *exchange) parseBody() (map[string]interface{}, map[string]string, bool) {
+	var payload struct {
+		Data struct {
+			Attributes    map[string]interface{} `json:"attributes"`
+			Relationships map[string]struct {
+				Data jsonapi.ResourceIdentifier `json:"data"`
+			} `json:"relationships"`
+		} `json:"data"`
+	}
+	if !ex.decode(&payload) {
+		return nil, nil, false
+	}
+	relationships := make(map[string]string)
+	for key, value := range payload.Data.Relationships {
+		relationships[key] = value.Data.Id
+	}
+	attributes := payload.Data.Attributes
+	if attributes == nil {
+		attributes = make(map[string]interface{})
+	}
+	return attributes, relationships, true
+}
+
+// Decode a JSON request body into 'payload' or respond with an error
+func (ex *exchange) decode(payload interface{}) bool {
+	err := json.NewDecoder(ex.request.Body).Decode(payload)
+	if err != nil {
+		ex.error(400, "parse_error", err.Error())
+		return false
+	}
+	return true
+}
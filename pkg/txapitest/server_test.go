@@ -0,0 +1,353 @@
+package txapitest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+func getTestServer(t *testing.T) (*Server, jsonapi.Connection) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	project := server.Store.AddProject("org", "proj", "en", "el", "fr")
+	server.Store.AddResource(
+		project.Id, "res", "KEYVALUEJSON",
+		[]byte(`{"hello": "Hello", "bye": "Goodbye"}`),
+	)
+	return server, server.Connection()
+}
+
+func getTestResource(t *testing.T, api *jsonapi.Connection) *jsonapi.Resource {
+	organization, err := txapi.GetOrganization(api, "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := txapi.GetProject(api, organization, "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource, err := txapi.GetResource(api, project, "res")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource == nil {
+		t.Fatal("Resource not found")
+	}
+	return resource
+}
+
+func TestGetProjectsPaginated(t *testing.T) {
+	server, api := getTestServer(t)
+	server.Store.AddProject("org", "another", "en")
+	server.Store.AddProject("org", "third", "en")
+	server.Store.AddProject("other_org", "invisible", "en")
+	server.PageSize = 1
+
+	organization, err := txapi.GetOrganization(&api, "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects, err := txapi.GetProjects(&api, organization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 3 {
+		t.Errorf("Got %d projects, expected 3", len(projects))
+	}
+	listRequests := 0
+	for _, request := range server.Requests() {
+		if request.Path == "/projects" {
+			listRequests++
+		}
+	}
+	if listRequests != 3 {
+		t.Errorf("Got %d requests for projects, expected 3", listRequests)
+	}
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	server, api := getTestServer(t)
+	server.JobSteps = 2
+	resource := getTestResource(t, &api)
+
+	upload, err := txapi.UploadSource(&api, resource, bytes.NewReader(
+		[]byte(`{"hello": "Hi", "new": "New"}`),
+	), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upload.Attributes["status"] != "pending" {
+		t.Errorf("Got status %v, expected pending", upload.Attributes["status"])
+	}
+	err = txapi.PollSourceUpload(upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attributes txapi.ResourceStringAsyncUploadAttributes
+	_ = upload.MapAttributes(&attributes)
+	details := attributes.Details
+	if details.StringsCreated != 1 || details.StringsUpdated != 1 ||
+		details.StringsDeleted != 1 {
+		t.Errorf("Got upload details %+v", details)
+	}
+
+	upload, err = txapi.UploadTranslation(
+		&api, resource, &jsonapi.Resource{Type: "languages", Id: "l:el"},
+		bytes.NewReader([]byte(`{"hello": "Γειά"}`)), false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollTranslationUpload(upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := txapi.GetResourceStats(&api, resource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var greek txapi.ResourceLanguageStatsAttributes
+	_ = stats["l:el"].MapAttributes(&greek)
+	if greek.TotalStrings != 2 || greek.TranslatedStrings != 1 {
+		t.Errorf("Got Greek stats %+v", greek)
+	}
+
+	download, err := txapi.CreateTranslationsAsyncDownload(
+		&api, resource, "el", "text", "default", "default",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "el.json")
+	err = txapi.PollTranslationDownload(download, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != `{"hello": "Γειά"}` {
+		t.Errorf("Got downloaded file %s", content)
+	}
+}
+
+func TestUploadInvalidFile(t *testing.T) {
+	_, api := getTestServer(t)
+	resource := getTestResource(t, &api)
+
+	upload, err := txapi.UploadSource(
+		&api, resource, bytes.NewReader([]byte(`{"hello`)), false, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollSourceUpload(upload)
+	var uploadError *txapi.ResourceStringAsyncUploadAttributes
+	if !errors.As(err, &uploadError) ||
+		uploadError.Errors[0].Code != "parse_error" {
+		t.Errorf("Got error %v, expected a parse error", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	server, api := getTestServer(t)
+	resource := getTestResource(t, &api)
+	branch := server.Store.AddResource(
+		resource.Relationships["project"].DataSingular.Id, "feature--res",
+		"KEYVALUEJSON", []byte(`{"hello": "Hello there"}`),
+	)
+	branch.BaseId = resource.Id
+	branchResource, err := txapi.GetResourceById(&api, branch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merge, err := txapi.CreateAsyncResourceMerge(
+		&api, branchResource, "USE_HEAD", false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollResourceMerge(merge, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merge.Relationships["base"].DataSingular.Id != resource.Id {
+		t.Errorf("Got merge %+v", merge.Relationships)
+	}
+	base := server.Store.Resources[resource.Id]
+	if string(base.Content) != `{"hello": "Hello there"}` {
+		t.Errorf("Got base content %s", base.Content)
+	}
+}
+
+func TestMergeDiverged(t *testing.T) {
+	server, api := getTestServer(t)
+	resource := getTestResource(t, &api)
+	branch := server.Store.AddResource(
+		resource.Relationships["project"].DataSingular.Id, "feature--res",
+		"KEYVALUEJSON", []byte(`{"hello": "Hello there"}`),
+	)
+	branch.BaseId = resource.Id
+	branch.BaseContent = []byte(`{"hello": "Hello"}`)
+	branchResource, err := txapi.GetResourceById(&api, branch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merge, err := txapi.CreateAsyncResourceMerge(
+		&api, branchResource, "USE_HEAD", false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollResourceMerge(merge, time.Millisecond)
+	var mergeError *txapi.ResourceAsyncMergeAttributes
+	if !errors.As(err, &mergeError) || mergeError.Errors[0].Code != "diverged" {
+		t.Fatalf("Got error %v, expected a divergence", err)
+	}
+
+	merge, err = txapi.CreateAsyncResourceMerge(
+		&api, branchResource, "USE_HEAD", true,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollResourceMerge(merge, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThrottling(t *testing.T) {
+	server, api := getTestServer(t)
+	server.Throttle(2, 0)
+
+	_, err := txapi.GetOrganization(&api, "org")
+	var apiError *jsonapi.RetryError
+	if !errors.As(err, &apiError) {
+		t.Errorf("Got error %v, expected a throttling error", err)
+	}
+
+	policy := jsonapi.DefaultRetryPolicy()
+	policy.InitialInterval = time.Millisecond
+	api.RetryPolicy = policy
+	organization, err := txapi.GetOrganization(&api, "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if organization.Attributes["slug"] != "org" {
+		t.Errorf("Got organization %+v", organization.Attributes)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server, _ := getTestServer(t)
+	server.Token = "secret"
+	api := jsonapi.Connection{Host: server.URL, Token: "wrong"}
+
+	_, err := txapi.GetOrganization(&api, "org")
+	var apiError *jsonapi.Error
+	if !errors.As(err, &apiError) || apiError.StatusCode != 401 {
+		t.Errorf("Got error %v, expected 401", err)
+	}
+
+	api = server.Connection()
+	_, err = txapi.GetOrganization(&api, "org")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIncludeAndSparseFields(t *testing.T) {
+	server, api := getTestServer(t)
+	resource, err := txapi.GetResourceById(&api, "o:org:p:proj:r:res")
+	if err != nil {
+		t.Fatal(err)
+	}
+	relationship := resource.Relationships["project"]
+	if !relationship.Fetched ||
+		relationship.DataSingular.Attributes["slug"] != "proj" {
+		t.Errorf("Project was not included: %+v", relationship.DataSingular)
+	}
+
+	query := jsonapi.Query{
+		Filters: map[string]string{"project": "o:org:p:proj"},
+		Fields:  map[string][]string{"resources": {"slug"}},
+	}.Encode()
+	resources, err := api.ListAll(
+		context.Background(), "resources", query, jsonapi.ListOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || len(resources[0].Attributes) != 1 ||
+		resources[0].Attributes["slug"] != "res" {
+		t.Errorf("Got sparse resources %+v", resources)
+	}
+	if len(server.Requests()) != 2 {
+		t.Errorf("Got %d requests, expected 2", len(server.Requests()))
+	}
+}
+
+func TestResourceTranslations(t *testing.T) {
+	server, api := getTestServer(t)
+	resource := getTestResource(t, &api)
+	err := server.Store.SetTranslation(
+		resource.Id, "el", []byte(`{"hello": "Γεια"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	untranslated := false
+	translations, err := txapi.GetResourceTranslations(
+		&api, resource, "el",
+		txapi.ResourceTranslationsFilter{Translated: &untranslated},
+		jsonapi.ListOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(translations) != 1 || translations[0].Attributes["strings"] != nil {
+		t.Fatalf("Got untranslated %+v", translations)
+	}
+	sourceString := translations[0].Relationships["resource_string"]
+	if !sourceString.Fetched ||
+		sourceString.DataSingular.Attributes["key"] != "bye" {
+		t.Errorf("Source string was not included: %+v", sourceString.DataSingular)
+	}
+
+	translations[0].Attributes["strings"] = map[string]string{"other": "Αντίο"}
+	translations[0].Attributes["reviewed"] = true
+	err = txapi.UpdateResourceTranslations(&api, translations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translations[0].Attributes["datetime_reviewed"] == nil {
+		t.Errorf("Translation was not updated: %+v", translations[0].Attributes)
+	}
+
+	stats, err := txapi.GetResourceStats(&api, resource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	el := stats["l:el"]
+	if el.Attributes["translated_strings"] != float64(2) ||
+		el.Attributes["reviewed_strings"] != float64(1) {
+		t.Errorf("Got stats %+v", el.Attributes)
+	}
+
+	translations[0].Attributes["proofread"] = true
+	translations[0].Attributes["reviewed"] = false
+	err = txapi.UpdateResourceTranslations(&api, translations)
+	if err == nil {
+		t.Error("Expected an error for proofreading an unreviewed translation")
+	}
+}
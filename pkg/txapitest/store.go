@@ -0,0 +1,423 @@
+package txapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+Store
+Everything the fake server knows about. It is plain data so that it can be
+inspected by tests and serialised as JSON. Objects are keyed by their API id.
+*/
+type Store struct {
+	Organizations map[string]*Organization `json:"organizations"`
+	Projects      map[string]*Project      `json:"projects"`
+	Resources     map[string]*Resource     `json:"resources"`
+	Languages     map[string]*Language     `json:"languages"`
+	I18nFormats   map[string]*I18nFormat   `json:"i18n_formats"`
+}
+
+type Organization struct {
+	Id      string `json:"id"`
+	Slug    string `json:"slug"`
+	Name    string `json:"name"`
+	Private bool   `json:"private"`
+}
+
+type Project struct {
+	Id             string   `json:"id"`
+	Slug           string   `json:"slug"`
+	Name           string   `json:"name"`
+	OrganizationId string   `json:"organization_id"`
+	SourceLanguage string   `json:"source_language"`
+	Languages      []string `json:"languages"`
+	Private        bool     `json:"private"`
+	Archived       bool     `json:"archived,omitempty"`
+	// Settings that can be changed through the API and mean nothing to the
+	// fake
+	Description     string   `json:"description,omitempty"`
+	LongDescription string   `json:"long_description,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	TMFillup        bool     `json:"translation_memory_fillup,omitempty"`
+	InstructionsURL string   `json:"instructions_url,omitempty"`
+	HomepageURL     string   `json:"homepage_url,omitempty"`
+	RepositoryURL   string   `json:"repository_url,omitempty"`
+	License         string   `json:"license,omitempty"`
+	Created         string   `json:"datetime_created"`
+	Modified        string   `json:"datetime_modified"`
+}
+
+type Resource struct {
+	Id         string   `json:"id"`
+	Slug       string   `json:"slug"`
+	Name       string   `json:"name"`
+	ProjectId  string   `json:"project_id"`
+	I18nFormat string   `json:"i18n_format"`
+	BaseId     string   `json:"base_id,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	// Resources accept translations unless this is set
+	NotAcceptingTranslations bool   `json:"not_accepting_translations,omitempty"`
+	Created                  string `json:"datetime_created"`
+	Modified                 string `json:"datetime_modified"`
+	// The last source file that was uploaded
+	Content File `json:"content,omitempty"`
+	// For branches, the base's source file when the branch was created; merges
+	// fail without 'force' if the base changed since
+	BaseContent File `json:"base_content,omitempty"`
+	// Language code -> the last translation file that was uploaded
+	Translations map[string]*Translation `json:"translations,omitempty"`
+	// String key -> metadata set through the API, for strings that have any
+	Strings map[string]*StringMetadata `json:"strings,omitempty"`
+}
+
+type StringMetadata struct {
+	Context          string   `json:"context,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	CharacterLimit   int      `json:"character_limit,omitempty"`
+	DeveloperComment string   `json:"developer_comment,omitempty"`
+	Instructions     string   `json:"instructions,omitempty"`
+	Modified         string   `json:"datetime_modified,omitempty"`
+	Deleted          bool     `json:"deleted,omitempty"`
+	// When the string first appeared in, and was last changed by, a source
+	// upload; empty means when the resource was created and last modified
+	Created      string `json:"datetime_created,omitempty"`
+	TextModified string `json:"strings_datetime_modified,omitempty"`
+}
+
+type Translation struct {
+	Content  File   `json:"content"`
+	Modified string `json:"datetime_modified"`
+	// String key -> translations edited through the API since the file was
+	// uploaded; they take precedence over the file's contents
+	Strings map[string]*StringTranslation `json:"strings,omitempty"`
+}
+
+type StringTranslation struct {
+	// Empty if the translation was removed
+	Text      string `json:"text"`
+	Reviewed  bool   `json:"reviewed,omitempty"`
+	Proofread bool   `json:"proofread,omitempty"`
+	Modified  string `json:"datetime_modified"`
+}
+
+/*
+File
+The contents of a file. Saved in JSON as a plain string so that stores are easy
+to write by hand, or as '{"base64": "..."}' if the file is not text.
+*/
+type File []byte
+
+func (file File) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(file) {
+		return json.Marshal(string(file))
+	}
+	return json.Marshal(map[string][]byte{"base64": file})
+}
+
+func (file *File) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*file = File(text)
+		return nil
+	}
+	var encoded map[string][]byte
+	err := json.Unmarshal(data, &encoded)
+	if err != nil {
+		return fmt.Errorf("file content must be a string or base64: %w", err)
+	}
+	*file = encoded["base64"]
+	return nil
+}
+
+type Language struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Rtl  bool   `json:"rtl"`
+}
+
+type I18nFormat struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	FileExtensions []string `json:"file_extensions"`
+	MediaType      string   `json:"media_type"`
+}
+
+/*
+NewStore
+Return a store with a few common languages and file formats and nothing else
+*/
+func NewStore() *Store {
+	store := &Store{
+		Organizations: make(map[string]*Organization),
+		Projects:      make(map[string]*Project),
+		Resources:     make(map[string]*Resource),
+		Languages:     make(map[string]*Language),
+		I18nFormats:   make(map[string]*I18nFormat),
+	}
+	for _, language := range []Language{
+		{Code: "en", Name: "English"},
+		{Code: "el", Name: "Greek"},
+		{Code: "fr", Name: "French"},
+		{Code: "de", Name: "German"},
+		{Code: "es", Name: "Spanish"},
+		{Code: "it", Name: "Italian"},
+		{Code: "pt_BR", Name: "Portuguese (Brazil)"},
+		{Code: "ja", Name: "Japanese"},
+		{Code: "zh_CN", Name: "Chinese (China)"},
+		{Code: "ar", Name: "Arabic", Rtl: true},
+	} {
+		language := language
+		store.Languages[languageId(language.Code)] = &language
+	}
+	for _, format := range []I18nFormat{
+		{"KEYVALUEJSON", "JSON (Key-Value)", []string{".json"},
+			"application/json"},
+		{"STRUCTURED_JSON", "Structured JSON", []string{".json"},
+			"application/json"},
+		{"PO", "Gettext", []string{".po", ".pot"}, "text/x-po"},
+		{"YML", "Yaml", []string{".yml", ".yaml"}, "text/yaml"},
+		{"ANDROID", "Android", []string{".xml"}, "application/xml"},
+		{"STRINGS", "Apple strings", []string{".strings"}, "text/plain"},
+		{"XLIFF", "XLIFF", []string{".xlf", ".xliff"},
+			"application/x-xliff+xml"},
+	} {
+		format := format
+		store.I18nFormats[format.Name] = &format
+	}
+	return store
+}
+
+/*
+LoadStore
+Load a store saved with Save. The common languages and file formats of
+NewStore are available even if the file does not mention them and ids may be
+omitted from the objects, since they are the keys they are saved under. So can
+names and timestamps.
+*/
+func LoadStore(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	store := NewStore()
+	err = json.Unmarshal(data, store)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store '%s': %w", path, err)
+	}
+	now := timestamp()
+	for id, organization := range store.Organizations {
+		organization.Id = id
+		if organization.Name == "" {
+			organization.Name = organization.Slug
+		}
+	}
+	for id, project := range store.Projects {
+		project.Id = id
+		if project.Name == "" {
+			project.Name = project.Slug
+		}
+		if project.Created == "" {
+			project.Created, project.Modified = now, now
+		}
+		if _, exists := store.Organizations[project.OrganizationId]; !exists {
+			store.AddOrganization(
+				strings.TrimPrefix(project.OrganizationId, "o:"),
+			)
+		}
+	}
+	for id, resource := range store.Resources {
+		resource.Id = id
+		if resource.Name == "" {
+			resource.Name = resource.Slug
+		}
+		if resource.Created == "" {
+			resource.Created, resource.Modified = now, now
+		}
+		if resource.Translations == nil {
+			resource.Translations = make(map[string]*Translation)
+		}
+	}
+	for id, language := range store.Languages {
+		if language.Code == "" {
+			language.Code = strings.TrimPrefix(id, "l:")
+		}
+	}
+	for name, format := range store.I18nFormats {
+		format.Name = name
+	}
+	return store, nil
+}
+
+func (store *Store) Save(path string) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (store *Store) AddOrganization(slug string) *Organization {
+	organization := &Organization{
+		Id: organizationId(slug), Slug: slug, Name: slug,
+	}
+	store.Organizations[organization.Id] = organization
+	return organization
+}
+
+/*
+AddProject
+Add a project (and its organization, if missing) with the given source and
+target language codes
+*/
+func (store *Store) AddProject(
+	organizationSlug, slug, sourceLanguage string, languages ...string,
+) *Project {
+	orgId := organizationId(organizationSlug)
+	if _, exists := store.Organizations[orgId]; !exists {
+		store.AddOrganization(organizationSlug)
+	}
+	now := timestamp()
+	project := &Project{
+		Id:             fmt.Sprintf("%s:p:%s", orgId, slug),
+		Slug:           slug,
+		Name:           slug,
+		OrganizationId: orgId,
+		SourceLanguage: sourceLanguage,
+		Languages:      append([]string{}, languages...),
+		Created:        now,
+		Modified:       now,
+	}
+	store.Projects[project.Id] = project
+	return project
+}
+
+/*
+AddResource
+Add a resource to an existing project. 'content' is the source file, it may be
+nil.
+*/
+func (store *Store) AddResource(
+	projectId, slug, i18nFormat string, content []byte,
+) *Resource {
+	now := timestamp()
+	resource := &Resource{
+		Id:           fmt.Sprintf("%s:r:%s", projectId, slug),
+		Slug:         slug,
+		Name:         slug,
+		ProjectId:    projectId,
+		I18nFormat:   i18nFormat,
+		Created:      now,
+		Modified:     now,
+		Content:      content,
+		Translations: make(map[string]*Translation),
+	}
+	store.Resources[resource.Id] = resource
+	return resource
+}
+
+func (store *Store) AddLanguage(code, name string) *Language {
+	language := &Language{Code: code, Name: name}
+	store.Languages[languageId(code)] = language
+	return language
+}
+
+/*
+SetTranslation
+Set the translation file of a resource for a language, as if it had been
+uploaded
+*/
+func (store *Store) SetTranslation(
+	resourceId, languageCode string, content []byte,
+) error {
+	resource, exists := store.Resources[resourceId]
+	if !exists {
+		return fmt.Errorf("resource '%s' does not exist", resourceId)
+	}
+	if resource.Translations == nil {
+		resource.Translations = make(map[string]*Translation)
+	}
+	resource.Translations[languageCode] = &Translation{
+		Content:  content,
+		Modified: timestamp(),
+	}
+	return nil
+}
+
+/*
+Return the translated strings, keyed like stringKeys: the ones in the uploaded
+file with the ones edited through the API applied on top
+*/
+func (translation *Translation) texts() map[string]string {
+	result := stringKeys(translation.Content)
+	for key, edited := range translation.Strings {
+		if edited.Text == "" {
+			delete(result, key)
+		} else {
+			result[key] = edited.Text
+		}
+	}
+	return result
+}
+
+func (resource *Resource) StringCount() int {
+	return len(stringKeys(resource.Content))
+}
+
+/*
+Return the strings of a file, keyed by something that identifies them: the
+paths to the string leaves of a JSON document or, for any other format, the
+non-empty lines themselves. Good enough for statistics and upload reports.
+*/
+func stringKeys(content []byte) map[string]string {
+	result := make(map[string]string)
+	var parsed interface{}
+	if json.Unmarshal(content, &parsed) == nil {
+		collectJsonStrings(parsed, "", result)
+		return result
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			result[line] = line
+		}
+	}
+	return result
+}
+
+func collectJsonStrings(
+	value interface{}, path string, result map[string]string,
+) {
+	switch typed := value.(type) {
+	case string:
+		result[path] = typed
+	case map[string]interface{}:
+		for key, item := range typed {
+			if path != "" {
+				key = path + "." + key
+			}
+			collectJsonStrings(item, key, result)
+		}
+	case []interface{}:
+		for index, item := range typed {
+			collectJsonStrings(item, fmt.Sprintf("%s[%d]", path, index), result)
+		}
+	}
+}
+
+func organizationId(slug string) string {
+	return "o:" + slug
+}
+
+func languageId(code string) string {
+	return "l:" + code
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,54 @@
+package txapitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	err := os.WriteFile(path, []byte(`{
+		"projects": {"o:org:p:proj": {
+			"slug": "proj", "organization_id": "o:org",
+			"source_language": "en", "languages": ["el"]
+		}},
+		"resources": {"o:org:p:proj:r:res": {
+			"slug": "res", "project_id": "o:org:p:proj",
+			"i18n_format": "KEYVALUEJSON", "content": "{\"hello\": \"world\"}"
+		}}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := store.Resources["o:org:p:proj:r:res"]
+	if resource.Id != "o:org:p:proj:r:res" ||
+		string(resource.Content) != `{"hello": "world"}` {
+		t.Errorf("Got resource %+v", resource)
+	}
+	if store.Languages["l:el"] == nil {
+		t.Error("Default languages are missing")
+	}
+
+	err = store.SetTranslation(resource.Id, "el", []byte{0xff, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err = LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := store.Resources[resource.Id].Translations["el"].Content
+	if string(content) != string([]byte{0xff, 0x00}) {
+		t.Errorf("Got binary translation %v", content)
+	}
+}
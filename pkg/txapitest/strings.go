@@ -0,0 +1,256 @@
+package txapitest
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A source string, as derived from the source file of a resource
+type sourceString struct {
+	key   string
+	value string
+	order int
+}
+
+func stringHash(key string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(key)))
+}
+
+func stringId(resource *Resource, key string) string {
+	return fmt.Sprintf("%s:s:%s", resource.Id, stringHash(key))
+}
+
+func (resource *Resource) sourceStrings() []sourceString {
+	values := stringKeys(resource.Content)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if metadata, exists := resource.Strings[key]; exists && metadata.Deleted {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]sourceString, 0, len(keys))
+	for i, key := range keys {
+		result = append(result, sourceString{key, values[key], i})
+	}
+	return result
+}
+
+// When a source string was created and when its text was last modified
+func (resource *Resource) stringDates(key string) (string, string) {
+	created, modified := resource.Created, resource.Modified
+	if metadata, exists := resource.Strings[key]; exists {
+		if metadata.Created != "" {
+			created = metadata.Created
+		}
+		if metadata.TextModified != "" {
+			modified = metadata.TextModified
+		}
+	}
+	return created, modified
+}
+
+/*
+Replace the source file of a resource, keeping track of when each string was
+created and changed
+*/
+func (resource *Resource) setContent(content []byte) {
+	now := timestamp()
+	old := stringKeys(resource.Content)
+	for key, value := range stringKeys(content) {
+		oldValue, existed := old[key]
+		created, modified := resource.stringDates(key)
+		if !existed {
+			created, modified = now, now
+		} else if oldValue != value {
+			modified = now
+		}
+		metadata := resource.metadata(key)
+		metadata.Created, metadata.TextModified = created, modified
+	}
+	resource.Content = content
+	resource.Modified = now
+}
+
+func (resource *Resource) metadata(key string) *StringMetadata {
+	if resource.Strings == nil {
+		resource.Strings = make(map[string]*StringMetadata)
+	}
+	if _, exists := resource.Strings[key]; !exists {
+		resource.Strings[key] = &StringMetadata{}
+	}
+	return resource.Strings[key]
+}
+
+func (ex *exchange) stringObject(resource *Resource, item sourceString) object {
+	metadata, exists := resource.Strings[item.key]
+	if !exists {
+		metadata = &StringMetadata{}
+	}
+	var characterLimit interface{}
+	if metadata.CharacterLimit > 0 {
+		characterLimit = metadata.CharacterLimit
+	}
+	tags := metadata.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	metadataModified := metadata.Modified
+	if metadataModified == "" {
+		metadataModified = resource.Created
+	}
+	created, modified := resource.stringDates(item.key)
+	id := stringId(resource, item.key)
+	return object{
+		"type": "resource_strings",
+		"id":   id,
+		"attributes": map[string]interface{}{
+			"appearance_order":           item.order,
+			"key":                        item.key,
+			"context":                    metadata.Context,
+			"strings":                    map[string]string{"other": item.value},
+			"tags":                       tags,
+			"occurrences":                "",
+			"developer_comment":          metadata.DeveloperComment,
+			"instructions":               metadata.Instructions,
+			"character_limit":            characterLimit,
+			"pluralized":                 false,
+			"string_hash":                stringHash(item.key),
+			"datetime_created":           created,
+			"strings_datetime_modified":  modified,
+			"metadata_datetime_modified": metadataModified,
+		},
+		"relationships": map[string]interface{}{
+			"resource": map[string]interface{}{
+				"data": identifier("resources", resource.Id),
+			},
+		},
+		"links": map[string]string{
+			"self": ex.url("/resource_strings/%s", id),
+		},
+	}
+}
+
+func (ex *exchange) listStrings() {
+	resourceId, ok := ex.filter("resource", true)
+	if !ok {
+		return
+	}
+	resource, exists := ex.server.Store.Resources[resourceId]
+	if !exists {
+		ex.error(400, "invalid", "resource does not exist")
+		return
+	}
+	query := ex.request.URL.Query()
+	key := query.Get("filter[key]")
+	var tags []string
+	if value := query.Get("filter[tags][all]"); value != "" {
+		tags = strings.Split(value, ",")
+	}
+	after := query.Get("filter[strings_date_modified][gte]")
+	before := query.Get("filter[strings_date_modified][lte]")
+
+	items := []object{}
+	for _, item := range resource.sourceStrings() {
+		if key != "" && item.key != key {
+			continue
+		}
+		_, modified := resource.stringDates(item.key)
+		if (after != "" && modified < after) ||
+			(before != "" && modified > before) {
+			continue
+		}
+		matches := true
+		for _, tag := range tags {
+			metadata, exists := resource.Strings[item.key]
+			if !exists || indexOf(metadata.Tags, tag) == -1 {
+				matches = false
+			}
+		}
+		if matches {
+			items = append(items, ex.stringObject(resource, item))
+		}
+	}
+	ex.respondPage(items)
+}
+
+// Find the resource and source string a string id refers to
+func (ex *exchange) findString(id string) (*Resource, sourceString, bool) {
+	parts := strings.SplitN(id, ":s:", 2)
+	if len(parts) != 2 {
+		return nil, sourceString{}, false
+	}
+	resource, exists := ex.server.Store.Resources[parts[0]]
+	if !exists {
+		return nil, sourceString{}, false
+	}
+	for _, item := range resource.sourceStrings() {
+		if stringHash(item.key) == parts[1] {
+			return resource, item, true
+		}
+	}
+	return nil, sourceString{}, false
+}
+
+func (ex *exchange) getString(id string) {
+	resource, item, exists := ex.findString(id)
+	if !exists {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.stringObject(resource, item))
+}
+
+func (ex *exchange) updateString(id string) {
+	resource, item, exists := ex.findString(id)
+	if !exists {
+		ex.notFound()
+		return
+	}
+	attributes, _, ok := ex.parseBody()
+	if !ok {
+		return
+	}
+	metadata := resource.metadata(item.key)
+	for name, value := range attributes {
+		switch name {
+		case "context":
+			metadata.Context, _ = value.(string)
+		case "developer_comment":
+			metadata.DeveloperComment, _ = value.(string)
+		case "instructions":
+			metadata.Instructions, _ = value.(string)
+		case "character_limit":
+			limit, _ := value.(float64)
+			metadata.CharacterLimit = int(limit)
+		case "tags":
+			metadata.Tags = nil
+			values, _ := value.([]interface{})
+			for _, tag := range values {
+				if text, ok := tag.(string); ok {
+					metadata.Tags = append(metadata.Tags, text)
+				}
+			}
+		default:
+			ex.error(400, "invalid", fmt.Sprintf(
+				"'%s' cannot be changed", name,
+			))
+			return
+		}
+	}
+	metadata.Modified = timestamp()
+	ex.respondSingle(200, ex.stringObject(resource, item))
+}
+
+func (ex *exchange) deleteString(id string) {
+	resource, item, exists := ex.findString(id)
+	if !exists {
+		ex.notFound()
+		return
+	}
+	resource.metadata(item.key).Deleted = true
+	ex.respond(204, nil)
+}
@@ -0,0 +1,253 @@
+package txapitest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+// Maximum number of translations the API accepts in a bulk update
+const bulkLimit = 150
+
+func translationId(resource *Resource, key, code string) string {
+	return fmt.Sprintf("%s:%s", stringId(resource, key), languageId(code))
+}
+
+// The translation of a single source string, as seen through the API
+type stringTranslationState struct {
+	text      string
+	reviewed  bool
+	proofread bool
+	modified  string
+}
+
+func (resource *Resource) translationState(
+	key, code string,
+) stringTranslationState {
+	translation, exists := resource.Translations[code]
+	if !exists {
+		return stringTranslationState{}
+	}
+	if edited, exists := translation.Strings[key]; exists {
+		return stringTranslationState{
+			edited.Text, edited.Reviewed, edited.Proofread, edited.Modified,
+		}
+	}
+	text := stringKeys(translation.Content)[key]
+	if text == "" {
+		return stringTranslationState{}
+	}
+	return stringTranslationState{text: text, modified: translation.Modified}
+}
+
+func (ex *exchange) translationObject(
+	resource *Resource, item sourceString, code string,
+) object {
+	state := resource.translationState(item.key, code)
+	var texts, translated, reviewed, proofread interface{}
+	if state.text != "" {
+		texts = map[string]string{"other": state.text}
+		translated = state.modified
+	}
+	if state.reviewed {
+		reviewed = state.modified
+	}
+	if state.proofread {
+		proofread = state.modified
+	}
+	id := translationId(resource, item.key, code)
+	return object{
+		"type": "resource_translations",
+		"id":   id,
+		"attributes": map[string]interface{}{
+			"strings":             texts,
+			"reviewed":            state.reviewed,
+			"proofread":           state.proofread,
+			"finalized":           state.proofread,
+			"origin":              "EDITOR",
+			"datetime_created":    resource.Created,
+			"datetime_translated": translated,
+			"datetime_reviewed":   reviewed,
+			"datetime_proofread":  proofread,
+		},
+		"relationships": map[string]interface{}{
+			"resource": map[string]interface{}{
+				"data": identifier("resources", resource.Id),
+			},
+			"language": map[string]interface{}{
+				"data": identifier("languages", languageId(code)),
+			},
+			"resource_string": map[string]interface{}{
+				"data": identifier("resource_strings", stringId(resource, item.key)),
+			},
+		},
+		"links": map[string]string{
+			"self": ex.url("/resource_translations/%s", id),
+		},
+	}
+}
+
+// Find the resource, language and source string a translation id refers to
+func (ex *exchange) findTranslation(
+	id string,
+) (*Resource, string, sourceString, bool) {
+	index := strings.LastIndex(id, ":l:")
+	if index == -1 {
+		return nil, "", sourceString{}, false
+	}
+	resource, item, exists := ex.findString(id[:index])
+	if !exists {
+		return nil, "", sourceString{}, false
+	}
+	code := id[index+len(":l:"):]
+	project := ex.server.Store.Projects[resource.ProjectId]
+	if indexOf(project.Languages, code) == -1 {
+		return nil, "", sourceString{}, false
+	}
+	return resource, code, item, true
+}
+
+func (ex *exchange) listTranslations() {
+	resourceId, ok := ex.filter("resource", true)
+	if !ok {
+		return
+	}
+	language, ok := ex.filter("language", true)
+	if !ok {
+		return
+	}
+	resource, exists := ex.server.Store.Resources[resourceId]
+	if !exists {
+		ex.error(400, "invalid", "resource does not exist")
+		return
+	}
+	code := strings.TrimPrefix(language, "l:")
+	project := ex.server.Store.Projects[resource.ProjectId]
+	if indexOf(project.Languages, code) == -1 {
+		ex.error(400, "invalid", fmt.Sprintf(
+			"'%s' is not a target language of the project", code,
+		))
+		return
+	}
+	query := ex.request.URL.Query()
+	key := query.Get("filter[resource_string][key]")
+	translated := query.Get("filter[translated]")
+	reviewed := query.Get("filter[reviewed]")
+	since := query.Get("filter[date_translated][gte]")
+
+	items := []object{}
+	for _, item := range resource.sourceStrings() {
+		state := resource.translationState(item.key, code)
+		if (key != "" && item.key != key) ||
+			(translated != "" && translated != fmt.Sprint(state.text != "")) ||
+			(reviewed != "" && reviewed != fmt.Sprint(state.reviewed)) ||
+			(since != "" && (state.text == "" || state.modified < since)) {
+			continue
+		}
+		items = append(items, ex.translationObject(resource, item, code))
+	}
+	ex.respondPage(items)
+}
+
+func (ex *exchange) getTranslation(id string) {
+	resource, code, item, exists := ex.findTranslation(id)
+	if !exists {
+		ex.notFound()
+		return
+	}
+	ex.respondSingle(200, ex.translationObject(resource, item, code))
+}
+
+/*
+Update many translations at once. Like the real API, this only accepts the
+"bulk" profile and either applies all the changes or none of them.
+*/
+func (ex *exchange) bulkUpdateTranslations() {
+	if !strings.Contains(ex.request.Header.Get("Content-Type"), `profile="bulk"`) {
+		ex.error(415, "unsupported_media_type",
+			"Only bulk updates are supported on this endpoint")
+		return
+	}
+	var payload jsonapi.PayloadPluralRead
+	if !ex.decode(&payload) {
+		return
+	}
+	if len(payload.Data) > bulkLimit {
+		ex.error(400, "invalid", fmt.Sprintf(
+			"at most %d translations can be updated at once", bulkLimit,
+		))
+		return
+	}
+
+	type change struct {
+		resource *Resource
+		code     string
+		item     sourceString
+		state    stringTranslationState
+	}
+	changes := make([]change, 0, len(payload.Data))
+	for _, data := range payload.Data {
+		resource, code, item, exists := ex.findTranslation(data.Id)
+		if !exists {
+			ex.error(400, "invalid", fmt.Sprintf(
+				"translation '%s' does not exist", data.Id,
+			))
+			return
+		}
+		state := resource.translationState(item.key, code)
+		for name, value := range data.Attributes {
+			switch name {
+			case "strings":
+				texts, _ := value.(map[string]interface{})
+				state.text, _ = texts["other"].(string)
+				if value != nil && state.text == "" {
+					ex.error(400, "invalid", fmt.Sprintf(
+						"translation '%s' needs the 'other' plural form", data.Id,
+					))
+					return
+				}
+			case "reviewed":
+				state.reviewed, _ = value.(bool)
+			case "proofread":
+				state.proofread, _ = value.(bool)
+			default:
+				ex.error(400, "invalid", fmt.Sprintf(
+					"'%s' cannot be changed", name,
+				))
+				return
+			}
+		}
+		if state.text == "" {
+			state.reviewed, state.proofread = false, false
+		} else if state.proofread && !state.reviewed {
+			ex.error(400, "invalid", fmt.Sprintf(
+				"translation '%s' must be reviewed before it is proofread",
+				data.Id,
+			))
+			return
+		}
+		state.modified = timestamp()
+		changes = append(changes, change{resource, code, item, state})
+	}
+
+	items := make([]object, 0, len(changes))
+	for _, c := range changes {
+		if _, exists := c.resource.Translations[c.code]; !exists {
+			_ = ex.server.Store.SetTranslation(c.resource.Id, c.code, nil)
+		}
+		translation := c.resource.Translations[c.code]
+		if translation.Strings == nil {
+			translation.Strings = make(map[string]*StringTranslation)
+		}
+		translation.Strings[c.item.key] = &StringTranslation{
+			Text:      c.state.text,
+			Reviewed:  c.state.reviewed,
+			Proofread: c.state.proofread,
+			Modified:  c.state.modified,
+		}
+		translation.Modified = c.state.modified
+		items = append(items, ex.translationObject(c.resource, c.item, c.code))
+	}
+	ex.respond(200, map[string]interface{}{"data": items})
+}
@@ -0,0 +1,100 @@
+package worker_pool
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+Concurrency
+Limit on how many tasks of a Scheduler may run at the same time, adjusted with
+AIMD (additive increase, multiplicative decrease): every finished task raises
+the limit a little, every call to 'Decrease' (for example when the server
+responds with 429) halves it. The limit always stays between 'min' and 'max'.
+
+	concurrency := worker_pool.NewConcurrency(1, 20)
+	scheduler := worker_pool.NewScheduler(20, false)
+	scheduler.Concurrency = concurrency
+	api.OnRetry = func(*jsonapi.RetryError) { concurrency.Decrease() }
+
+A burst of throttled responses usually arrives at once from all the workers,
+so decreases that happen within 'cooldown' of the previous one are ignored.
+*/
+type Concurrency struct {
+	min      int
+	max      int
+	limit    float64
+	active   int
+	cooldown time.Duration
+	lastCut  time.Time
+	mutex    sync.Mutex
+	cond     *sync.Cond
+}
+
+func NewConcurrency(min, max int) *Concurrency {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	concurrency := Concurrency{
+		min:      min,
+		max:      max,
+		limit:    float64(max),
+		cooldown: time.Second,
+	}
+	concurrency.cond = sync.NewCond(&concurrency.mutex)
+	return &concurrency
+}
+
+/*
+Limit
+Return the number of tasks that are currently allowed to run in parallel
+*/
+func (concurrency *Concurrency) Limit() int {
+	concurrency.mutex.Lock()
+	defer concurrency.mutex.Unlock()
+	return int(concurrency.limit)
+}
+
+func (concurrency *Concurrency) Decrease() {
+	concurrency.mutex.Lock()
+	defer concurrency.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(concurrency.lastCut) < concurrency.cooldown {
+		return
+	}
+	concurrency.lastCut = now
+	concurrency.limit = concurrency.limit / 2
+	if concurrency.limit < float64(concurrency.min) {
+		concurrency.limit = float64(concurrency.min)
+	}
+}
+
+func (concurrency *Concurrency) Increase() {
+	concurrency.mutex.Lock()
+	defer concurrency.mutex.Unlock()
+	// Grows by roughly 1 every time 'limit' tasks finish
+	concurrency.limit += 1 / concurrency.limit
+	if concurrency.limit > float64(concurrency.max) {
+		concurrency.limit = float64(concurrency.max)
+	}
+	concurrency.cond.Broadcast()
+}
+
+func (concurrency *Concurrency) acquire() {
+	concurrency.mutex.Lock()
+	defer concurrency.mutex.Unlock()
+	for concurrency.active >= int(concurrency.limit) {
+		concurrency.cond.Wait()
+	}
+	concurrency.active++
+}
+
+func (concurrency *Concurrency) release() {
+	concurrency.mutex.Lock()
+	defer concurrency.mutex.Unlock()
+	concurrency.active--
+	concurrency.cond.Broadcast()
+}
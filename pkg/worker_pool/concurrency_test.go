@@ -0,0 +1,95 @@
+package worker_pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyDecreaseAndIncrease(t *testing.T) {
+	concurrency := NewConcurrency(1, 8)
+	if concurrency.Limit() != 8 {
+		t.Errorf("Got limit %d, expected 8", concurrency.Limit())
+	}
+
+	concurrency.Decrease()
+	if concurrency.Limit() != 4 {
+		t.Errorf("Got limit %d, expected 4", concurrency.Limit())
+	}
+
+	// Within the cooldown, so it's ignored
+	concurrency.Decrease()
+	if concurrency.Limit() != 4 {
+		t.Errorf("Got limit %d, expected 4 during cooldown", concurrency.Limit())
+	}
+
+	// Roughly 'limit' finished tasks are needed to grow by 1
+	for i := 0; i < 5; i++ {
+		concurrency.Increase()
+	}
+	if concurrency.Limit() != 5 {
+		t.Errorf("Got limit %d, expected 5", concurrency.Limit())
+	}
+
+	for i := 0; i < 1000; i++ {
+		concurrency.Increase()
+	}
+	if concurrency.Limit() != 8 {
+		t.Errorf("Got limit %d, expected max 8", concurrency.Limit())
+	}
+}
+
+func TestConcurrencyNeverBelowMinimum(t *testing.T) {
+	concurrency := NewConcurrency(2, 4)
+	concurrency.cooldown = 0
+	for i := 0; i < 10; i++ {
+		concurrency.Decrease()
+	}
+	if concurrency.Limit() != 2 {
+		t.Errorf("Got limit %d, expected min 2", concurrency.Limit())
+	}
+}
+
+type countingTask struct {
+	mutex   *sync.Mutex
+	active  *int
+	maximum *int
+}
+
+func (task *countingTask) Run(send func(string), abort func()) {
+	task.mutex.Lock()
+	*task.active++
+	if *task.active > *task.maximum {
+		*task.maximum = *task.active
+	}
+	task.mutex.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	task.mutex.Lock()
+	*task.active--
+	task.mutex.Unlock()
+}
+
+func TestSchedulerRespectsConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	active, maximum := 0, 0
+
+	concurrency := NewConcurrency(1, 8)
+	concurrency.Decrease()
+	concurrency.Decrease()
+	concurrency.cooldown = 0
+	concurrency.Decrease()
+	concurrency.Decrease()
+	// Limit is now 1 and grows slowly; 10 tasks shouldn't get past 4
+
+	scheduler := NewScheduler(8, true)
+	scheduler.Concurrency = concurrency
+	for i := 0; i < 10; i++ {
+		scheduler.Add(&countingTask{&mutex, &active, &maximum})
+	}
+	scheduler.Start()
+	<-scheduler.Wait()
+
+	if maximum > 4 {
+		t.Errorf("%d tasks ran in parallel, expected at most 4", maximum)
+	}
+}
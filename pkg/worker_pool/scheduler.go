@@ -0,0 +1,262 @@
+package worker_pool
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gosuri/uilive"
+	"github.com/mattn/go-isatty"
+)
+
+/*
+Scheduler
+Dependency-aware alternative to Pool. Each task may declare a list of
+prerequisite tasks; it will only be picked up by a worker after all of them
+have finished. Tasks may also be added while the scheduler is running (from
+within another task's 'Run'), which allows pipelines where, for example, the
+task that fetches info about a resource schedules the tasks that upload its
+files:
+
+	type InfoTask struct {
+		scheduler *worker_pool.Scheduler
+		i         int
+	}
+
+	func (task *InfoTask) Run(send func(string), abort func()) {
+		send(fmt.Sprintf("Getting info for %d", task.i))
+		upload := task.scheduler.Add(&UploadTask{task.i})
+		task.scheduler.Add(&PollTask{task.i}, upload)
+	}
+
+	func main() {
+		scheduler := worker_pool.NewScheduler(5, false)
+		for i := 0; i < 40; i++ {
+			scheduler.Add(&InfoTask{scheduler, i})
+		}
+		scheduler.Start()
+		<-scheduler.Wait()
+		if scheduler.IsAborted {
+			fmt.Println("Something went wrong")
+		}
+	}
+
+A task whose prerequisites have finished is started regardless of whether the
+prerequisites succeeded; tasks that need to know should check for themselves.
+Like with Pool, calling 'abort' makes sure no new tasks will be run.
+
+If 'Concurrency' is set, the number of tasks that run in parallel will adapt to
+it instead of always being 'numWorkers' (see Concurrency).
+*/
+type Scheduler struct {
+	numWorkers       int
+	forceNotTerminal bool
+
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	nodes     []*schedulerNode
+	ready     []*schedulerNode
+	finished  int
+	waitGroup sync.WaitGroup
+
+	IsAborted   bool
+	Concurrency *Concurrency
+}
+
+type TaskId int
+
+type schedulerNode struct {
+	id         TaskId
+	task       Task
+	pending    int
+	done       bool
+	dependents []*schedulerNode
+}
+
+func NewScheduler(numWorkers int, forceNotTerminal bool) *Scheduler {
+	scheduler := Scheduler{
+		numWorkers:       numWorkers,
+		forceNotTerminal: forceNotTerminal,
+	}
+	scheduler.cond = sync.NewCond(&scheduler.mutex)
+	return &scheduler
+}
+
+/*
+Add
+Schedule 'task' to run after all the tasks in 'prerequisites' have finished.
+Returns an identifier that can be used as a prerequisite for subsequent tasks.
+Safe to call both before 'Start' and from within running tasks.
+*/
+func (scheduler *Scheduler) Add(task Task, prerequisites ...TaskId) TaskId {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	node := &schedulerNode{id: TaskId(len(scheduler.nodes)), task: task}
+	for _, prerequisite := range prerequisites {
+		if prerequisite < 0 || prerequisite >= node.id {
+			continue
+		}
+		prerequisiteNode := scheduler.nodes[prerequisite]
+		if !prerequisiteNode.done {
+			node.pending++
+			prerequisiteNode.dependents = append(
+				prerequisiteNode.dependents, node,
+			)
+		}
+	}
+	scheduler.nodes = append(scheduler.nodes, node)
+	if node.pending == 0 {
+		scheduler.ready = append(scheduler.ready, node)
+		scheduler.cond.Signal()
+	}
+	return node.id
+}
+
+func (scheduler *Scheduler) Start() {
+	isTerminal := !scheduler.forceNotTerminal &&
+		isatty.IsTerminal(os.Stdout.Fd())
+	messages := make(map[TaskId]string)
+	var progressBar string
+	messageChannel := make(chan message_t)
+	writer := uilive.New()
+	if isTerminal {
+		writer.Start()
+	}
+
+	scheduler.waitGroup.Add(1)
+	workersDone := make(chan struct{})
+	var workersWaitGroup sync.WaitGroup
+
+	for i := 0; i < scheduler.numWorkers; i++ {
+		workersWaitGroup.Add(1)
+		go func() {
+			defer workersWaitGroup.Done()
+			for {
+				node := scheduler.next()
+				if node == nil {
+					return
+				}
+				if !scheduler.IsAborted {
+					send := func(body string) {
+						messageChannel <- message_t{int(node.id), body}
+					}
+					if scheduler.Concurrency != nil {
+						scheduler.Concurrency.acquire()
+					}
+					node.task.Run(send, scheduler.abort)
+					if scheduler.Concurrency != nil {
+						scheduler.Concurrency.release()
+						scheduler.Concurrency.Increase()
+					}
+				}
+				finished, total := scheduler.complete(node)
+				if isTerminal {
+					messageChannel <- message_t{
+						-1, makeProgressBar(int32(finished), total),
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		workersWaitGroup.Wait()
+		workersDone <- struct{}{}
+	}()
+
+	printMessages := func() {
+		ids := make([]int, 0, len(messages))
+		for id := range messages {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+		var tmpMessages []string
+		for _, id := range ids {
+			line := messages[TaskId(id)]
+			if len(line) > 0 {
+				tmpMessages = append(tmpMessages, line)
+			}
+		}
+		if len(progressBar) > 0 {
+			tmpMessages = append(tmpMessages, progressBar)
+		}
+		fmt.Fprintln(writer, strings.Join(tmpMessages, "\n"))
+		writer.Flush()
+	}
+
+	go func() {
+		for {
+			select {
+			case msg := <-messageChannel:
+				if isTerminal {
+					if msg.i == -1 {
+						progressBar = msg.body
+					} else {
+						messages[TaskId(msg.i)] = msg.body
+					}
+					printMessages()
+				} else {
+					fmt.Println(msg.body)
+				}
+			case <-workersDone:
+				if isTerminal {
+					writer.Stop()
+				}
+				scheduler.waitGroup.Done()
+				return
+			}
+		}
+	}()
+}
+
+// Block until a task is ready to run. Returns nil once every task that has
+// been added is finished.
+func (scheduler *Scheduler) next() *schedulerNode {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	for len(scheduler.ready) == 0 &&
+		scheduler.finished < len(scheduler.nodes) {
+		scheduler.cond.Wait()
+	}
+	if len(scheduler.ready) == 0 {
+		return nil
+	}
+	node := scheduler.ready[0]
+	scheduler.ready = scheduler.ready[1:]
+	return node
+}
+
+func (scheduler *Scheduler) complete(node *schedulerNode) (int, int) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	node.done = true
+	scheduler.finished++
+	for _, dependent := range node.dependents {
+		dependent.pending--
+		if dependent.pending == 0 {
+			scheduler.ready = append(scheduler.ready, dependent)
+		}
+	}
+	node.dependents = nil
+	// Wake up everyone; either there is new work or, if everything is
+	// finished, the workers need to exit
+	scheduler.cond.Broadcast()
+	return scheduler.finished, len(scheduler.nodes)
+}
+
+func (scheduler *Scheduler) abort() {
+	// No need to protect this with a Mutex since it only goes from false -> true
+	scheduler.IsAborted = true
+}
+
+func (scheduler *Scheduler) Wait() <-chan struct{} {
+	waitChannel := make(chan struct{})
+	go func() {
+		scheduler.waitGroup.Wait()
+		waitChannel <- struct{}{}
+	}()
+	return waitChannel
+}
@@ -0,0 +1,131 @@
+package worker_pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTask struct {
+	name     string
+	delay    time.Duration
+	mutex    *sync.Mutex
+	finished *[]string
+	then     func()
+}
+
+func (task *recordingTask) Run(send func(string), abort func()) {
+	time.Sleep(task.delay)
+	task.mutex.Lock()
+	*task.finished = append(*task.finished, task.name)
+	task.mutex.Unlock()
+	if task.then != nil {
+		task.then()
+	}
+}
+
+func indexOf(haystack []string, needle string) int {
+	for i, item := range haystack {
+		if item == needle {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestSchedulerRespectsPrerequisites(t *testing.T) {
+	var mutex sync.Mutex
+	var finished []string
+	newTask := func(name string, delay time.Duration) *recordingTask {
+		return &recordingTask{name, delay, &mutex, &finished, nil}
+	}
+
+	scheduler := NewScheduler(4, true)
+	slow := scheduler.Add(newTask("slow", 50*time.Millisecond))
+	fast := scheduler.Add(newTask("fast", 0))
+	scheduler.Add(newTask("after-slow", 0), slow)
+	scheduler.Add(newTask("after-both", 0), slow, fast)
+	scheduler.Add(newTask("after-fast", 0), fast)
+	scheduler.Start()
+	<-scheduler.Wait()
+
+	if len(finished) != 5 {
+		t.Fatalf("Got %d finished tasks, expected 5: %v", len(finished), finished)
+	}
+	if indexOf(finished, "after-slow") < indexOf(finished, "slow") {
+		t.Errorf("'after-slow' ran before 'slow': %v", finished)
+	}
+	if indexOf(finished, "after-both") < indexOf(finished, "slow") ||
+		indexOf(finished, "after-both") < indexOf(finished, "fast") {
+		t.Errorf("'after-both' ran before its prerequisites: %v", finished)
+	}
+	// Pipelines are independent; 'after-fast' must not wait for 'slow'
+	if indexOf(finished, "after-fast") > indexOf(finished, "slow") {
+		t.Errorf("'after-fast' waited for 'slow': %v", finished)
+	}
+}
+
+func TestSchedulerAddFromRunningTask(t *testing.T) {
+	var mutex sync.Mutex
+	var finished []string
+
+	scheduler := NewScheduler(2, true)
+	scheduler.Add(&recordingTask{
+		"parent", 0, &mutex, &finished,
+		func() {
+			child := scheduler.Add(
+				&recordingTask{"child", 10 * time.Millisecond, &mutex, &finished, nil},
+			)
+			scheduler.Add(
+				&recordingTask{"grandchild", 0, &mutex, &finished, nil},
+				child,
+			)
+		},
+	})
+	scheduler.Start()
+	<-scheduler.Wait()
+
+	expected := []string{"parent", "child", "grandchild"}
+	if len(finished) != len(expected) {
+		t.Fatalf("Got %v, expected %v", finished, expected)
+	}
+	for i := range expected {
+		if finished[i] != expected[i] {
+			t.Errorf("Got %v, expected %v", finished, expected)
+		}
+	}
+}
+
+type abortingTask struct{}
+
+func (task *abortingTask) Run(send func(string), abort func()) {
+	abort()
+}
+
+func TestSchedulerAbort(t *testing.T) {
+	var mutex sync.Mutex
+	var finished []string
+
+	scheduler := NewScheduler(1, true)
+	first := scheduler.Add(&abortingTask{})
+	scheduler.Add(&recordingTask{"second", 0, &mutex, &finished, nil}, first)
+	scheduler.Start()
+	<-scheduler.Wait()
+
+	if !scheduler.IsAborted {
+		t.Error("Scheduler should be aborted")
+	}
+	if len(finished) != 0 {
+		t.Errorf("Tasks ran after abort: %v", finished)
+	}
+}
+
+func TestSchedulerEmpty(t *testing.T) {
+	scheduler := NewScheduler(3, true)
+	scheduler.Start()
+	select {
+	case <-scheduler.Wait():
+	case <-time.After(time.Second):
+		t.Error("Empty scheduler did not finish")
+	}
+}